@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/EnesDemirtas/restaurant-management/helpers"
+	"github.com/EnesDemirtas/restaurant-management/services"
+	"github.com/gin-gonic/gin"
+)
+
+func GetBusinessDays() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allDays, err := services.GetBusinessDays(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, allDays)
+	}
+}
+
+func GetBusinessDay() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		day, err := services.GetBusinessDay(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, day)
+	}
+}
+
+func GetCurrentBusinessDay() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		day, err := services.GetCurrentBusinessDay(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, day)
+	}
+}
+
+func OpenBusinessDay() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := services.OpenBusinessDay(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+func AddCashDrop() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := services.AddCashDrop(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+func GetBusinessDayReport() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report, err := services.GetBusinessDayReport(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+func CloseBusinessDay() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := services.CloseBusinessDay(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/EnesDemirtas/restaurant-management/helpers"
+	"github.com/EnesDemirtas/restaurant-management/services"
+	"github.com/gin-gonic/gin"
+)
+
+func GetCombos() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allCombos, err := services.GetCombos(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, allCombos)
+	}
+}
+
+func GetCombo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		combo, err := services.GetCombo(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, combo)
+	}
+}
+
+func CreateCombo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := services.CreateCombo(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+func UpdateCombo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := services.UpdateCombo(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/EnesDemirtas/restaurant-management/helpers"
+	"github.com/EnesDemirtas/restaurant-management/services"
+	"github.com/gin-gonic/gin"
+)
+
+func GetExchangeRates() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allExchangeRates, err := services.GetExchangeRates(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, allExchangeRates)
+	}
+}
+
+func GetExchangeRate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exchangeRate, err := services.GetExchangeRate(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, exchangeRate)
+	}
+}
+
+func CreateExchangeRate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := services.CreateExchangeRate(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+func UpdateExchangeRate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := services.UpdateExchangeRate(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	}
+}
@@ -54,3 +54,15 @@ func UpdateFood() gin.HandlerFunc {
 		c.JSON(http.StatusOK, result)
 	}
 }
+
+func SetFoodAvailability() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := services.SetFoodAvailability(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/EnesDemirtas/restaurant-management/helpers"
@@ -32,6 +33,22 @@ func GetInvoice() gin.HandlerFunc {
 	}
 }
 
+func GetReceipt() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		receipt, err := services.GetReceipt(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		if receipt.ContentType == "application/pdf" {
+			c.Header("Content-Disposition", fmt.Sprintf("inline; filename=\"invoice-%s.pdf\"", c.Param("id")))
+		}
+
+		c.Data(http.StatusOK, receipt.ContentType, receipt.Body)
+	}
+}
+
 func CreateInvoice() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		result, err := services.CreateInvoice(c)
@@ -40,6 +57,12 @@ func CreateInvoice() gin.HandlerFunc {
 			return
 		}
 
+		// A bill that is not split is answered with the invoice alone.
+		if len(result) == 1 && result[0].SplitGroupID == nil {
+			c.JSON(http.StatusOK, result[0])
+			return
+		}
+
 		c.JSON(http.StatusOK, result)
 	}
 }
@@ -55,3 +78,51 @@ func UpdateInvoice() gin.HandlerFunc {
 		c.JSON(http.StatusOK, result)
 	}
 }
+
+func GetTips() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tips, err := services.GetTips(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, tips)
+	}
+}
+
+func GetPayments() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payments, err := services.GetPayments(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, payments)
+	}
+}
+
+func CreatePayment() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := services.CreatePayment(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+func HandlePaymentWebhook() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payment, err := services.HandlePaymentWebhook(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, payment)
+	}
+}
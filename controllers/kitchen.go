@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/helpers"
+	"github.com/EnesDemirtas/restaurant-management/services"
+	"github.com/gin-gonic/gin"
+)
+
+func GetKitchenTickets() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tickets, err := services.GetKitchenTickets(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, tickets)
+	}
+}
+
+// StreamKitchen pushes kitchen events to the client as Server-Sent Events
+// until it disconnects. A ping is sent every 15 seconds so idle connections
+// are not dropped by proxies.
+func StreamKitchen() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		events, unsubscribe := services.SubscribeKitchen()
+		defer unsubscribe()
+
+		ping := time.NewTicker(15 * time.Second)
+		defer ping.Stop()
+
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case event := <-events:
+				c.SSEvent(event.Type, event)
+				return true
+			case <-ping.C:
+				c.SSEvent("ping", time.Now().Unix())
+				return true
+			case <-c.Request.Context().Done():
+				return false
+			}
+		})
+	}
+}
+
+func UpdateKitchenStatus() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ticket, err := services.UpdateKitchenStatus(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, ticket)
+	}
+}
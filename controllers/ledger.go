@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/EnesDemirtas/restaurant-management/helpers"
+	"github.com/EnesDemirtas/restaurant-management/services"
+	"github.com/gin-gonic/gin"
+)
+
+func RefundInvoice() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := services.RefundInvoice(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+func VoidInvoice() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := services.VoidInvoice(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+func VoidOrderItem() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := services.VoidOrderItem(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
@@ -19,6 +19,18 @@ func GetMenus() gin.HandlerFunc {
 	}
 }
 
+func GetActiveMenus() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		activeMenus, err := services.GetActiveMenus(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, activeMenus)
+	}
+}
+
 func GetMenu() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		menu, err := services.GetMenu(c)
@@ -52,3 +52,15 @@ func UpdateOrder() gin.HandlerFunc {
 		c.JSON(http.StatusOK, result)
 	}
 }
+
+func UpdateOrderStatus() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		order, err := services.UpdateOrderStatus(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, order)
+	}
+}
@@ -22,7 +22,7 @@ func GetOrderItems() gin.HandlerFunc {
 
 func GetOrderItemsByOrder() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		orderId := c.Param("order_id")
+		orderId := c.Param("id")
 		allOrderItems, err := services.ItemsByOrder(orderId)
 
 		if err != nil {
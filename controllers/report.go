@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/EnesDemirtas/restaurant-management/helpers"
+	"github.com/EnesDemirtas/restaurant-management/services"
+	"github.com/gin-gonic/gin"
+)
+
+func GetRevenueReport() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report, err := services.GetRevenueReport(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+func GetTicketReport() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report, err := services.GetTicketReport(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+func GetTableReport() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report, err := services.GetTableReport(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+func GetTopFoodsReport() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report, err := services.GetTopFoodsReport(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+func GetCategoryReport() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report, err := services.GetCategoryReport(c)
+		if err != nil {
+			c.JSON(err.(helpers.HttpError).GetFields())
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
@@ -3,25 +3,22 @@ package database
 import (
 	"context"
 	"fmt"
-	"log"
 	"os"
 
-	"github.com/joho/godotenv"
+	"github.com/EnesDemirtas/restaurant-management/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func DBInstance() *mongo.Client {
-	if err := godotenv.Load(".env"); err != nil {
-		log.Fatalf("Error loading .env file: %s", err)
-	}
-
 	connString := os.Getenv("CONN_STRING")
 
 	// Use the SetServerAPIOptions() method to set the version of the Stable API on the client
 	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
-	opts := options.Client().ApplyURI(connString).SetServerAPIOptions(serverAPI)
+	// Fall back to the json tags so documents are stored with the same snake_case keys the queries use
+	bsonOpts := &options.BSONOptions{UseJSONStructTags: true}
+	opts := options.Client().ApplyURI(connString).SetServerAPIOptions(serverAPI).SetBSONOptions(bsonOpts).SetRegistry(models.NewBSONRegistry())
 
 	// Create a new client and connect to the server
 	client, err := mongo.Connect(context.TODO(), opts)
@@ -37,8 +34,6 @@ func DBInstance() *mongo.Client {
 	return client
 }
 
-var Client *mongo.Client = DBInstance()
-
 func OpenCollection(client *mongo.Client, collectionName string) *mongo.Collection {
 	var collection *mongo.Collection = client.Database("restaurant-management").Collection(collectionName)
 
@@ -0,0 +1,165 @@
+package gateway
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	OutcomeApprove = "approve"
+	OutcomeDecline = "decline"
+	OutcomeTimeout = "timeout"
+)
+
+// mockTokens are card tokens that force an outcome whatever the mock is
+// configured with, to try every path without restarting the server.
+var mockTokens = map[string]string{
+	"tok_approve": OutcomeApprove,
+	"tok_decline": OutcomeDecline,
+	"tok_timeout": OutcomeTimeout,
+}
+
+// Mock is a provider simulated in process. It approves, declines or never
+// answers every authorization depending on its outcome, and keeps the
+// transactions in memory so captures and refunds are checked against them.
+// Webhooks are signed with the hex HMAC-SHA256 of the payload, and refused
+// when no secret is configured.
+type Mock struct {
+	outcome string
+	secret  []byte
+
+	mu           sync.Mutex
+	transactions map[string]*Transaction
+	refunded     map[string]models.Money
+}
+
+func NewMock(outcome, webhookSecret string) (*Mock, error) {
+	if outcome == "" {
+		outcome = OutcomeApprove
+	}
+
+	if outcome != OutcomeApprove && outcome != OutcomeDecline && outcome != OutcomeTimeout {
+		return nil, fmt.Errorf("unknown mock outcome %q, expected approve, decline or timeout", outcome)
+	}
+
+	return &Mock{
+		outcome:      outcome,
+		secret:       []byte(webhookSecret),
+		transactions: map[string]*Transaction{},
+		refunded:     map[string]models.Money{},
+	}, nil
+}
+
+func (m *Mock) Name() string {
+	return "mock"
+}
+
+func (m *Mock) Authorize(ctx context.Context, request AuthorizeRequest) (Transaction, error) {
+	outcome := m.outcome
+	if forced, ok := mockTokens[request.Token]; ok {
+		outcome = forced
+	}
+
+	switch outcome {
+	case OutcomeDecline:
+		return Transaction{}, ErrDeclined
+	case OutcomeTimeout:
+		<-ctx.Done()
+		return Transaction{}, ErrTimeout
+	}
+
+	transaction := Transaction{
+		ID:     "mock_" + primitive.NewObjectID().Hex(),
+		Status: StatusAuthorized,
+		Amount: request.Amount,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.transactions[transaction.ID] = &transaction
+
+	return transaction, nil
+}
+
+func (m *Mock) Capture(ctx context.Context, transactionId string, amount models.Money) (Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	transaction, ok := m.transactions[transactionId]
+	if !ok || transaction.Status != StatusAuthorized || amount.Amount > transaction.Amount.Amount {
+		return Transaction{}, ErrDeclined
+	}
+
+	transaction.Status = StatusCaptured
+	transaction.Amount = amount
+
+	return *transaction, nil
+}
+
+func (m *Mock) Void(ctx context.Context, transactionId string) (Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	transaction, ok := m.transactions[transactionId]
+	if !ok || transaction.Status != StatusAuthorized {
+		return Transaction{}, ErrDeclined
+	}
+
+	transaction.Status = StatusVoided
+
+	return *transaction, nil
+}
+
+func (m *Mock) Refund(ctx context.Context, transactionId string, amount models.Money) (Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	transaction, ok := m.transactions[transactionId]
+	if !ok || transaction.Status == StatusAuthorized {
+		return Transaction{}, ErrDeclined
+	}
+
+	refunded := m.refunded[transactionId].Add(amount)
+	if refunded.Amount > transaction.Amount.Amount {
+		return Transaction{}, ErrDeclined
+	}
+	m.refunded[transactionId] = refunded
+
+	if refunded.Amount == transaction.Amount.Amount {
+		transaction.Status = StatusRefunded
+	}
+
+	return Transaction{
+		ID:     "mock_" + primitive.NewObjectID().Hex(),
+		Status: StatusRefunded,
+		Amount: amount,
+	}, nil
+}
+
+func (m *Mock) VerifyWebhook(payload []byte, signature string) (Event, error) {
+	if len(m.secret) == 0 || !hmac.Equal([]byte(m.Sign(payload)), []byte(signature)) {
+		return Event{}, ErrInvalidSignature
+	}
+
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return Event{}, err
+	}
+
+	return event, nil
+}
+
+// Sign returns the signature the mock expects on a webhook payload.
+func (m *Mock) Sign(payload []byte) string {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
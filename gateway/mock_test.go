@@ -0,0 +1,146 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+)
+
+func usd(amount int64) models.Money {
+	return models.Money{Amount: amount, Currency: "USD"}
+}
+
+func TestMockAuthorize(t *testing.T) {
+	tests := []struct {
+		name    string
+		outcome string
+		token   string
+		wantErr error
+	}{
+		{"approved", OutcomeApprove, "", nil},
+		{"declined", OutcomeDecline, "", ErrDeclined},
+		{"timed out", OutcomeTimeout, "", ErrTimeout},
+		{"approved by the token", OutcomeDecline, "tok_approve", nil},
+		{"declined by the token", OutcomeApprove, "tok_decline", ErrDeclined},
+		{"timed out by the token", OutcomeApprove, "tok_timeout", ErrTimeout},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mock, err := NewMock(test.outcome, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			transaction, err := mock.Authorize(ctx, AuthorizeRequest{Amount: usd(1000), Token: test.token})
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("got %v, want %v", err, test.wantErr)
+			}
+
+			if test.wantErr == nil && (transaction.Status != StatusAuthorized || transaction.Amount != usd(1000)) {
+				t.Errorf("got %+v, want 10.00 authorized", transaction)
+			}
+		})
+	}
+}
+
+func TestNewMockUnknownOutcome(t *testing.T) {
+	if _, err := NewMock("maybe", ""); err == nil {
+		t.Error("got no error, want the outcome refused")
+	}
+}
+
+func TestMockTransactions(t *testing.T) {
+	ctx := context.Background()
+	mock, err := NewMock(OutcomeApprove, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authorization, err := mock.Authorize(ctx, AuthorizeRequest{Amount: usd(1000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name    string
+		call    func() (Transaction, error)
+		wantErr error
+	}{
+		{"refund before the capture", func() (Transaction, error) { return mock.Refund(ctx, authorization.ID, usd(100)) }, ErrDeclined},
+		{"capture more than held", func() (Transaction, error) { return mock.Capture(ctx, authorization.ID, usd(1001)) }, ErrDeclined},
+		{"capture less than held", func() (Transaction, error) { return mock.Capture(ctx, authorization.ID, usd(800)) }, nil},
+		{"capture again", func() (Transaction, error) { return mock.Capture(ctx, authorization.ID, usd(800)) }, ErrDeclined},
+		{"void once captured", func() (Transaction, error) { return mock.Void(ctx, authorization.ID) }, ErrDeclined},
+		{"refund part", func() (Transaction, error) { return mock.Refund(ctx, authorization.ID, usd(500)) }, nil},
+		{"refund more than is left", func() (Transaction, error) { return mock.Refund(ctx, authorization.ID, usd(301)) }, ErrDeclined},
+		{"refund the rest", func() (Transaction, error) { return mock.Refund(ctx, authorization.ID, usd(300)) }, nil},
+		{"unknown transaction", func() (Transaction, error) { return mock.Capture(ctx, "mock_unknown", usd(1)) }, ErrDeclined},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			if _, err := step.call(); !errors.Is(err, step.wantErr) {
+				t.Fatalf("got %v, want %v", err, step.wantErr)
+			}
+		})
+	}
+
+	voided, err := mock.Authorize(ctx, AuthorizeRequest{Amount: usd(1000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := mock.Void(ctx, voided.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := mock.Capture(ctx, voided.ID, usd(1000)); !errors.Is(err, ErrDeclined) {
+		t.Errorf("got %v capturing a voided hold, want ErrDeclined", err)
+	}
+}
+
+func TestMockVerifyWebhook(t *testing.T) {
+	payload := []byte(`{"type":"chargeback","transaction_id":"mock_1","status":"CHARGED_BACK"}`)
+
+	signed, err := NewMock(OutcomeApprove, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unsigned, err := NewMock(OutcomeApprove, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		mock      *Mock
+		signature string
+		wantErr   error
+	}{
+		{"valid signature", signed, signed.Sign(payload), nil},
+		{"wrong signature", signed, unsigned.Sign(payload), ErrInvalidSignature},
+		{"no signature", signed, "", ErrInvalidSignature},
+		{"no secret", unsigned, unsigned.Sign(payload), ErrInvalidSignature},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			event, err := test.mock.VerifyWebhook(payload, test.signature)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("got %v, want %v", err, test.wantErr)
+			}
+
+			if test.wantErr == nil && (event.TransactionID != "mock_1" || event.Status != "CHARGED_BACK") {
+				t.Errorf("got %+v, want the chargeback of mock_1", event)
+			}
+		})
+	}
+}
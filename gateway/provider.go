@@ -0,0 +1,91 @@
+// Package gateway talks to the card processors payments are charged through.
+// Services only use the Provider interface, so a processor is added by
+// implementing it and registering it in New.
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+)
+
+var (
+	ErrDeclined         = errors.New("payment was declined")
+	ErrTimeout          = errors.New("payment provider did not answer in time")
+	ErrInvalidSignature = errors.New("webhook signature is invalid")
+)
+
+const (
+	StatusAuthorized = "AUTHORIZED"
+	StatusCaptured   = "CAPTURED"
+	StatusRefunded   = "REFUNDED"
+	StatusVoided     = "VOIDED"
+	StatusFailed     = "FAILED"
+)
+
+// AuthorizeRequest is a card payment to hold on the customer's card. Token is
+// the card as tokenized by the provider's terminal or payment form, Reference
+// the invoice the payment is for.
+type AuthorizeRequest struct {
+	Amount    models.Money
+	Token     string
+	Reference string
+}
+
+// Transaction is an operation on a card as the provider recorded it.
+type Transaction struct {
+	ID     string
+	Status string
+	Amount models.Money
+}
+
+// Event is a notification sent by the provider about a transaction, such as
+// a capture settling or a chargeback.
+type Event struct {
+	Type          string `json:"type"`
+	TransactionID string `json:"transaction_id"`
+	Status        string `json:"status"`
+}
+
+// Provider is a card processor. Authorize holds an amount on a card, Capture
+// charges what was held, Void releases a hold that was not captured and Refund
+// gives back some of what was charged.
+// Calls return ErrDeclined when the processor refuses them and ErrTimeout when
+// it does not answer before the context is done.
+type Provider interface {
+	Name() string
+	Authorize(ctx context.Context, request AuthorizeRequest) (Transaction, error)
+	Capture(ctx context.Context, transactionId string, amount models.Money) (Transaction, error)
+	Void(ctx context.Context, transactionId string) (Transaction, error)
+	Refund(ctx context.Context, transactionId string, amount models.Money) (Transaction, error)
+	// VerifyWebhook checks the signature of a notification sent by the
+	// provider and reads it.
+	VerifyWebhook(payload []byte, signature string) (Event, error)
+}
+
+// New returns the provider configured with PAYMENT_PROVIDER, the mock one
+// unless another is set.
+func New() (Provider, error) {
+	switch name := os.Getenv("PAYMENT_PROVIDER"); name {
+	case "", "mock":
+		return NewMock(os.Getenv("PAYMENT_MOCK_OUTCOME"), os.Getenv("PAYMENT_WEBHOOK_SECRET"))
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", name)
+	}
+}
+
+// Timeout is how long a provider is given to answer, configured in seconds
+// with PAYMENT_TIMEOUT and defaulting to 30 seconds.
+func Timeout() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("PAYMENT_TIMEOUT"))
+	if err != nil || seconds <= 0 {
+		seconds = 30
+	}
+
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,93 @@
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// TextPDF lays out lines of monospaced text on pages of the given size, in
+// points, and returns them as a PDF document. It uses the Courier font every
+// PDF reader has built in, so nothing has to be embedded; characters the
+// font's encoding lacks are printed as '?'.
+func TextPDF(lines []string, pageWidth, pageHeight, fontSize, margin float64) []byte {
+	leading := fontSize * 1.2
+
+	linesPerPage := int((pageHeight - 2*margin) / leading)
+	if linesPerPage < 1 {
+		linesPerPage = 1
+	}
+
+	var pages [][]string
+	for start := 0; start < len(lines); start += linesPerPage {
+		pages = append(pages, lines[start:min(start+linesPerPage, len(lines))])
+	}
+	if len(pages) == 0 {
+		pages = [][]string{{}}
+	}
+
+	// Objects 1 and 2 are the catalog and the page tree, 3 the font, then
+	// every page is followed by its content stream.
+	var objects []string
+	var kids []string
+	for i, page := range pages {
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "BT\n/F1 %.2f Tf\n%.2f TL\n%.2f %.2f Td\n", fontSize, leading, margin, pageHeight-margin)
+		for _, line := range page {
+			fmt.Fprintf(&content, "(%s) '\n", pdfString(line))
+		}
+		content.WriteString("ET")
+
+		pageObject := 4 + 2*i
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageObject))
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, pageObject+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		)
+	}
+
+	objects = append([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+	}, objects...)
+
+	var document bytes.Buffer
+	document.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = document.Len()
+		fmt.Fprintf(&document, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := document.Len()
+	fmt.Fprintf(&document, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&document, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&document, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return document.Bytes()
+}
+
+// pdfString escapes text for a PDF string literal in the Latin-1 range of
+// WinAnsiEncoding.
+func pdfString(text string) string {
+	var escaped strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			escaped.WriteByte('\\')
+			escaped.WriteRune(r)
+		case r < 0x20 || r > 0xff || (r >= 0x7f && r < 0xa0):
+			escaped.WriteByte('?')
+		case r > 0x7f:
+			fmt.Fprintf(&escaped, "\\%03o", r)
+		default:
+			escaped.WriteRune(r)
+		}
+	}
+
+	return escaped.String()
+}
@@ -1,18 +1,14 @@
 package helpers
 
 import (
-	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"log"
 	"os"
 	"time"
 
-	"github.com/EnesDemirtas/restaurant-management/database"
 	"github.com/dgrijalva/jwt-go"
-	"github.com/joho/godotenv"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type SignedDetails struct {
@@ -20,32 +16,49 @@ type SignedDetails struct {
 	FirstName string
 	LastName  string
 	Uid       string
+	Role      string
+	Family    string
+	TokenType string
 	jwt.StandardClaims
 }
 
-var userCollection *mongo.Collection = database.OpenCollection(database.Client, "user")
+const (
+	AccessTokenType  = "access"
+	RefreshTokenType = "refresh"
+)
+
+// RefreshTokenLifetime is how long a refresh token can be used, after which
+// the session it belongs to is over.
+const RefreshTokenLifetime = 8 * time.Hour
 
-func GenerateAllTokens(email, firstName, lastName, uid string) (string, string, error) {
+// GenerateAllTokens issues an access and a refresh token. Both carry the
+// family of the session they belong to, so that revoking the family on the
+// user invalidates every token issued for that session.
+func GenerateAllTokens(email, firstName, lastName, uid, role, family string) (string, string, error) {
 	claims := &SignedDetails{
 		Email:     email,
 		FirstName: firstName,
 		LastName:  lastName,
 		Uid:       uid,
+		Role:      role,
+		Family:    family,
+		TokenType: AccessTokenType,
 		StandardClaims: jwt.StandardClaims{
+			Id:        newTokenID(),
 			ExpiresAt: time.Now().Local().Add(time.Hour * time.Duration(4)).Unix(),
 		},
 	}
 
 	refreshClaims := &SignedDetails{
+		Uid:       uid,
+		Family:    family,
+		TokenType: RefreshTokenType,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(time.Hour * time.Duration(8)).Unix(),
+			Id:        newTokenID(),
+			ExpiresAt: time.Now().Local().Add(RefreshTokenLifetime).Unix(),
 		},
 	}
 
-	if err := godotenv.Load(".env"); err != nil {
-		log.Fatalf("Error loading .env file: %s", err)
-	}
-
 	secretKey := os.Getenv("SECRET_KEY")
 
 	signedToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secretKey))
@@ -61,61 +74,46 @@ func GenerateAllTokens(email, firstName, lastName, uid string) (string, string,
 	return signedToken, refreshToken, err
 }
 
-func UpdateAllTokens(signedToken, signedRefreshToken, userId string) {
-	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-	defer cancel()
-
-	var updateObj primitive.D
-
-	updateObj = append(updateObj, bson.E{Key: "token", Value: signedToken})
-	updateObj = append(updateObj, bson.E{Key: "refresh_token", Value: signedRefreshToken})
-
-	updated_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-
-	updateObj = append(updateObj, bson.E{Key: "updated_at", Value: updated_at})
-
-	upsert := true
-	filter := bson.M{"user_id": userId}
-	opt := options.UpdateOptions{
-		Upsert: &upsert,
-	}
-
-	_, err := userCollection.UpdateOne(
-		ctx,
-		filter,
-		bson.D{
-			{Key: "$set", Value: updateObj},
-		},
-		&opt,
-	)
-
-	if err != nil {
-		log.Panic(err)
-		return
-	}
-}
-
 func ValidateToken(signedToken string) (claims *SignedDetails, msg string) {
-	if err := godotenv.Load(".env"); err != nil {
-		log.Fatalf("Error loading .env file: %s", err)
-	}
-
 	secretKey := os.Getenv("SECRET_KEY")
 
 	token, err := jwt.ParseWithClaims(signedToken, &SignedDetails{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(secretKey), nil
 	})
 
-	claims, ok := token.Claims.(*SignedDetails)
-	if !ok {
+	if err != nil {
 		msg = err.Error()
 		return nil, msg
 	}
 
+	claims, ok := token.Claims.(*SignedDetails)
+	if !ok || !token.Valid {
+		msg = "the token is invalid"
+		return nil, msg
+	}
+
 	if claims.ExpiresAt < time.Now().Local().Unix() {
-		msg = err.Error()
+		msg = "the token has expired"
 		return nil, msg
 	}
 
 	return claims, msg
 }
+
+// HashToken returns the hex SHA-256 of a token, which is what is stored in
+// place of the token itself.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newTokenID returns a random id so that two tokens issued within the same
+// second never end up identical.
+func newTokenID() string {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		log.Panic(err)
+	}
+
+	return hex.EncodeToString(bytes)
+}
@@ -1,35 +1,95 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"log"
 	"os"
 
 	"github.com/EnesDemirtas/restaurant-management/database"
+	"github.com/EnesDemirtas/restaurant-management/gateway"
 	"github.com/EnesDemirtas/restaurant-management/middlewares"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/EnesDemirtas/restaurant-management/notify"
+	"github.com/EnesDemirtas/restaurant-management/repositories"
 	"github.com/EnesDemirtas/restaurant-management/routes"
+	"github.com/EnesDemirtas/restaurant-management/services"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/mongo"
+	"github.com/joho/godotenv"
 )
 
-var foodCollection *mongo.Collection = database.OpenCollection(database.Client, "food")
-
 func main() {
+	if err := godotenv.Load(".env"); err != nil {
+		log.Printf("No .env file loaded: %s", err)
+	}
+
+	migrate := flag.Bool("migrate", false, "convert the amounts of money stored in MongoDB to minor units and exit")
+	flag.Parse()
+
+	if currency := os.Getenv("CURRENCY"); currency != "" {
+		models.DefaultCurrency = currency
+	}
+
+	if *migrate {
+		migrated, err := repositories.MigrateMoney(context.Background(), database.DBInstance())
+		if err != nil {
+			log.Fatalf("Migration failed after %d documents: %s", migrated, err)
+		}
+		log.Printf("Migrated %d documents", migrated)
+		return
+	}
+
 	port := os.Getenv("PORT")
 
 	if port == "" {
 		port = "8000"
 	}
 
+	switch os.Getenv("STORAGE") {
+	case "memory":
+		services.UseStore(repositories.NewMemoryStore())
+	case "", "mongo":
+		client := database.DBInstance()
+		if err := repositories.EnsureIndexes(context.Background(), client); err != nil {
+			log.Fatalf("Creating indexes failed: %s", err)
+		}
+		services.UseStore(repositories.NewMongoStore(client))
+	default:
+		log.Fatalf("Unknown STORAGE %q, expected \"mongo\" or \"memory\"", os.Getenv("STORAGE"))
+	}
+
+	provider, err := gateway.New()
+	if err != nil {
+		log.Fatalf("Payment provider failed: %s", err)
+	}
+	services.UsePaymentProvider(provider)
+
+	notifier, err := notify.New()
+	if err != nil {
+		log.Fatalf("Notifier failed: %s", err)
+	}
+	services.UseNotifier(notifier)
+	go services.RunOverdueJob(context.Background())
+
 	router := gin.New()
 	router.Use(gin.Logger())
 	routes.User(router)
+	routes.PaymentWebhook(router)
 	router.Use(middlewares.Authentication())
 
 	routes.Food(router)
 	routes.Menu(router)
+	routes.Combo(router)
 	routes.Table(router)
 	routes.Order(router)
 	routes.OrderItem(router)
 	routes.Invoice(router)
+	routes.Kitchen(router)
+	routes.TaxRate(router)
+	routes.Promotion(router)
+	routes.ExchangeRate(router)
+	routes.BusinessDay(router)
+	routes.Report(router)
 
 	router.Run(":" + port)
 }
\ No newline at end of file
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/EnesDemirtas/restaurant-management/helpers"
+	"github.com/EnesDemirtas/restaurant-management/services"
 	"github.com/gin-gonic/gin"
 )
 
@@ -23,11 +24,44 @@ func Authentication() gin.HandlerFunc {
 			return
 		}
 
+		if claims.TokenType != helpers.AccessTokenType {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "The token has been revoked"})
+			c.Abort()
+			return
+		}
+
+		role, active := services.SessionRole(claims.Uid, claims.Family)
+		if !active {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "The token has been revoked"})
+			c.Abort()
+			return
+		}
+
 		c.Set("email", claims.Email)
 		c.Set("first_name", claims.FirstName)
 		c.Set("last_name", claims.LastName)
 		c.Set("uid", claims.Uid)
+		c.Set("role", role)
+		c.Set("family", claims.Family)
 
 		c.Next()
 	}
+}
+
+// Authorization only lets the request through when the authenticated user has
+// one of the given roles. It must run after Authentication.
+func Authorization(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to perform this action"})
+		c.Abort()
+	}
 }
\ No newline at end of file
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	BusinessDayOpen		= "OPEN"
+	BusinessDayClosed	= "CLOSED"
+)
+
+// CashDrop is cash taken out of the drawer during the day, e.g. to the safe.
+type CashDrop struct {
+	CashDropID		string				`json:"cash_drop_id"`
+	Amount			Money				`json:"amount"`
+	Note			*string				`json:"note"`
+	RecordedBy		string				`json:"recorded_by"`
+	CreatedAt		time.Time			`json:"created_at"`
+}
+
+// BusinessDay is the time the restaurant is open, from the opening float put
+// in the cash drawer to the count of the drawer at closing. Only one day is
+// open at a time, and once closed the Report is kept and the invoices of the
+// day can no longer be changed.
+type BusinessDay struct {
+	ID 				primitive.ObjectID 	`bson:"_id"`
+	Status			string				`json:"status"`
+	OpeningFloat	*Money				`json:"opening_float" validate:"required"`
+	CashDrops		[]CashDrop			`json:"cash_drops"`
+	CountedCash		*Money				`json:"counted_cash"`
+	Report			*ZReport			`json:"report"`
+	OpenedBy		string				`json:"opened_by"`
+	OpenedAt		time.Time			`json:"opened_at"`
+	ClosedBy		*string				`json:"closed_by"`
+	ClosedAt		*time.Time			`json:"closed_at"`
+	BusinessDayID	string				`json:"business_day_id"`
+}
+
+// MethodTotal is the total of the payments or refunds made with one method.
+type MethodTotal struct {
+	Method			string				`json:"method"`
+	Count			int					`json:"count"`
+	Amount			Money				`json:"amount"`
+	Tips			Money				`json:"tips"`
+}
+
+// WaiterTips is the total of the tips a waiter received.
+type WaiterTips struct {
+	WaiterID		string				`json:"waiter_id"`
+	Tips			Money				`json:"tips"`
+	Payments		int					`json:"payments"`
+}
+
+// ZReport totals a business day: the invoices created during it, the payments
+// and refunds made during it, and the cash expected in the drawer, which is
+// the opening float plus the cash received, tips included, less cash refunds
+// and drops. ExpectedCash is the cash in the base currency, which the float,
+// the drops and the counted cash are in. Cash received in other currencies is
+// expected in ExpectedForeignCash, one total per currency.
+type ZReport struct {
+	From			time.Time			`json:"from"`
+	To				time.Time			`json:"to"`
+	Invoices		int					`json:"invoices"`
+	VoidedInvoices	int					`json:"voided_invoices"`
+	DiscountTotal	Money				`json:"discount_total"`
+	Subtotal		Money				`json:"subtotal"`
+	ServiceCharge	Money				`json:"service_charge"`
+	TaxLines		[]InvoiceTaxLine	`json:"tax_lines"`
+	TaxTotal		Money				`json:"tax_total"`
+	GrandTotal		Money				`json:"grand_total"`
+	Payments		[]MethodTotal		`json:"payments"`
+	Refunds			[]MethodTotal		`json:"refunds"`
+	RefundTotal		Money				`json:"refund_total"`
+	Tips			[]WaiterTips		`json:"tips"`
+	TipTotal		Money				`json:"tip_total"`
+	OpeningFloat	Money				`json:"opening_float"`
+	CashDrops		Money				`json:"cash_drops"`
+	ExpectedCash	Money				`json:"expected_cash"`
+	ExpectedForeignCash	[]Money			`json:"expected_foreign_cash"`
+	CountedCash		*Money				`json:"counted_cash"`
+	CashDifference	*Money				`json:"cash_difference"`
+}
\ No newline at end of file
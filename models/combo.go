@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ComboChoice is a food that can fill a slot of a combo, as its VariantID if
+// the food has variants, for its Upcharge on top of the price of the combo.
+type ComboChoice struct {
+	FoodID			*string				`json:"food_id" validate:"required"`
+	VariantID		*string				`json:"variant_id"`
+	Upcharge		Money				`json:"upcharge"`
+}
+
+// ComboSlot is a part of a combo, such as the side, filled with one of its
+// Choices.
+type ComboSlot struct {
+	SlotID			string				`json:"slot_id"`
+	Name			*string				`json:"name" validate:"required,min=1,max=100"`
+	Choices			[]ComboChoice		`json:"choices" validate:"required,min=1,dive"`
+}
+
+// Combo is a set of foods sold together at a fixed Price, such as a burger,
+// a side and a drink, one food being chosen for each of its Slots.
+type Combo struct {
+	ID 				primitive.ObjectID 	`bson:"_id"`
+	Name			*string				`json:"name" validate:"required,min=2,max=100"`
+	Price			*Money				`json:"price" validate:"required"`
+	Slots			[]ComboSlot			`json:"slots" validate:"required,min=1,dive"`
+	CreatedAt		time.Time			`json:"created_at"`
+	UpdatedAt		time.Time			`json:"updated_at"`
+	ComboID			string				`json:"combo_id"`
+}
\ No newline at end of file
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExchangeRate is the value of one unit of the base currency in Currency from
+// EffectiveFrom on, until a rate with a later EffectiveFrom takes over.
+type ExchangeRate struct {
+	ID 				primitive.ObjectID 	`bson:"_id"`
+	Currency		*string				`json:"currency" validate:"required,len=3,alpha"`
+	Rate			*float64			`json:"rate" validate:"required,gt=0"`
+	EffectiveFrom	*time.Time			`json:"effective_from" validate:"required"`
+	CreatedAt		time.Time			`json:"created_at"`
+	UpdatedAt		time.Time			`json:"updated_at"`
+	ExchangeRateID	string				`json:"exchange_rate_id"`
+}
\ No newline at end of file
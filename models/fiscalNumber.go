@@ -0,0 +1,35 @@
+package models
+
+import "fmt"
+
+const (
+	FiscalSeriesInvoice		= "INV"
+	FiscalSeriesCreditNote	= "CN"
+)
+
+// FiscalNumber is the legal number of an invoice or credit note. Documents are
+// numbered from 1 in the series of their restaurant for each year, without
+// gaps. Number is the whole of it as printed, e.g. INV-2024-000042.
+type FiscalNumber struct {
+	Restaurant		string				`json:"restaurant"`
+	Series			string				`json:"series"`
+	Year			int					`json:"year"`
+	Sequence		int64				`json:"sequence"`
+	Number			string				`json:"number"`
+}
+
+// WithSequence returns the number in the same series at the sequence.
+func (n FiscalNumber) WithSequence(sequence int64) FiscalNumber {
+	n.Sequence = sequence
+	n.Number = fmt.Sprintf("%s-%d-%06d", n.Series, n.Year, sequence)
+	if n.Restaurant != "" {
+		n.Number = n.Restaurant + "-" + n.Number
+	}
+
+	return n
+}
+
+// InSeries tells whether the number belongs to the series of the other one.
+func (n FiscalNumber) InSeries(other FiscalNumber) bool {
+	return n.Restaurant == other.Restaurant && n.Series == other.Series && n.Year == other.Year
+}
\ No newline at end of file
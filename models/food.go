@@ -6,13 +6,54 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ModifierOption is one choice of a modifier group, such as "no onions" or
+// "extra cheese". Its PriceDelta is added to the price of the food.
+type ModifierOption struct {
+	OptionID   string  `json:"option_id"`
+	Name       *string `json:"name" validate:"required,min=1,max=100"`
+	PriceDelta Money   `json:"price_delta"`
+}
+
+// ModifierGroup is a set of options offered with a food, such as how a steak
+// is cooked. At least MinSelections of its options, one if it is Required,
+// and at most MaxSelections of them, unless it is 0, are picked.
+type ModifierGroup struct {
+	GroupID       string           `json:"group_id"`
+	Name          *string          `json:"name" validate:"required,min=1,max=100"`
+	Required      bool             `json:"required"`
+	MinSelections int              `json:"min_selections" validate:"min=0"`
+	MaxSelections int              `json:"max_selections" validate:"omitempty,gtefield=MinSelections"`
+	Options       []ModifierOption `json:"options" validate:"required,min=1,dive"`
+}
+
+// FoodVariant is one size or kind a food is sold in, such as a large drink,
+// with its own price and stock keeping unit.
+type FoodVariant struct {
+	VariantID      string  `json:"variant_id"`
+	Name           *string `json:"name" validate:"required,min=1,max=100"`
+	Price          *Money  `json:"price" validate:"required"`
+	ConvertedPrice *Money  `json:"converted_price,omitempty" bson:"-"`
+	SKU            *string `json:"sku" validate:"omitempty,max=64"`
+}
+
+// Food prices are in the base currency. ConvertedPrice is only filled in when
+// foods are listed in another currency and is never stored. A food can't be
+// ordered when it is not Available, or when RemainingPortions is set and
+// fewer portions are left than ordered. ModifierGroups are the options it can
+// be ordered with. A food with Variants is ordered as one of them, at its
+// price, and needs no Price of its own.
 type Food struct {
-	ID			primitive.ObjectID 	`bson:"_id"`
-	Name		*string				`json:"name" validate:"required,min=2,max=100"`
-	Price		*float64			`json:"price" validate:"required"`
-	FoodImage	*string				`json:"food_image" validate:"required"`
-	CreatedAt	time.Time			`json:"created_at"`
-	UpdatedAt	time.Time			`json:"updated_at"`				
-	FoodID		string				`json:"food_id"`
-	MenuID		*string				`json:"menu_id" validate:"required"`
-}
\ No newline at end of file
+	ID                primitive.ObjectID `bson:"_id"`
+	Name              *string            `json:"name" validate:"required,min=2,max=100"`
+	Price             *Money             `json:"price" validate:"required_without=Variants"`
+	ConvertedPrice    *Money             `json:"converted_price,omitempty" bson:"-"`
+	FoodImage         *string            `json:"food_image" validate:"required"`
+	CreatedAt         time.Time          `json:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at"`
+	FoodID            string             `json:"food_id"`
+	MenuID            *string            `json:"menu_id" validate:"required"`
+	Available         *bool              `json:"available"`
+	RemainingPortions *int               `json:"remaining_portions" validate:"omitempty,min=0"`
+	Variants          []FoodVariant      `json:"variants" validate:"dive"`
+	ModifierGroups    []ModifierGroup    `json:"modifier_groups" validate:"dive"`
+}
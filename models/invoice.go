@@ -6,13 +6,127 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	PaymentStatusPending		= "PENDING"
+	PaymentStatusPartiallyPaid	= "PARTIALLY_PAID"
+	PaymentStatusPaid			= "PAID"
+	// PaymentStatusOverdue is set on invoices that are not paid in full by
+	// their PaymentDueDate.
+	PaymentStatusOverdue		= "OVERDUE"
+)
+
+const (
+	PaymentMethodCash		= "CASH"
+	PaymentMethodCard		= "CARD"
+	PaymentMethodGiftCard	= "GIFT_CARD"
+	// PaymentMethodMixed is set on invoices paid with more than one method.
+	PaymentMethodMixed		= "MIXED"
+)
+
+// InvoicePayment is money received for an invoice. For cash, Tendered is what
+// the customer handed over and ChangeDue what they got back. Tip is paid on
+// top of Amount and goes to the waiter of the order. Amounts are in the base
+// currency, ConvertedAmount is Amount in the Currency it was paid in. Card
+// payments charged through a payment provider keep its TransactionID and the
+// last ProviderStatus it reported.
+type InvoicePayment struct {
+	PaymentID		string				`json:"payment_id"`
+	Method			string				`json:"method"`
+	Amount			Money				`json:"amount"`
+	Tendered		*Money				`json:"tendered"`
+	Tip				Money				`json:"tip"`
+	ChangeDue		Money				`json:"change_due"`
+	Reference		*string				`json:"reference"`
+	Provider		*string				`json:"provider"`
+	TransactionID	*string				`json:"transaction_id"`
+	ProviderStatus	*string				`json:"provider_status"`
+	ReceivedBy		string				`json:"received_by"`
+	WaiterID		*string				`json:"waiter_id"`
+	Currency		string				`json:"currency"`
+	ExchangeRate	float64				`json:"exchange_rate"`
+	ConvertedAmount	Money				`json:"converted_amount"`
+	CreatedAt		time.Time			`json:"created_at"`
+}
+
+// InvoiceTaxLine is the total charged for one tax rate on an invoice, copied
+// from the rate at billing time.
+type InvoiceTaxLine struct {
+	TaxRateID		string				`json:"tax_rate_id"`
+	Name			string				`json:"name"`
+	Rate			float64				`json:"rate"`
+	Inclusive		bool				`json:"inclusive"`
+	Compound		bool				`json:"compound"`
+	TaxableAmount	Money				`json:"taxable_amount"`
+	Amount			Money				`json:"amount"`
+}
+
+// InvoiceLine is one order item as it was billed. Amount is what is charged
+// for it once Discount has been taken off. Its UnitPrice includes the price
+// deltas of its Modifiers. A combo is billed as one line, named after it.
+type InvoiceLine struct {
+	OrderItemID		string				`json:"order_item_id"`
+	FoodID			string				`json:"food_id"`
+	FoodName		string				`json:"food_name"`
+	Quantity		int					`json:"quantity"`
+	UnitPrice		Money				`json:"unit_price"`
+	VariantID		string				`json:"variant_id,omitempty"`
+	VariantName		string				`json:"variant_name,omitempty"`
+	Modifiers		[]SelectedModifier	`json:"modifiers,omitempty"`
+	ComboID			string				`json:"combo_id,omitempty"`
+	ComboComponents	[]ComboComponent	`json:"combo_components,omitempty"`
+	Discount		Money				`json:"discount"`
+	Amount			Money				`json:"amount"`
+}
+
+// InvoiceDiscount is the total discount given by one promotion on an invoice.
+type InvoiceDiscount struct {
+	PromotionID		string				`json:"promotion_id"`
+	Name			string				`json:"name"`
+	Type			string				`json:"type"`
+	Scope			string				`json:"scope"`
+	CouponCode		*string				`json:"coupon_code"`
+	Amount			Money				`json:"amount"`
+}
+
+// Invoice amounts are in the base currency. An invoice paid in another
+// currency also shows its grand total and balance in PaymentCurrency, at the
+// ExchangeRate in effect when it was last billed or paid. Overdue invoices
+// are reminded to BillingEmail, RemindersSent counts the reminders sent.
+// AmountRefunded and RefundedItems are what refunds have claimed so far, so
+// that two refunds can never take back the same money.
 type Invoice struct {
 	ID 				primitive.ObjectID 	`bson:"_id"`
 	InvoiceID		string				`json:"invoice_id"`
+	FiscalNumber	*FiscalNumber		`json:"fiscal_number"`
 	OrderID			string				`json:"order_id"`
-	PaymentMethod	*string				`json:"payment_method" validate:"eq=CARD|eq=CASH|eq="`
+	PaymentMethod	*string				`json:"payment_method" validate:"omitempty,eq=CARD|eq=CASH|eq=GIFT_CARD|eq="`
 	PaymentStatus	*string				`json:"payment_status" validate:"required,eq=PENDING|eq=PAID"`
 	PaymentDueDate	time.Time			`json:"payment_due_date"`
+	BillingEmail	*string				`json:"billing_email" validate:"omitempty,email"`
+	RemindersSent	int					`json:"reminders_sent"`
+	RemindedAt		*time.Time			`json:"reminded_at"`
+	CouponCodes		[]string			`json:"coupon_codes"`
+	Lines			[]InvoiceLine		`json:"lines"`
+	Discounts		[]InvoiceDiscount	`json:"discounts"`
+	DiscountTotal	*Money				`json:"discount_total"`
+	Subtotal		*Money				`json:"subtotal"`
+	ServiceCharge	*Money				`json:"service_charge"`
+	TaxLines		[]InvoiceTaxLine	`json:"tax_lines"`
+	TaxTotal		*Money				`json:"tax_total"`
+	GrandTotal		*Money				`json:"grand_total"`
+	Payments		[]InvoicePayment	`json:"payments"`
+	AmountPaid		*Money				`json:"amount_paid"`
+	Balance			*Money				`json:"balance"`
+	TipTotal		*Money				`json:"tip_total"`
+	AmountRefunded	*Money				`json:"amount_refunded"`
+	RefundedItems	[]string			`json:"refunded_items"`
+	PaymentCurrency	*string				`json:"payment_currency" validate:"omitempty,len=3,alpha"`
+	ExchangeRate	*float64			`json:"exchange_rate"`
+	ConvertedTotal	*Money				`json:"converted_total"`
+	ConvertedBalance	*Money			`json:"converted_balance"`
+	SplitGroupID	*string				`json:"split_group_id"`
+	SplitIndex		*int				`json:"split_index"`
+	SplitCount		*int				`json:"split_count"`
 	CreatedAt		time.Time			`json:"created_at"`
 	UpdatedAt		time.Time			`json:"updated_at"`
 }
\ No newline at end of file
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	LedgerEntryRefund	= "REFUND"
+	LedgerEntryVoid		= "VOID"
+)
+
+const (
+	ReasonCustomerComplaint	= "CUSTOMER_COMPLAINT"
+	ReasonWrongItem			= "WRONG_ITEM"
+	ReasonQualityIssue		= "QUALITY_ISSUE"
+	ReasonPricingError		= "PRICING_ERROR"
+	ReasonDuplicateCharge	= "DUPLICATE_CHARGE"
+	ReasonOther				= "OTHER"
+)
+
+// LedgerEntry records money or items taken back after the fact. Entries are
+// never changed: a refund or void is reflected in totals by adding up the
+// entries, not by editing the invoice or order item it applies to. Refunds
+// and voids of invoices are credit notes and get a FiscalNumber.
+type LedgerEntry struct {
+	ID 				primitive.ObjectID 	`bson:"_id"`
+	Type			string				`json:"type"`
+	OrderID			string				`json:"order_id"`
+	InvoiceID		*string				`json:"invoice_id"`
+	OrderItemID		*string				`json:"order_item_id"`
+	Amount			Money				`json:"amount"`
+	Method			*string				`json:"method"`
+	PaymentID		*string				`json:"payment_id"`
+	TransactionID	*string				`json:"transaction_id"`
+	ReasonCode		string				`json:"reason_code"`
+	Note			*string				`json:"note"`
+	RequestedBy		string				`json:"requested_by"`
+	AuthorizedBy	string				`json:"authorized_by"`
+	FiscalNumber	*FiscalNumber		`json:"fiscal_number"`
+	CreatedAt		time.Time			`json:"created_at"`
+	LedgerEntryID	string				`json:"ledger_entry_id"`
+}
\ No newline at end of file
@@ -6,12 +6,24 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// MenuWindow is a time of the week a menu is served, such as weekdays from
+// 07:00 to 11:00, in the timezone of the restaurant. A window whose To is not
+// after its From ends the next day.
+type MenuWindow struct {
+	Days			[]string			`json:"days" validate:"required,min=1,dive,eq=MON|eq=TUE|eq=WED|eq=THU|eq=FRI|eq=SAT|eq=SUN"`
+	From			string				`json:"from" validate:"required,datetime=15:04"`
+	To				string				`json:"to" validate:"required,datetime=15:04"`
+}
+
+// Menu is served from StartDate until EndDate, when they are set, and during
+// its Windows, when it has any.
 type Menu struct {
 	ID 				primitive.ObjectID 	`bson:"_id"`
 	Name			string				`json:"name" validate:"required"`
 	Category		string				`json:"category" validate:"required"`
 	StartDate		*time.Time			`json:"start_date"`
 	EndDate			*time.Time			`json:"end_date"`
+	Windows			[]MenuWindow		`json:"windows" validate:"dive"`
 	CreatedAt		time.Time			`json:"created_at"`
 	UpdatedAt		time.Time			`json:"updated_at"`
 	MenuID			string				`json:"menu_id"`
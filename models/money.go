@@ -0,0 +1,278 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// DefaultCurrency is the base currency of the restaurant, which prices and
+// totals are kept in. Amounts sent by clients, which only ever send numbers,
+// are read in it.
+var DefaultCurrency = "USD"
+
+// minorUnitDigits lists the currencies that don't have two decimals.
+var minorUnitDigits = map[string]int{
+	"BHD": 3,
+	"CLP": 0,
+	"ISK": 0,
+	"JOD": 3,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"OMR": 3,
+	"TND": 3,
+	"VND": 0,
+}
+
+// Money is an amount in the minor units of its currency, cents for most of
+// them, so adding up amounts never drifts. It is read and written as a
+// decimal number in JSON, the way amounts have always been sent, and stored
+// as its minor units along with the currency.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+type moneyDocument struct {
+	Amount   int64  `bson:"amount"`
+	Currency string `bson:"currency"`
+}
+
+// NewMoney rounds an amount in major units to the minor units of the currency,
+// halves away from zero.
+func NewMoney(amount float64, currency string) Money {
+	money, _ := ParseMoney(strconv.FormatFloat(amount, 'f', -1, 64), currency)
+	return money
+}
+
+// ParseMoney reads a decimal amount in major units exactly, rounding it to the
+// minor units of the currency.
+func ParseMoney(amount string, currency string) (Money, error) {
+	rat, ok := new(big.Rat).SetString(strings.TrimSpace(amount))
+	if !ok {
+		return Money{}, fmt.Errorf("%q is not an amount of money", amount)
+	}
+
+	return roundMoney(rat, currency)
+}
+
+// roundMoney rounds an amount in major units to the minor units of the
+// currency, halves away from zero.
+func roundMoney(amount *big.Rat, currency string) (Money, error) {
+	rat := new(big.Rat).Mul(amount, new(big.Rat).SetInt(minorUnits(currency)))
+
+	quotient, remainder := new(big.Int).QuoRem(rat.Num(), rat.Denom(), new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(rat.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(rat.Sign())))
+	}
+
+	if !quotient.IsInt64() {
+		return Money{}, fmt.Errorf("%s is too large an amount of money", amount.FloatString(MinorUnitDigits(currency)))
+	}
+
+	return Money{Amount: quotient.Int64(), Currency: currency}, nil
+}
+
+// MinorUnitDigits returns the number of decimals of the currency.
+func MinorUnitDigits(currency string) int {
+	if digits, ok := minorUnitDigits[currency]; ok {
+		return digits
+	}
+
+	return 2
+}
+
+// minorUnits returns the number of minor units in one unit of the currency.
+func minorUnits(currency string) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(MinorUnitDigits(currency))), nil)
+}
+
+func (m Money) Add(other Money) Money {
+	return Money{Amount: m.Amount + other.Amount, Currency: m.currencyWith(other)}
+}
+
+func (m Money) Sub(other Money) Money {
+	return Money{Amount: m.Amount - other.Amount, Currency: m.currencyWith(other)}
+}
+
+func (m Money) Mul(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+// Scale returns the amount multiplied by factor, rounded to the minor units
+// of its currency, halves away from zero.
+func (m Money) Scale(factor float64) Money {
+	return m.scale(decimalRat(factor), m.Currency)
+}
+
+// Percent returns rate percent of the amount, such as the tax charged on it,
+// rounded to the minor units of its currency.
+func (m Money) Percent(rate float64) Money {
+	return m.scale(new(big.Rat).Quo(decimalRat(rate), big.NewRat(100, 1)), m.Currency)
+}
+
+// WithoutPercent returns the amount that adding rate percent to gives this
+// one, such as the net price of a price including a tax, rounded to the minor
+// units of its currency.
+func (m Money) WithoutPercent(rate float64) Money {
+	percent := new(big.Rat).Quo(decimalRat(rate), big.NewRat(100, 1))
+	return m.scale(new(big.Rat).Inv(percent.Add(percent, big.NewRat(1, 1))), m.Currency)
+}
+
+// Convert returns the amount in another currency, at a rate given as units of
+// that currency for one unit of this one.
+func (m Money) Convert(rate float64, currency string) Money {
+	return m.scale(decimalRat(rate), currency)
+}
+
+// scale multiplies the amount in major units by factor and rounds the result
+// to the minor units of currency.
+func (m Money) scale(factor *big.Rat, currency string) Money {
+	amount := new(big.Rat).SetFrac(big.NewInt(m.Amount), minorUnits(m.Currency))
+	scaled, _ := roundMoney(amount.Mul(amount, factor), currency)
+
+	return scaled
+}
+
+// decimalRat reads a factor back from its shortest decimal form, e.g. 1.1
+// rather than the binary fraction closest to it.
+func decimalRat(factor float64) *big.Rat {
+	exact, _ := new(big.Rat).SetString(strconv.FormatFloat(factor, 'f', -1, 64))
+	return exact
+}
+
+// currencyWith returns the currency of an operation on two amounts, one of
+// which may be a zero value without a currency.
+func (m Money) currencyWith(other Money) string {
+	if m.Currency == "" {
+		return other.Currency
+	}
+
+	return m.Currency
+}
+
+// String formats the amount in major units, e.g. 10.50.
+func (m Money) String() string {
+	digits := MinorUnitDigits(m.Currency)
+
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	if digits == 0 {
+		return sign + strconv.FormatInt(amount, 10)
+	}
+
+	text := fmt.Sprintf("%0*d", digits+1, amount)
+	return sign + text[:len(text)-digits] + "." + text[len(text)-digits:]
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON reads a number, or a string holding one, in the default
+// currency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+
+	money, err := ParseMoney(text, DefaultCurrency)
+	if err != nil {
+		return err
+	}
+
+	*m = money
+	return nil
+}
+
+func (m Money) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(moneyDocument{Amount: m.Amount, Currency: m.Currency})
+}
+
+// UnmarshalBSONValue reads the document an amount is stored as. Amounts stored
+// before they were kept in minor units are plain numbers in major units, which
+// are read in the default currency.
+func (m *Money) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	switch t {
+	case bsontype.Null, bsontype.Undefined:
+		*m = Money{}
+	case bsontype.Int32:
+		integer, _, ok := bsoncore.ReadInt32(data)
+		if !ok {
+			return errors.New("money stored as an invalid int32")
+		}
+		*m = Money{Amount: int64(integer) * minorUnits(DefaultCurrency).Int64(), Currency: DefaultCurrency}
+	case bsontype.Int64:
+		integer, _, ok := bsoncore.ReadInt64(data)
+		if !ok {
+			return errors.New("money stored as an invalid int64")
+		}
+		*m = Money{Amount: integer * minorUnits(DefaultCurrency).Int64(), Currency: DefaultCurrency}
+	case bsontype.Double:
+		double, _, ok := bsoncore.ReadDouble(data)
+		if !ok {
+			return errors.New("money stored as an invalid double")
+		}
+		*m = NewMoney(double, DefaultCurrency)
+	case bsontype.EmbeddedDocument:
+		var doc moneyDocument
+		if err := bson.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		*m = Money{Amount: doc.Amount, Currency: doc.Currency}
+	default:
+		return fmt.Errorf("money cannot be stored as %s", t)
+	}
+
+	return nil
+}
+
+// NewBSONRegistry returns the default BSON registry, except that a null amount
+// is decoded into a nil *Money. The driver would otherwise allocate one and
+// have UnmarshalBSONValue read the null as a zero amount.
+func NewBSONRegistry() *bsoncodec.Registry {
+	registry := bson.NewRegistry()
+	registry.RegisterTypeDecoder(reflect.TypeOf(&Money{}), bsoncodec.ValueDecoderFunc(decodeMoneyPointer))
+
+	return registry
+}
+
+func decodeMoneyPointer(_ bsoncodec.DecodeContext, vr bsonrw.ValueReader, val reflect.Value) error {
+	if vr.Type() == bsontype.Null {
+		val.Set(reflect.Zero(val.Type()))
+		return vr.ReadNull()
+	}
+
+	t, data, err := bsonrw.Copier{}.CopyValueToBytes(vr)
+	if err != nil {
+		return err
+	}
+
+	money := &Money{}
+	if err := money.UnmarshalBSONValue(t, data); err != nil {
+		return err
+	}
+	val.Set(reflect.ValueOf(money))
+
+	return nil
+}
\ No newline at end of file
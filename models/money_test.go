@@ -0,0 +1,207 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		money Money
+		json  string
+	}{
+		{"cents", Money{Amount: 1050, Currency: "USD"}, "10.50"},
+		{"negative", Money{Amount: -5, Currency: "USD"}, "-0.05"},
+		{"zero", Money{Currency: "USD"}, "0.00"},
+		{"no decimals", Money{Amount: 1505, Currency: "JPY"}, "1505"},
+		{"three decimals", Money{Amount: 1234, Currency: "KWD"}, "1.234"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := json.Marshal(test.money)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(data) != test.json {
+				t.Errorf("got %s, want %s", data, test.json)
+			}
+		})
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    Money
+		wantErr bool
+	}{
+		{"number", "10.5", Money{Amount: 1050, Currency: DefaultCurrency}, false},
+		{"string", `"10.50"`, Money{Amount: 1050, Currency: DefaultCurrency}, false},
+		{"half rounds up", "0.125", Money{Amount: 13, Currency: DefaultCurrency}, false},
+		{"negative half rounds down", "-0.125", Money{Amount: -13, Currency: DefaultCurrency}, false},
+		{"no float drift", "0.285", Money{Amount: 29, Currency: DefaultCurrency}, false},
+		{"not a number", `"ten"`, Money{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var money Money
+			err := json.Unmarshal([]byte(test.json), &money)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %v", err, test.wantErr)
+			}
+
+			if money != test.want {
+				t.Errorf("got %+v, want %+v", money, test.want)
+			}
+		})
+	}
+}
+
+func TestMoneyUnmarshalJSONNull(t *testing.T) {
+	var value struct {
+		Total *Money `json:"total"`
+	}
+
+	if err := json.Unmarshal([]byte(`{"total": null}`), &value); err != nil {
+		t.Fatal(err)
+	}
+
+	if value.Total != nil {
+		t.Errorf("got %+v, want nil", *value.Total)
+	}
+}
+
+func TestMoneyBSONValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored interface{}
+		want   Money
+	}{
+		{"document", moneyDocument{Amount: 1505, Currency: "JPY"}, Money{Amount: 1505, Currency: "JPY"}},
+		{"legacy double", 10.005, Money{Amount: 1001, Currency: DefaultCurrency}},
+		{"legacy int32", int32(12), Money{Amount: 1200, Currency: DefaultCurrency}},
+		{"legacy int64", int64(12), Money{Amount: 1200, Currency: DefaultCurrency}},
+		{"null", primitive.Null{}, Money{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bsonType, data, err := bson.MarshalValue(test.stored)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			money := Money{Amount: 1, Currency: "EUR"}
+			if err := money.UnmarshalBSONValue(bsonType, data); err != nil {
+				t.Fatal(err)
+			}
+
+			if money != test.want {
+				t.Errorf("got %+v, want %+v", money, test.want)
+			}
+		})
+	}
+}
+
+func TestMoneyBSONValueUnsupported(t *testing.T) {
+	bsonType, data, err := bson.MarshalValue("10.50")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var money Money
+	if err := money.UnmarshalBSONValue(bsonType, data); err == nil {
+		t.Errorf("got %+v, want an error", money)
+	}
+}
+
+func TestMoneyBSONRoundTrip(t *testing.T) {
+	type document struct {
+		Price Money  `bson:"price"`
+		Total *Money `bson:"total"`
+	}
+
+	total := Money{Amount: -250, Currency: "KWD"}
+	tests := []struct {
+		name string
+		doc  document
+	}{
+		{"amounts", document{Price: Money{Amount: 1050, Currency: "USD"}, Total: &total}},
+		{"nil amount", document{Price: Money{Amount: 1050, Currency: "USD"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := bson.Marshal(test.doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var decoded document
+			if err := bson.UnmarshalWithRegistry(NewBSONRegistry(), data, &decoded); err != nil {
+				t.Fatal(err)
+			}
+
+			if decoded.Price != test.doc.Price {
+				t.Errorf("got price %+v, want %+v", decoded.Price, test.doc.Price)
+			}
+
+			if (decoded.Total == nil) != (test.doc.Total == nil) || (decoded.Total != nil && *decoded.Total != *test.doc.Total) {
+				t.Errorf("got total %v, want %v", decoded.Total, test.doc.Total)
+			}
+		})
+	}
+}
+
+func TestMoneyBSONRegistryLegacyAmount(t *testing.T) {
+	data, err := bson.Marshal(bson.D{{Key: "total", Value: 10.5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded struct {
+		Total *Money `bson:"total"`
+	}
+	if err := bson.UnmarshalWithRegistry(NewBSONRegistry(), data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	want := Money{Amount: 1050, Currency: DefaultCurrency}
+	if decoded.Total == nil || *decoded.Total != want {
+		t.Errorf("got %v, want %+v", decoded.Total, want)
+	}
+}
+
+func TestMoneyScale(t *testing.T) {
+	tests := []struct {
+		name  string
+		money Money
+		got   func(Money) Money
+		want  Money
+	}{
+		{"scale half rounds up", Money{Amount: 1005, Currency: "USD"}, func(m Money) Money { return m.Scale(0.5) }, Money{Amount: 503, Currency: "USD"}},
+		{"scale negative half rounds down", Money{Amount: -1005, Currency: "USD"}, func(m Money) Money { return m.Scale(0.5) }, Money{Amount: -503, Currency: "USD"}},
+		{"percent", Money{Amount: 3700, Currency: "USD"}, func(m Money) Money { return m.Percent(8.875) }, Money{Amount: 328, Currency: "USD"}},
+		{"percent without decimals", Money{Amount: 3000, Currency: "JPY"}, func(m Money) Money { return m.Percent(10) }, Money{Amount: 300, Currency: "JPY"}},
+		{"without percent", Money{Amount: 1100, Currency: "USD"}, func(m Money) Money { return m.WithoutPercent(10) }, Money{Amount: 1000, Currency: "USD"}},
+		{"without percent rounds", Money{Amount: 1000, Currency: "USD"}, func(m Money) Money { return m.WithoutPercent(18) }, Money{Amount: 847, Currency: "USD"}},
+		{"convert", Money{Amount: 1000, Currency: "USD"}, func(m Money) Money { return m.Convert(150.5, "JPY") }, Money{Amount: 1505, Currency: "JPY"}},
+		{"convert back", Money{Amount: 3000, Currency: "JPY"}, func(m Money) Money { return m.Convert(1/151.35, "USD") }, Money{Amount: 1982, Currency: "USD"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.got(test.money); got != test.want {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
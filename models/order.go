@@ -6,6 +6,22 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	OrderStatusOpen				= "OPEN"
+	OrderStatusSentToKitchen	= "SENT_TO_KITCHEN"
+	OrderStatusServed			= "SERVED"
+	OrderStatusBilled			= "BILLED"
+	OrderStatusClosed			= "CLOSED"
+	OrderStatusCancelled		= "CANCELLED"
+	OrderStatusVoided			= "VOIDED"
+)
+
+type OrderStatusChange struct {
+	From			string				`json:"from"`
+	To				string				`json:"to"`
+	At				time.Time			`json:"at"`
+}
+
 type Order struct {
 	ID 				primitive.ObjectID 	`bson:"_id"`
 	OrderDate		time.Time			`json:"order_date" validate:"required"`
@@ -13,4 +29,7 @@ type Order struct {
 	UpdatedAt		time.Time			`json:"updated_at"`
 	OrderID			string				`json:"order_id"`
 	TableID			*string				`json:"table_id" validate:"required"`
+	WaiterID		*string				`json:"waiter_id"`
+	Status			*string				`json:"status" validate:"omitempty,eq=OPEN|eq=SENT_TO_KITCHEN|eq=SERVED|eq=BILLED|eq=CLOSED|eq=CANCELLED|eq=VOIDED"`
+	StatusHistory	[]OrderStatusChange	`json:"status_history"`
 }
\ No newline at end of file
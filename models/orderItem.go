@@ -6,13 +6,51 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	KitchenStatusQueued		= "QUEUED"
+	KitchenStatusPreparing	= "PREPARING"
+	KitchenStatusReady		= "READY"
+)
+
+// SelectedModifier is an option picked for an order item. Its names and price
+// delta are captured when it is ordered, like the unit price.
+type SelectedModifier struct {
+	GroupID			string				`json:"group_id" validate:"required"`
+	OptionID		string				`json:"option_id" validate:"required"`
+	GroupName		string				`json:"group_name"`
+	Name			string				`json:"name"`
+	PriceDelta		Money				`json:"price_delta"`
+}
+
+// ComboComponent is the food chosen for a slot of a combo that was ordered.
+// Its names and upcharge are captured when it is ordered.
+type ComboComponent struct {
+	SlotID			string				`json:"slot_id" validate:"required"`
+	FoodID			string				`json:"food_id" validate:"required"`
+	VariantID		*string				`json:"variant_id"`
+	SlotName		string				`json:"slot_name"`
+	FoodName		string				`json:"food_name"`
+	VariantName		string				`json:"variant_name,omitempty"`
+	Upcharge		Money				`json:"upcharge"`
+}
+
+// The UnitPrice of an order item is the price of its food, or of its variant
+// when the food has variants, plus the price deltas of its Modifiers. An item
+// ordering a combo has a ComboID instead of a FoodID, and is priced at the
+// price of the combo plus the upcharges of its ComboComponents.
 type OrderItem struct {
 	ID 				primitive.ObjectID 	`bson:"_id"`
 	Quantity		*int				`json:"quantity" validate:"required,gt=0"`	
-	UnitPrice		*float64			`json:"unit_price" validate:"required"`
+	UnitPrice		*Money				`json:"unit_price" validate:"required"`
 	CreatedAt		time.Time			`json:"created_at"`
 	UpdatedAt		time.Time			`json:"updated_at"`
-	FoodID			*string				`json:"food_id" validate:"required"`
+	FoodID			*string				`json:"food_id" validate:"required_without=ComboID,excluded_with=ComboID"`
 	OrderItemID		string				`json:"order_item_id" validate:"required"`
 	OrderID			string				`json:"order_id" validate:"required"`
+	KitchenStatus	*string				`json:"kitchen_status"`
+	Seat			*int				`json:"seat" validate:"omitempty,gt=0"`
+	VariantID		*string				`json:"variant_id"`
+	ComboID			*string				`json:"combo_id"`
+	ComboComponents	[]ComboComponent	`json:"combo_components" validate:"dive"`
+	Modifiers		[]SelectedModifier	`json:"modifiers" validate:"dive"`
 }
\ No newline at end of file
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PromotionTypePercentage	= "PERCENTAGE"
+	PromotionTypeFixed		= "FIXED"
+	PromotionTypeBuyXGetY	= "BUY_X_GET_Y"
+)
+
+const (
+	PromotionScopeItem		= "ITEM"
+	PromotionScopeInvoice	= "INVOICE"
+)
+
+// Promotion is a discount applied when billing. Promotions with a CouponCode
+// only apply to invoices the code was given for, the others apply to every
+// invoice while they are active. Item promotions discount the matching order
+// items, invoice promotions the whole bill, both limited to FoodIDs if set.
+// Value is a percentage or, for fixed discounts, an amount per unit for item
+// promotions and per invoice for invoice promotions. Buy X get Y gives
+// GetQuantity of the cheapest matching units for free every time
+// BuyQuantity + GetQuantity units are ordered.
+type Promotion struct {
+	ID 				primitive.ObjectID 	`bson:"_id"`
+	Name			*string				`json:"name" validate:"required,min=2,max=100"`
+	Type			*string				`json:"type" validate:"required,eq=PERCENTAGE|eq=FIXED|eq=BUY_X_GET_Y"`
+	Scope			*string				`json:"scope" validate:"required,eq=ITEM|eq=INVOICE"`
+	Value			*float64			`json:"value" validate:"omitempty,gt=0"`
+	FoodIDs			[]string			`json:"food_ids"`
+	BuyQuantity		*int				`json:"buy_quantity" validate:"omitempty,gt=0"`
+	GetQuantity		*int				`json:"get_quantity" validate:"omitempty,gt=0"`
+	CouponCode		*string				`json:"coupon_code" validate:"omitempty,min=3,max=32,alphanum"`
+	UsageLimit		*int				`json:"usage_limit" validate:"omitempty,gt=0"`
+	UsageCount		int					`json:"usage_count"`
+	StartsAt		*time.Time			`json:"starts_at"`
+	ExpiresAt		*time.Time			`json:"expires_at"`
+	Stackable		*bool				`json:"stackable"`
+	Priority		*int				`json:"priority"`
+	Active			*bool				`json:"active"`
+	CreatedAt		time.Time			`json:"created_at"`
+	UpdatedAt		time.Time			`json:"updated_at"`
+	PromotionID		string				`json:"promotion_id"`
+}
\ No newline at end of file
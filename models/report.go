@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	ReportPeriodDay		= "day"
+	ReportPeriodWeek	= "week"
+	ReportPeriodMonth	= "month"
+)
+
+// ReportRange is the time a report covers, from From up to but not including
+// To. Days, weeks and months begin at midnight in Location.
+type ReportRange struct {
+	From			time.Time			`json:"from"`
+	To				time.Time			`json:"to"`
+	Location		*time.Location		`json:"-"`
+}
+
+// PeriodRevenue is the revenue of the invoices created in a day, such as
+// 2024-03-01, an ISO week, such as 2024-W09, or a month, such as 2024-03.
+type PeriodRevenue struct {
+	Period			string				`json:"period"`
+	Invoices		int					`json:"invoices"`
+	Revenue			Money				`json:"revenue"`
+}
+
+// TicketSummary is the revenue of the invoices and how much was spent per
+// order on average, split bills counting as one ticket.
+type TicketSummary struct {
+	Orders			int					`json:"orders"`
+	Invoices		int					`json:"invoices"`
+	Revenue			Money				`json:"revenue"`
+	AverageTicket	Money				`json:"average_ticket"`
+}
+
+type TableRevenue struct {
+	TableID			string				`json:"table_id"`
+	TableNumber		*int				`json:"table_number"`
+	Orders			int					`json:"orders"`
+	Invoices		int					`json:"invoices"`
+	Revenue			Money				`json:"revenue"`
+}
+
+// VariantSales is the part of FoodSales ordered as one variant of the food.
+type VariantSales struct {
+	VariantID		string				`json:"variant_id"`
+	Name			*string				`json:"name"`
+	SKU				*string				`json:"sku"`
+	Quantity		int					`json:"quantity"`
+	Revenue			Money				`json:"revenue"`
+}
+
+// FoodSales is the quantity of a food ordered and what it sold for, at the
+// prices it was ordered at, before bill discounts and taxes, along with how
+// much of it each of its variants sold.
+type FoodSales struct {
+	FoodID			string				`json:"food_id"`
+	Name			*string				`json:"name"`
+	Quantity		int					`json:"quantity"`
+	Revenue			Money				`json:"revenue"`
+	Variants		[]VariantSales		`json:"variants"`
+}
+
+// CategorySales is FoodSales added up by menu category.
+type CategorySales struct {
+	Category		string				`json:"category"`
+	Quantity		int					`json:"quantity"`
+	Revenue			Money				`json:"revenue"`
+}
\ No newline at end of file
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaxRate is a tax applied to invoice lines. It applies to every line unless
+// it is limited to some menu categories or menus. Inclusive taxes are already
+// part of the food price, compound taxes are charged on the price plus the
+// taxes applied before them, in ascending Priority.
+type TaxRate struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Name       *string            `json:"name" validate:"required,min=2,max=100"`
+	Rate       *float64           `json:"rate" validate:"required,gte=0,lte=100"`
+	Inclusive  *bool              `json:"inclusive"`
+	Compound   *bool              `json:"compound"`
+	Priority   *int               `json:"priority"`
+	Categories []string           `json:"categories"`
+	MenuIDs    []string           `json:"menu_ids"`
+	Active     *bool              `json:"active"`
+	CreatedAt  time.Time          `json:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+	TaxRateID  string             `json:"tax_rate_id"`
+}
@@ -6,6 +6,28 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	RoleAdmin		= "admin"
+	RoleManager		= "manager"
+	RoleWaiter		= "waiter"
+	RoleKitchen		= "kitchen"
+	RoleCashier		= "cashier"
+)
+
+// StaffRoles lists every role that can be assigned to a user.
+var StaffRoles = []string{RoleAdmin, RoleManager, RoleWaiter, RoleKitchen, RoleCashier}
+
+// UserSession is a login of the user on one device. The tokens issued for it
+// carry its Family, and only the hash of its current refresh token is stored.
+type UserSession struct {
+	Family			string				`json:"family"`
+	RefreshToken	string				`json:"refresh_token"`
+	CreatedAt		time.Time			`json:"created_at"`
+	UpdatedAt		time.Time			`json:"updated_at"`
+}
+
+// Sessions lists the devices the user is logged in on. FirstUser is only set
+// on the very first account, which signed up as admin.
 type User struct {
 	ID 				primitive.ObjectID 	`bson:"_id"`
 	FirstName		*string				`json:"first_name" validate:"required,min=2,max=100"`
@@ -14,8 +36,9 @@ type User struct {
 	Email			*string				`json:"email" validate:"email,required"`
 	Avatar			*string				`json:"avatar"`
 	Phone			*string				`json:"phone" validate:"required"`
-	Token			*string				`json:"token"`
-	RefreshToken	*string				`json:"refresh_token"`
+	Sessions		[]UserSession		`bson:"sessions" json:"-"`
+	Role			*string				`json:"role" validate:"omitempty,eq=admin|eq=manager|eq=waiter|eq=kitchen|eq=cashier"`
+	FirstUser		*bool				`bson:"first_user,omitempty" json:"-"`
 	CreatedAt		time.Time			`json:"created_at"`
 	UpdatedAt		time.Time			`json:"updated_at"`
 	UserID			string				`json:"user_id"`
@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+// File appends reminders to a file as JSON lines, each with its message, for
+// local development or for another program to deliver them.
+type File struct {
+	path string
+
+	mu sync.Mutex
+}
+
+func NewFile(path string) (*File, error) {
+	if path == "" {
+		return nil, errors.New("NOTIFIER_FILE must be set to the file reminders are written to")
+	}
+
+	return &File{path: path}, nil
+}
+
+func (f *File) Name() string {
+	return "file"
+}
+
+func (f *File) Send(ctx context.Context, reminder Reminder) error {
+	line, err := json.Marshal(struct {
+		Reminder
+		Message string `json:"message"`
+	}{reminder, reminder.Message()})
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
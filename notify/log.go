@@ -0,0 +1,28 @@
+package notify
+
+import (
+	"context"
+	"log"
+)
+
+// Log writes reminders to the server log instead of delivering them, for
+// local development.
+type Log struct{}
+
+func NewLog() *Log {
+	return &Log{}
+}
+
+func (l *Log) Name() string {
+	return "log"
+}
+
+func (l *Log) Send(ctx context.Context, reminder Reminder) error {
+	to := "no billing email"
+	if reminder.Email != nil {
+		to = *reminder.Email
+	}
+
+	log.Printf("Reminder %d to %s: %s", reminder.Sequence, to, reminder.Message())
+	return nil
+}
@@ -0,0 +1,50 @@
+// Package notify sends reminders about unpaid invoices. Services only use the
+// Notifier interface, so a channel such as email is added by implementing it
+// and registering it in New.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+)
+
+// Reminder is the Sequence-th reminder that an invoice is overdue. Email is
+// the billing address of the invoice, when it has one.
+type Reminder struct {
+	InvoiceID     string       `json:"invoice_id"`
+	InvoiceNumber string       `json:"invoice_number"`
+	Email         *string      `json:"email"`
+	Balance       models.Money `json:"balance"`
+	DueDate       time.Time    `json:"due_date"`
+	Sequence      int          `json:"sequence"`
+	SentAt        time.Time    `json:"sent_at"`
+}
+
+// Message is the text of the reminder.
+func (r Reminder) Message() string {
+	return fmt.Sprintf("Invoice %s was due on %s and %s %s is still to be paid.", r.InvoiceNumber, r.DueDate.Format(time.DateOnly), r.Balance, r.Balance.Currency)
+}
+
+// Notifier delivers reminders. Send returns an error if the reminder could not
+// be delivered.
+type Notifier interface {
+	Name() string
+	Send(ctx context.Context, reminder Reminder) error
+}
+
+// New returns the notifier configured with NOTIFIER: log, the default, writes
+// reminders to the server log and file appends them to NOTIFIER_FILE.
+func New() (Notifier, error) {
+	switch name := os.Getenv("NOTIFIER"); name {
+	case "", "log":
+		return NewLog(), nil
+	case "file":
+		return NewFile(os.Getenv("NOTIFIER_FILE"))
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", name)
+	}
+}
@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type BusinessDayRepository interface {
+	FindAll(ctx context.Context) ([]models.BusinessDay, error)
+	FindByID(ctx context.Context, businessDayId string) (models.BusinessDay, error)
+	FindOpen(ctx context.Context) (models.BusinessDay, error)
+	// Open inserts an open business day, or returns ErrConflict if another
+	// one is already open. The day is inserted with an empty list of cash
+	// drops for them to be pushed to.
+	Open(ctx context.Context, day models.BusinessDay) error
+	// AddCashDrop records a cash drop on the day, or returns ErrConflict if
+	// it is no longer open.
+	AddCashDrop(ctx context.Context, businessDayId string, drop models.CashDrop) error
+	// Close stores the day as closed, or returns ErrConflict if it was closed
+	// since it was read.
+	Close(ctx context.Context, day models.BusinessDay) error
+}
+
+type mongoBusinessDayRepository struct {
+	days mongoCollection[models.BusinessDay]
+}
+
+func (r *mongoBusinessDayRepository) FindAll(ctx context.Context) ([]models.BusinessDay, error) {
+	return r.days.find(ctx, bson.M{})
+}
+
+func (r *mongoBusinessDayRepository) FindByID(ctx context.Context, businessDayId string) (models.BusinessDay, error) {
+	return r.days.findByID(ctx, businessDayId)
+}
+
+func (r *mongoBusinessDayRepository) FindOpen(ctx context.Context) (models.BusinessDay, error) {
+	return r.days.findOne(ctx, bson.M{"status": models.BusinessDayOpen})
+}
+
+func (r *mongoBusinessDayRepository) Open(ctx context.Context, day models.BusinessDay) error {
+	err := r.days.insert(ctx, day)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrConflict
+	}
+
+	return err
+}
+
+func (r *mongoBusinessDayRepository) AddCashDrop(ctx context.Context, businessDayId string, drop models.CashDrop) error {
+	err := r.days.updateOne(
+		ctx,
+		bson.M{"business_day_id": businessDayId, "status": models.BusinessDayOpen},
+		bson.M{"$push": bson.M{"cash_drops": drop}},
+	)
+	if errors.Is(err, ErrNotFound) {
+		return ErrConflict
+	}
+
+	return err
+}
+
+func (r *mongoBusinessDayRepository) Close(ctx context.Context, day models.BusinessDay) error {
+	err := r.days.updateOne(
+		ctx,
+		bson.M{"business_day_id": day.BusinessDayID, "status": models.BusinessDayOpen},
+		bson.M{"$set": bson.M{
+			"status":       day.Status,
+			"counted_cash": day.CountedCash,
+			"report":       day.Report,
+			"closed_by":    day.ClosedBy,
+			"closed_at":    day.ClosedAt,
+		}},
+	)
+	if errors.Is(err, ErrNotFound) {
+		return ErrConflict
+	}
+
+	return err
+}
+
+type memoryBusinessDayRepository struct {
+	days *memoryCollection[models.BusinessDay]
+}
+
+func (r *memoryBusinessDayRepository) FindAll(ctx context.Context) ([]models.BusinessDay, error) {
+	return r.days.find(nil)
+}
+
+func (r *memoryBusinessDayRepository) FindByID(ctx context.Context, businessDayId string) (models.BusinessDay, error) {
+	return r.days.findByID(businessDayId)
+}
+
+func (r *memoryBusinessDayRepository) FindOpen(ctx context.Context) (models.BusinessDay, error) {
+	return r.days.findOne(func(day models.BusinessDay) bool {
+		return day.Status == models.BusinessDayOpen
+	})
+}
+
+func (r *memoryBusinessDayRepository) Open(ctx context.Context, day models.BusinessDay) error {
+	r.days.mu.Lock()
+	defer r.days.mu.Unlock()
+
+	for _, stored := range r.days.docs {
+		if stored.Status == models.BusinessDayOpen {
+			return ErrConflict
+		}
+	}
+
+	return r.days.insertLocked([]models.BusinessDay{day})
+}
+
+func (r *memoryBusinessDayRepository) AddCashDrop(ctx context.Context, businessDayId string, drop models.CashDrop) error {
+	return r.days.update(businessDayId, func(stored *models.BusinessDay) error {
+		if stored.Status != models.BusinessDayOpen {
+			return ErrConflict
+		}
+
+		stored.CashDrops = append(stored.CashDrops, drop)
+		return nil
+	})
+}
+
+func (r *memoryBusinessDayRepository) Close(ctx context.Context, day models.BusinessDay) error {
+	return r.days.update(day.BusinessDayID, func(stored *models.BusinessDay) error {
+		if stored.Status != models.BusinessDayOpen {
+			return ErrConflict
+		}
+
+		*stored = day
+		return nil
+	})
+}
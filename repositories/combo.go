@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type ComboRepository interface {
+	FindAll(ctx context.Context) ([]models.Combo, error)
+	FindByID(ctx context.Context, comboId string) (models.Combo, error)
+	Insert(ctx context.Context, combo models.Combo) error
+	Update(ctx context.Context, combo models.Combo) error
+}
+
+type mongoComboRepository struct {
+	combos mongoCollection[models.Combo]
+}
+
+func (r *mongoComboRepository) FindAll(ctx context.Context) ([]models.Combo, error) {
+	return r.combos.find(ctx, bson.M{})
+}
+
+func (r *mongoComboRepository) FindByID(ctx context.Context, comboId string) (models.Combo, error) {
+	return r.combos.findByID(ctx, comboId)
+}
+
+func (r *mongoComboRepository) Insert(ctx context.Context, combo models.Combo) error {
+	return r.combos.insert(ctx, combo)
+}
+
+func (r *mongoComboRepository) Update(ctx context.Context, combo models.Combo) error {
+	return r.combos.replace(ctx, combo.ComboID, combo)
+}
+
+type memoryComboRepository struct {
+	combos *memoryCollection[models.Combo]
+}
+
+func (r *memoryComboRepository) FindAll(ctx context.Context) ([]models.Combo, error) {
+	return r.combos.find(nil)
+}
+
+func (r *memoryComboRepository) FindByID(ctx context.Context, comboId string) (models.Combo, error) {
+	return r.combos.findByID(comboId)
+}
+
+func (r *memoryComboRepository) Insert(ctx context.Context, combo models.Combo) error {
+	return r.combos.insert(combo)
+}
+
+func (r *memoryComboRepository) Update(ctx context.Context, combo models.Combo) error {
+	return r.combos.replace(combo.ComboID, combo)
+}
@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type ExchangeRateRepository interface {
+	FindAll(ctx context.Context) ([]models.ExchangeRate, error)
+	FindByID(ctx context.Context, exchangeRateId string) (models.ExchangeRate, error)
+	FindByCurrency(ctx context.Context, currency string) ([]models.ExchangeRate, error)
+	Insert(ctx context.Context, exchangeRate models.ExchangeRate) error
+	Update(ctx context.Context, exchangeRate models.ExchangeRate) error
+}
+
+type mongoExchangeRateRepository struct {
+	exchangeRates mongoCollection[models.ExchangeRate]
+}
+
+func (r *mongoExchangeRateRepository) FindAll(ctx context.Context) ([]models.ExchangeRate, error) {
+	return r.exchangeRates.find(ctx, bson.M{})
+}
+
+func (r *mongoExchangeRateRepository) FindByID(ctx context.Context, exchangeRateId string) (models.ExchangeRate, error) {
+	return r.exchangeRates.findByID(ctx, exchangeRateId)
+}
+
+func (r *mongoExchangeRateRepository) FindByCurrency(ctx context.Context, currency string) ([]models.ExchangeRate, error) {
+	return r.exchangeRates.find(ctx, bson.M{"currency": currency})
+}
+
+func (r *mongoExchangeRateRepository) Insert(ctx context.Context, exchangeRate models.ExchangeRate) error {
+	return r.exchangeRates.insert(ctx, exchangeRate)
+}
+
+func (r *mongoExchangeRateRepository) Update(ctx context.Context, exchangeRate models.ExchangeRate) error {
+	return r.exchangeRates.replace(ctx, exchangeRate.ExchangeRateID, exchangeRate)
+}
+
+type memoryExchangeRateRepository struct {
+	exchangeRates *memoryCollection[models.ExchangeRate]
+}
+
+func (r *memoryExchangeRateRepository) FindAll(ctx context.Context) ([]models.ExchangeRate, error) {
+	return r.exchangeRates.find(nil)
+}
+
+func (r *memoryExchangeRateRepository) FindByID(ctx context.Context, exchangeRateId string) (models.ExchangeRate, error) {
+	return r.exchangeRates.findByID(exchangeRateId)
+}
+
+func (r *memoryExchangeRateRepository) FindByCurrency(ctx context.Context, currency string) ([]models.ExchangeRate, error) {
+	return r.exchangeRates.find(func(exchangeRate models.ExchangeRate) bool {
+		return exchangeRate.Currency != nil && *exchangeRate.Currency == currency
+	})
+}
+
+func (r *memoryExchangeRateRepository) Insert(ctx context.Context, exchangeRate models.ExchangeRate) error {
+	return r.exchangeRates.insert(exchangeRate)
+}
+
+func (r *memoryExchangeRateRepository) Update(ctx context.Context, exchangeRate models.ExchangeRate) error {
+	return r.exchangeRates.replace(exchangeRate.ExchangeRateID, exchangeRate)
+}
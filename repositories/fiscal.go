@@ -0,0 +1,199 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/EnesDemirtas/restaurant-management/database"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Fiscal numbers are taken from a counter per series, kept in the
+// fiscalCounter collection. The numbers of the documents are reserved on the
+// counter in the same transaction as the documents are inserted, which makes
+// them gap-free: if the documents can't be inserted, the counter is left as it
+// was. Concurrent transactions on the same counter conflict and are retried,
+// so each of them gets a block of numbers of its own.
+
+// EnsureIndexes creates the unique indexes that keep a fiscal number from
+// being stored twice on invoices and credit notes, the one that keeps a
+// single business day open and the one that lets a single user sign up as the
+// first admin.
+func EnsureIndexes(ctx context.Context, client *mongo.Client) error {
+	index := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "fiscal_number.restaurant", Value: 1},
+			{Key: "fiscal_number.series", Value: 1},
+			{Key: "fiscal_number.year", Value: 1},
+			{Key: "fiscal_number.sequence", Value: 1},
+		},
+		Options: options.Index().
+			SetName("fiscal_number").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"fiscal_number.sequence": bson.M{"$gt": 0}}),
+	}
+
+	for _, collection := range []string{"invoice", "ledger"} {
+		if _, err := database.OpenCollection(client, collection).Indexes().CreateOne(ctx, index); err != nil {
+			return err
+		}
+	}
+
+	openDay := mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}},
+		Options: options.Index().
+			SetName("open_business_day").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"status": models.BusinessDayOpen}),
+	}
+
+	if _, err := database.OpenCollection(client, "businessDay").Indexes().CreateOne(ctx, openDay); err != nil {
+		return err
+	}
+
+	firstUser := mongo.IndexModel{
+		Keys: bson.D{{Key: "first_user", Value: 1}},
+		Options: options.Index().
+			SetName("first_user").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"first_user": true}),
+	}
+
+	_, err := database.OpenCollection(client, "user").Indexes().CreateOne(ctx, firstUser)
+	return err
+}
+
+// fiscalCounterID identifies the counter of a series.
+type fiscalCounterID struct {
+	Restaurant string `bson:"restaurant"`
+	Series     string `bson:"series"`
+	Year       int    `bson:"year"`
+}
+
+type fiscalCounter struct {
+	ID       fiscalCounterID `bson:"_id"`
+	Sequence int64           `bson:"sequence"`
+}
+
+// insertNumbered inserts the documents with the numbers following the last
+// one of the series, in order. Either all of them are inserted and numbered,
+// or none of them and no number is taken.
+func (m mongoCollection[T]) insertNumbered(ctx context.Context, docs []T, series models.FiscalNumber, setNumber func(*T, models.FiscalNumber)) ([]T, error) {
+	session, err := m.collection.Database().Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	for {
+		numbered, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+			last, err := m.reserveNumbers(sc, series, int64(len(docs)))
+			if err != nil {
+				return nil, err
+			}
+
+			numbered := make([]T, len(docs))
+			first := last - int64(len(docs)) + 1
+			for i, doc := range docs {
+				setNumber(&doc, series.WithSequence(first+int64(i)))
+				numbered[i] = doc
+			}
+
+			return numbered, m.insertMany(sc, numbered)
+		})
+
+		// The counter of a series is created by the first document numbered
+		// in it; when two are numbered at once, the one that lost creating it
+		// is numbered again on the counter the other one created.
+		if errors.Is(err, errCounterCreated) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return numbered.([]T), nil
+	}
+}
+
+var errCounterCreated = errors.New("fiscal counter was created by another request")
+
+// reserveNumbers takes count numbers off the counter of the series and returns
+// the last of them. A series without a counter yet, such as one numbered
+// before counters were kept, carries on from the last number stored in it.
+func (m mongoCollection[T]) reserveNumbers(ctx mongo.SessionContext, series models.FiscalNumber, count int64) (int64, error) {
+	counters := m.collection.Database().Collection("fiscalCounter")
+	id := fiscalCounterID{Restaurant: series.Restaurant, Series: series.Series, Year: series.Year}
+
+	var counter fiscalCounter
+	err := counters.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$inc": bson.M{"sequence": count}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err == nil {
+		return counter.Sequence, nil
+	}
+
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, err
+	}
+
+	var last struct {
+		FiscalNumber models.FiscalNumber `bson:"fiscal_number"`
+	}
+
+	err = m.collection.FindOne(
+		ctx,
+		bson.M{
+			"fiscal_number.restaurant": series.Restaurant,
+			"fiscal_number.series":     series.Series,
+			"fiscal_number.year":       series.Year,
+		},
+		options.FindOne().SetSort(bson.D{{Key: "fiscal_number.sequence", Value: -1}}),
+	).Decode(&last)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, err
+	}
+
+	counter = fiscalCounter{ID: id, Sequence: last.FiscalNumber.Sequence + count}
+	if _, err := counters.InsertOne(ctx, counter); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return 0, errCounterCreated
+		}
+		return 0, err
+	}
+
+	return counter.Sequence, nil
+}
+
+// insertNumbered inserts the documents with the numbers following the last
+// one of the series, in order.
+func (m *memoryCollection[T]) insertNumbered(docs []T, series models.FiscalNumber, numberOf func(T) *models.FiscalNumber, setNumber func(*T, models.FiscalNumber)) ([]T, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var last int64
+	for _, doc := range m.docs {
+		if number := numberOf(doc); number != nil && number.InSeries(series) && number.Sequence > last {
+			last = number.Sequence
+		}
+	}
+
+	numbered := make([]T, len(docs))
+	for i, doc := range docs {
+		setNumber(&doc, series.WithSequence(last+int64(i)+1))
+		numbered[i] = doc
+	}
+
+	if err := m.insertLocked(numbered); err != nil {
+		return nil, err
+	}
+
+	return numbered, nil
+}
@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+)
+
+func TestMemoryInvoiceInsertNumbered(t *testing.T) {
+	ctx := context.Background()
+	invoices := NewMemoryStore().Invoices
+	series := models.FiscalNumber{Series: models.FiscalSeriesInvoice, Year: 2024}
+
+	tests := []struct {
+		name       string
+		invoiceIds []string
+		series     models.FiscalNumber
+		wantErr    error
+		want       []string
+	}{
+		{"first of the series", []string{"i1"}, series, nil, []string{"INV-2024-000001"}},
+		{"split", []string{"i2", "i3"}, series, nil, []string{"INV-2024-000002", "INV-2024-000003"}},
+		{"split with a taken id", []string{"i4", "i1"}, series, ErrConflict, nil},
+		{"after a failed split", []string{"i4"}, series, nil, []string{"INV-2024-000004"}},
+		{"next year", []string{"i5"}, models.FiscalNumber{Series: models.FiscalSeriesInvoice, Year: 2025}, nil, []string{"INV-2025-000001"}},
+		{"another restaurant", []string{"i6"}, models.FiscalNumber{Restaurant: "R2", Series: models.FiscalSeriesInvoice, Year: 2024}, nil, []string{"R2-INV-2024-000001"}},
+		{"back in the series", []string{"i7"}, series, nil, []string{"INV-2024-000005"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			toInsert := []models.Invoice{}
+			for _, invoiceId := range test.invoiceIds {
+				toInsert = append(toInsert, models.Invoice{InvoiceID: invoiceId})
+			}
+
+			numbered, err := invoices.InsertNumbered(ctx, toInsert, test.series)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("got %v, want %v", err, test.wantErr)
+			}
+
+			if len(numbered) != len(test.want) {
+				t.Fatalf("got %d invoices, want %d", len(numbered), len(test.want))
+			}
+
+			for i, invoice := range numbered {
+				stored, err := invoices.FindByID(ctx, invoice.InvoiceID)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if invoice.FiscalNumber == nil || stored.FiscalNumber == nil || invoice.FiscalNumber.Number != test.want[i] || *stored.FiscalNumber != *invoice.FiscalNumber {
+					t.Errorf("got %+v stored as %+v, want %s", invoice.FiscalNumber, stored.FiscalNumber, test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryLedgerInsertNumbered(t *testing.T) {
+	ctx := context.Background()
+	ledger := NewMemoryStore().Ledger
+	series := models.FiscalNumber{Series: models.FiscalSeriesCreditNote, Year: 2024}
+
+	var wg sync.WaitGroup
+	sequences := make([]int64, 20)
+	errs := make([]error, len(sequences))
+	for i := range sequences {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			entry, err := ledger.InsertNumbered(ctx, models.LedgerEntry{LedgerEntryID: fmt.Sprintf("e%d", i)}, series)
+			if entry.FiscalNumber != nil {
+				sequences[i] = entry.FiscalNumber.Sequence
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Credit notes numbered at the same time each get a number of their own,
+	// with none skipped.
+	sort.Slice(sequences, func(a, b int) bool { return sequences[a] < sequences[b] })
+	for i, sequence := range sequences {
+		if sequence != int64(i)+1 {
+			t.Fatalf("got sequences %v, want 1 to %d", sequences, len(sequences))
+		}
+	}
+}
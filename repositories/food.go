@@ -0,0 +1,181 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type FoodRepository interface {
+	FindAll(ctx context.Context) ([]models.Food, error)
+	FindByID(ctx context.Context, foodId string) (models.Food, error)
+	FindByMenu(ctx context.Context, menuId string) ([]models.Food, error)
+	Insert(ctx context.Context, food models.Food) error
+	// Update stores the food but leaves its availability as it is, which is
+	// only changed with SetAvailability and TakePortions so that no portion
+	// ordered meanwhile is lost.
+	Update(ctx context.Context, food models.Food) error
+	SetAvailability(ctx context.Context, food models.Food) error
+	// TakePortions takes the quantity off the remaining portions of the food,
+	// or returns ErrConflict if it is not available or fewer portions are
+	// left. Foods without a count of portions are only checked for being
+	// available.
+	TakePortions(ctx context.Context, foodId string, quantity int) error
+	// ReturnPortions gives back portions taken for an order that was not
+	// placed, if the food still has a count of portions.
+	ReturnPortions(ctx context.Context, foodId string, quantity int) error
+}
+
+type mongoFoodRepository struct {
+	foods mongoCollection[models.Food]
+}
+
+func (r *mongoFoodRepository) FindAll(ctx context.Context) ([]models.Food, error) {
+	return r.foods.find(ctx, bson.M{})
+}
+
+func (r *mongoFoodRepository) FindByID(ctx context.Context, foodId string) (models.Food, error) {
+	return r.foods.findByID(ctx, foodId)
+}
+
+func (r *mongoFoodRepository) FindByMenu(ctx context.Context, menuId string) ([]models.Food, error) {
+	return r.foods.find(ctx, bson.M{"menu_id": menuId})
+}
+
+func (r *mongoFoodRepository) Insert(ctx context.Context, food models.Food) error {
+	return r.foods.insert(ctx, food)
+}
+
+func (r *mongoFoodRepository) Update(ctx context.Context, food models.Food) error {
+	return r.foods.updateOne(ctx, bson.M{"food_id": food.FoodID}, bson.M{"$set": bson.M{
+		"name":            food.Name,
+		"price":           food.Price,
+		"food_image":      food.FoodImage,
+		"menu_id":         food.MenuID,
+		"variants":        food.Variants,
+		"modifier_groups": food.ModifierGroups,
+		"updated_at":      food.UpdatedAt,
+	}})
+}
+
+func (r *mongoFoodRepository) SetAvailability(ctx context.Context, food models.Food) error {
+	return r.foods.updateOne(ctx, bson.M{"food_id": food.FoodID}, bson.M{"$set": bson.M{
+		"available":          food.Available,
+		"remaining_portions": food.RemainingPortions,
+		"updated_at":         food.UpdatedAt,
+	}})
+}
+
+func (r *mongoFoodRepository) TakePortions(ctx context.Context, foodId string, quantity int) error {
+	err := r.foods.updateOne(
+		ctx,
+		bson.M{"food_id": foodId, "available": bson.M{"$ne": false}, "remaining_portions": bson.M{"$gte": quantity}},
+		bson.M{"$inc": bson.M{"remaining_portions": -quantity}},
+	)
+	if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	unlimited, err := r.foods.count(ctx, bson.M{"food_id": foodId, "available": bson.M{"$ne": false}, "remaining_portions": nil})
+	if err != nil {
+		return err
+	}
+
+	if unlimited == 0 {
+		return ErrConflict
+	}
+
+	return nil
+}
+
+func (r *mongoFoodRepository) ReturnPortions(ctx context.Context, foodId string, quantity int) error {
+	err := r.foods.updateOne(
+		ctx,
+		bson.M{"food_id": foodId, "remaining_portions": bson.M{"$type": "number"}},
+		bson.M{"$inc": bson.M{"remaining_portions": quantity}},
+	)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+type memoryFoodRepository struct {
+	foods *memoryCollection[models.Food]
+}
+
+func (r *memoryFoodRepository) FindAll(ctx context.Context) ([]models.Food, error) {
+	return r.foods.find(nil)
+}
+
+func (r *memoryFoodRepository) FindByID(ctx context.Context, foodId string) (models.Food, error) {
+	return r.foods.findByID(foodId)
+}
+
+func (r *memoryFoodRepository) FindByMenu(ctx context.Context, menuId string) ([]models.Food, error) {
+	return r.foods.find(func(food models.Food) bool {
+		return food.MenuID != nil && *food.MenuID == menuId
+	})
+}
+
+func (r *memoryFoodRepository) Insert(ctx context.Context, food models.Food) error {
+	return r.foods.insert(food)
+}
+
+func (r *memoryFoodRepository) Update(ctx context.Context, food models.Food) error {
+	return r.foods.update(food.FoodID, func(stored *models.Food) error {
+		food.Available = stored.Available
+		food.RemainingPortions = stored.RemainingPortions
+		*stored = food
+		return nil
+	})
+}
+
+func (r *memoryFoodRepository) SetAvailability(ctx context.Context, food models.Food) error {
+	return r.foods.update(food.FoodID, func(stored *models.Food) error {
+		stored.Available = food.Available
+		stored.RemainingPortions = food.RemainingPortions
+		stored.UpdatedAt = food.UpdatedAt
+		return nil
+	})
+}
+
+func (r *memoryFoodRepository) TakePortions(ctx context.Context, foodId string, quantity int) error {
+	return r.foods.update(foodId, func(stored *models.Food) error {
+		if stored.Available != nil && !*stored.Available {
+			return ErrConflict
+		}
+
+		if stored.RemainingPortions == nil {
+			return nil
+		}
+
+		if *stored.RemainingPortions < quantity {
+			return ErrConflict
+		}
+
+		remaining := *stored.RemainingPortions - quantity
+		stored.RemainingPortions = &remaining
+		return nil
+	})
+}
+
+func (r *memoryFoodRepository) ReturnPortions(ctx context.Context, foodId string, quantity int) error {
+	err := r.foods.update(foodId, func(stored *models.Food) error {
+		if stored.RemainingPortions == nil {
+			return nil
+		}
+
+		remaining := *stored.RemainingPortions + quantity
+		stored.RemainingPortions = &remaining
+		return nil
+	})
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+
+	return err
+}
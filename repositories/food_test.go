@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+)
+
+func TestMemoryFoodTakePortions(t *testing.T) {
+	available, soldOut := true, false
+	five := 5
+
+	tests := []struct {
+		name      string
+		available *bool
+		remaining *int
+		quantity  int
+		wantErr   error
+		want      *int
+	}{
+		{"portions left", &available, &five, 2, nil, intPointer(3)},
+		{"last portions", &available, &five, 5, nil, intPointer(0)},
+		{"too few portions", &available, &five, 6, ErrConflict, intPointer(5)},
+		{"sold out", &soldOut, &five, 1, ErrConflict, intPointer(5)},
+		{"not counted", nil, nil, 100, nil, nil},
+		{"not counted and sold out", &soldOut, nil, 1, ErrConflict, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			foods := NewMemoryStore().Foods
+
+			food := models.Food{FoodID: "f1", Available: test.available}
+			if test.remaining != nil {
+				remaining := *test.remaining
+				food.RemainingPortions = &remaining
+			}
+			if err := foods.Insert(ctx, food); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := foods.TakePortions(ctx, "f1", test.quantity); !errors.Is(err, test.wantErr) {
+				t.Fatalf("got %v, want %v", err, test.wantErr)
+			}
+
+			stored, err := foods.FindByID(ctx, "f1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if (stored.RemainingPortions == nil) != (test.want == nil) || (test.want != nil && *stored.RemainingPortions != *test.want) {
+				t.Errorf("got remaining portions %v, want %v", stored.RemainingPortions, test.want)
+			}
+		})
+	}
+}
+
+func TestMemoryFoodTakePortionsConcurrently(t *testing.T) {
+	ctx := context.Background()
+	foods := NewMemoryStore().Foods
+
+	if err := foods.Insert(ctx, models.Food{FoodID: "f1", RemainingPortions: intPointer(10)}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 20)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = foods.TakePortions(ctx, "f1", 1)
+		}(i)
+	}
+	wg.Wait()
+
+	// Only as many portions are taken as were left, and never more.
+	taken := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			taken++
+		case !errors.Is(err, ErrConflict):
+			t.Fatal(err)
+		}
+	}
+
+	stored, err := foods.FindByID(ctx, "f1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if taken != 10 || *stored.RemainingPortions != 0 {
+		t.Errorf("got %d portions taken and %d left, want 10 and 0", taken, *stored.RemainingPortions)
+	}
+}
+
+func TestMemoryFoodReturnPortions(t *testing.T) {
+	ctx := context.Background()
+	foods := NewMemoryStore().Foods
+
+	for _, food := range []models.Food{{FoodID: "counted", RemainingPortions: intPointer(1)}, {FoodID: "uncounted"}} {
+		if err := foods.Insert(ctx, food); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, foodId := range []string{"counted", "uncounted", "missing"} {
+		if err := foods.ReturnPortions(ctx, foodId, 2); err != nil {
+			t.Errorf("got %v returning portions of %s, want none", err, foodId)
+		}
+	}
+
+	counted, err := foods.FindByID(ctx, "counted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *counted.RemainingPortions != 3 {
+		t.Errorf("got %d portions left, want 3", *counted.RemainingPortions)
+	}
+
+	uncounted, err := foods.FindByID(ctx, "uncounted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uncounted.RemainingPortions != nil {
+		t.Errorf("got %d portions left, want none counted", *uncounted.RemainingPortions)
+	}
+}
+
+func TestMemoryFoodUpdateKeepsAvailability(t *testing.T) {
+	ctx := context.Background()
+	foods := NewMemoryStore().Foods
+
+	soldOut := false
+	if err := foods.Insert(ctx, models.Food{FoodID: "f1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := foods.SetAvailability(ctx, models.Food{FoodID: "f1", Available: &soldOut, RemainingPortions: intPointer(0)}); err != nil {
+		t.Fatal(err)
+	}
+
+	name := "Soup"
+	if err := foods.Update(ctx, models.Food{FoodID: "f1", Name: &name}); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := foods.FindByID(ctx, "f1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *stored.Name != name || stored.Available == nil || *stored.Available || stored.RemainingPortions == nil || *stored.RemainingPortions != 0 {
+		t.Errorf("got %+v, want the soup still sold out", stored)
+	}
+}
+
+func intPointer(value int) *int {
+	return &value
+}
@@ -0,0 +1,401 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type InvoiceRepository interface {
+	FindAll(ctx context.Context) ([]models.Invoice, error)
+	FindByID(ctx context.Context, invoiceId string) (models.Invoice, error)
+	FindByOrder(ctx context.Context, orderId string) ([]models.Invoice, error)
+	FindByStatus(ctx context.Context, status string) ([]models.Invoice, error)
+	// FindByTransaction finds the invoice with the card payment the provider
+	// recorded as the given transaction.
+	FindByTransaction(ctx context.Context, transactionId string) (models.Invoice, error)
+	Insert(ctx context.Context, invoice models.Invoice) error
+	InsertMany(ctx context.Context, invoices []models.Invoice) error
+	// InsertNumbered inserts the invoices with the next fiscal numbers of the
+	// series and returns them numbered. Either all of them are inserted or,
+	// when it fails, none.
+	InsertNumbered(ctx context.Context, invoices []models.Invoice, series models.FiscalNumber) ([]models.Invoice, error)
+	// Update stores the details and amounts of the invoice, leaving its
+	// payments alone. It returns ErrConflict if a payment was recorded since
+	// the invoice was read.
+	Update(ctx context.Context, invoice models.Invoice) error
+	// UpdatePaymentStatus stores the status the provider reports for the
+	// payment of the invoice with the given transaction.
+	UpdatePaymentStatus(ctx context.Context, invoiceId, transactionId, status string, at time.Time) error
+	// AddPayment stores the last payment of the invoice along with its new
+	// payment status and totals. It returns ErrConflict if another payment
+	// was recorded since the invoice was read.
+	AddPayment(ctx context.Context, invoice models.Invoice) error
+	// UpdateRefunded sets the amount refunded and the refunded items of the
+	// invoice, but only while its amount refunded is still refunded; otherwise
+	// it returns ErrConflict. A nil refunded matches invoices that never had
+	// their refunds counted.
+	UpdateRefunded(ctx context.Context, invoiceId string, refunded *models.Money, amountRefunded models.Money, refundedItems []string) error
+	// MarkOverdue sets an unpaid invoice OVERDUE, or returns ErrConflict if it
+	// was paid or marked since it was read.
+	MarkOverdue(ctx context.Context, invoiceId string, at time.Time) error
+	// RecordReminder counts the reminders sent for the invoice up to sent,
+	// or returns ErrConflict if they were already counted, so that each
+	// reminder is sent once.
+	RecordReminder(ctx context.Context, invoiceId string, sent int, at time.Time) error
+}
+
+type mongoInvoiceRepository struct {
+	invoices mongoCollection[models.Invoice]
+}
+
+func (r *mongoInvoiceRepository) FindAll(ctx context.Context) ([]models.Invoice, error) {
+	return r.invoices.find(ctx, bson.M{})
+}
+
+func (r *mongoInvoiceRepository) FindByID(ctx context.Context, invoiceId string) (models.Invoice, error) {
+	return r.invoices.findByID(ctx, invoiceId)
+}
+
+func (r *mongoInvoiceRepository) FindByOrder(ctx context.Context, orderId string) ([]models.Invoice, error) {
+	return r.invoices.find(ctx, bson.M{"order_id": orderId})
+}
+
+func (r *mongoInvoiceRepository) FindByStatus(ctx context.Context, status string) ([]models.Invoice, error) {
+	return r.invoices.find(ctx, bson.M{"payment_status": status})
+}
+
+func (r *mongoInvoiceRepository) FindByTransaction(ctx context.Context, transactionId string) (models.Invoice, error) {
+	return r.invoices.findOne(ctx, bson.M{"payments.transaction_id": transactionId})
+}
+
+func (r *mongoInvoiceRepository) Insert(ctx context.Context, invoice models.Invoice) error {
+	return r.invoices.insert(ctx, invoice)
+}
+
+func (r *mongoInvoiceRepository) InsertMany(ctx context.Context, invoices []models.Invoice) error {
+	return r.invoices.insertMany(ctx, invoices)
+}
+
+func (r *mongoInvoiceRepository) InsertNumbered(ctx context.Context, invoices []models.Invoice, series models.FiscalNumber) ([]models.Invoice, error) {
+	return r.invoices.insertNumbered(ctx, invoices, series, setInvoiceNumber)
+}
+
+func (r *mongoInvoiceRepository) Update(ctx context.Context, invoice models.Invoice) error {
+	err := r.invoices.updateOne(
+		ctx,
+		bson.M{"invoice_id": invoice.InvoiceID, fmt.Sprintf("payments.%d", len(invoice.Payments)): bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{
+			"payment_method":    invoice.PaymentMethod,
+			"payment_status":    invoice.PaymentStatus,
+			"payment_due_date":  invoice.PaymentDueDate,
+			"billing_email":     invoice.BillingEmail,
+			"reminders_sent":    invoice.RemindersSent,
+			"reminded_at":       invoice.RemindedAt,
+			"lines":             invoice.Lines,
+			"discounts":         invoice.Discounts,
+			"discount_total":    invoice.DiscountTotal,
+			"subtotal":          invoice.Subtotal,
+			"service_charge":    invoice.ServiceCharge,
+			"tax_lines":         invoice.TaxLines,
+			"tax_total":         invoice.TaxTotal,
+			"grand_total":       invoice.GrandTotal,
+			"payment_currency":  invoice.PaymentCurrency,
+			"exchange_rate":     invoice.ExchangeRate,
+			"converted_total":   invoice.ConvertedTotal,
+			"converted_balance": invoice.ConvertedBalance,
+			"updated_at":        invoice.UpdatedAt,
+		}},
+	)
+
+	if errors.Is(err, ErrNotFound) {
+		return ErrConflict
+	}
+
+	return err
+}
+
+func (r *mongoInvoiceRepository) UpdatePaymentStatus(ctx context.Context, invoiceId, transactionId, status string, at time.Time) error {
+	return r.invoices.updateOne(
+		ctx,
+		bson.M{"invoice_id": invoiceId, "payments.transaction_id": transactionId},
+		bson.M{"$set": bson.M{"payments.$.provider_status": status, "updated_at": at}},
+	)
+}
+
+func (r *mongoInvoiceRepository) AddPayment(ctx context.Context, invoice models.Invoice) error {
+	previous := len(invoice.Payments) - 1
+	payment := invoice.Payments[previous]
+
+	set := bson.D{
+		{Key: "payment_status", Value: invoice.PaymentStatus},
+		{Key: "payment_method", Value: invoice.PaymentMethod},
+		{Key: "amount_paid", Value: invoice.AmountPaid},
+		{Key: "balance", Value: invoice.Balance},
+		{Key: "tip_total", Value: invoice.TipTotal},
+		{Key: "exchange_rate", Value: invoice.ExchangeRate},
+		{Key: "converted_total", Value: invoice.ConvertedTotal},
+		{Key: "converted_balance", Value: invoice.ConvertedBalance},
+		{Key: "updated_at", Value: invoice.UpdatedAt},
+	}
+
+	// Invoices without payments have them stored as null, which can't be
+	// pushed to.
+	update := bson.D{{Key: "$push", Value: bson.D{{Key: "payments", Value: payment}}}}
+	if previous == 0 {
+		set = append(set, bson.E{Key: "payments", Value: bson.A{payment}})
+		update = bson.D{}
+	}
+	update = append(update, bson.E{Key: "$set", Value: set})
+
+	err := r.invoices.updateOne(
+		ctx,
+		bson.M{"invoice_id": invoice.InvoiceID, fmt.Sprintf("payments.%d", previous): bson.M{"$exists": false}},
+		update,
+	)
+
+	if errors.Is(err, ErrNotFound) {
+		return ErrConflict
+	}
+
+	return err
+}
+
+func (r *mongoInvoiceRepository) UpdateRefunded(ctx context.Context, invoiceId string, refunded *models.Money, amountRefunded models.Money, refundedItems []string) error {
+	filter := bson.M{"invoice_id": invoiceId, "amount_refunded": nil}
+	if refunded != nil {
+		filter["amount_refunded"] = *refunded
+	}
+
+	err := r.invoices.updateOne(
+		ctx,
+		filter,
+		bson.M{"$set": bson.M{"amount_refunded": amountRefunded, "refunded_items": refundedItems}},
+	)
+	if errors.Is(err, ErrNotFound) {
+		return ErrConflict
+	}
+
+	return err
+}
+
+func (r *mongoInvoiceRepository) MarkOverdue(ctx context.Context, invoiceId string, at time.Time) error {
+	err := r.invoices.updateOne(
+		ctx,
+		bson.M{
+			"invoice_id":     invoiceId,
+			"payment_status": bson.M{"$in": bson.A{models.PaymentStatusPending, models.PaymentStatusPartiallyPaid}},
+		},
+		bson.M{"$set": bson.M{"payment_status": models.PaymentStatusOverdue, "updated_at": at}},
+	)
+	if errors.Is(err, ErrNotFound) {
+		return ErrConflict
+	}
+
+	return err
+}
+
+func (r *mongoInvoiceRepository) RecordReminder(ctx context.Context, invoiceId string, sent int, at time.Time) error {
+	// Invoices created before reminders were sent have no count stored,
+	// which $lt would not match.
+	err := r.invoices.updateOne(
+		ctx,
+		bson.M{"invoice_id": invoiceId, "reminders_sent": bson.M{"$not": bson.M{"$gte": sent}}},
+		bson.M{"$set": bson.M{"reminders_sent": sent, "reminded_at": at}},
+	)
+	if errors.Is(err, ErrNotFound) {
+		return ErrConflict
+	}
+
+	return err
+}
+
+type memoryInvoiceRepository struct {
+	invoices *memoryCollection[models.Invoice]
+}
+
+func (r *memoryInvoiceRepository) FindAll(ctx context.Context) ([]models.Invoice, error) {
+	return r.invoices.find(nil)
+}
+
+func (r *memoryInvoiceRepository) FindByID(ctx context.Context, invoiceId string) (models.Invoice, error) {
+	return r.invoices.findByID(invoiceId)
+}
+
+func (r *memoryInvoiceRepository) FindByOrder(ctx context.Context, orderId string) ([]models.Invoice, error) {
+	return r.invoices.find(func(invoice models.Invoice) bool {
+		return invoice.OrderID == orderId
+	})
+}
+
+func (r *memoryInvoiceRepository) FindByStatus(ctx context.Context, status string) ([]models.Invoice, error) {
+	return r.invoices.find(func(invoice models.Invoice) bool {
+		return invoice.PaymentStatus != nil && *invoice.PaymentStatus == status
+	})
+}
+
+func (r *memoryInvoiceRepository) FindByTransaction(ctx context.Context, transactionId string) (models.Invoice, error) {
+	return r.invoices.findOne(func(invoice models.Invoice) bool {
+		for _, payment := range invoice.Payments {
+			if payment.TransactionID != nil && *payment.TransactionID == transactionId {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+func (r *memoryInvoiceRepository) Insert(ctx context.Context, invoice models.Invoice) error {
+	return r.invoices.insert(invoice)
+}
+
+func (r *memoryInvoiceRepository) InsertMany(ctx context.Context, invoices []models.Invoice) error {
+	return r.invoices.insertMany(invoices)
+}
+
+func (r *memoryInvoiceRepository) InsertNumbered(ctx context.Context, invoices []models.Invoice, series models.FiscalNumber) ([]models.Invoice, error) {
+	return r.invoices.insertNumbered(invoices, series, func(invoice models.Invoice) *models.FiscalNumber {
+		return invoice.FiscalNumber
+	}, setInvoiceNumber)
+}
+
+func (r *memoryInvoiceRepository) Update(ctx context.Context, invoice models.Invoice) error {
+	err := r.invoices.update(invoice.InvoiceID, func(stored *models.Invoice) error {
+		if len(stored.Payments) != len(invoice.Payments) {
+			return ErrConflict
+		}
+
+		stored.PaymentMethod = invoice.PaymentMethod
+		stored.PaymentStatus = invoice.PaymentStatus
+		stored.PaymentDueDate = invoice.PaymentDueDate
+		stored.BillingEmail = invoice.BillingEmail
+		stored.RemindersSent = invoice.RemindersSent
+		stored.RemindedAt = invoice.RemindedAt
+		stored.Lines = invoice.Lines
+		stored.Discounts = invoice.Discounts
+		stored.DiscountTotal = invoice.DiscountTotal
+		stored.Subtotal = invoice.Subtotal
+		stored.ServiceCharge = invoice.ServiceCharge
+		stored.TaxLines = invoice.TaxLines
+		stored.TaxTotal = invoice.TaxTotal
+		stored.GrandTotal = invoice.GrandTotal
+		stored.PaymentCurrency = invoice.PaymentCurrency
+		stored.ExchangeRate = invoice.ExchangeRate
+		stored.ConvertedTotal = invoice.ConvertedTotal
+		stored.ConvertedBalance = invoice.ConvertedBalance
+		stored.UpdatedAt = invoice.UpdatedAt
+		return nil
+	})
+
+	if errors.Is(err, ErrNotFound) {
+		return ErrConflict
+	}
+
+	return err
+}
+
+func (r *memoryInvoiceRepository) UpdatePaymentStatus(ctx context.Context, invoiceId, transactionId, status string, at time.Time) error {
+	return r.invoices.update(invoiceId, func(stored *models.Invoice) error {
+		for i, payment := range stored.Payments {
+			if payment.TransactionID != nil && *payment.TransactionID == transactionId {
+				stored.Payments[i].ProviderStatus = &status
+				stored.UpdatedAt = at
+				return nil
+			}
+		}
+
+		return ErrNotFound
+	})
+}
+
+func (r *memoryInvoiceRepository) AddPayment(ctx context.Context, invoice models.Invoice) error {
+	previous := len(invoice.Payments) - 1
+
+	err := r.invoices.update(invoice.InvoiceID, func(stored *models.Invoice) error {
+		if len(stored.Payments) != previous {
+			return ErrConflict
+		}
+
+		stored.Payments = append(stored.Payments, invoice.Payments[previous])
+		stored.PaymentStatus = invoice.PaymentStatus
+		stored.PaymentMethod = invoice.PaymentMethod
+		stored.AmountPaid = invoice.AmountPaid
+		stored.Balance = invoice.Balance
+		stored.TipTotal = invoice.TipTotal
+		stored.ExchangeRate = invoice.ExchangeRate
+		stored.ConvertedTotal = invoice.ConvertedTotal
+		stored.ConvertedBalance = invoice.ConvertedBalance
+		stored.UpdatedAt = invoice.UpdatedAt
+		return nil
+	})
+
+	if errors.Is(err, ErrNotFound) {
+		return ErrConflict
+	}
+
+	return err
+}
+
+func (r *memoryInvoiceRepository) UpdateRefunded(ctx context.Context, invoiceId string, refunded *models.Money, amountRefunded models.Money, refundedItems []string) error {
+	err := r.invoices.update(invoiceId, func(stored *models.Invoice) error {
+		if (stored.AmountRefunded == nil) != (refunded == nil) || (refunded != nil && *stored.AmountRefunded != *refunded) {
+			return ErrConflict
+		}
+
+		stored.AmountRefunded = &amountRefunded
+		stored.RefundedItems = refundedItems
+		return nil
+	})
+
+	if errors.Is(err, ErrNotFound) {
+		return ErrConflict
+	}
+
+	return err
+}
+
+func (r *memoryInvoiceRepository) MarkOverdue(ctx context.Context, invoiceId string, at time.Time) error {
+	err := r.invoices.update(invoiceId, func(stored *models.Invoice) error {
+		if stored.PaymentStatus == nil || (*stored.PaymentStatus != models.PaymentStatusPending && *stored.PaymentStatus != models.PaymentStatusPartiallyPaid) {
+			return ErrConflict
+		}
+
+		status := models.PaymentStatusOverdue
+		stored.PaymentStatus = &status
+		stored.UpdatedAt = at
+		return nil
+	})
+
+	if errors.Is(err, ErrNotFound) {
+		return ErrConflict
+	}
+
+	return err
+}
+
+func (r *memoryInvoiceRepository) RecordReminder(ctx context.Context, invoiceId string, sent int, at time.Time) error {
+	err := r.invoices.update(invoiceId, func(stored *models.Invoice) error {
+		if stored.RemindersSent >= sent {
+			return ErrConflict
+		}
+
+		stored.RemindersSent = sent
+		stored.RemindedAt = &at
+		return nil
+	})
+
+	if errors.Is(err, ErrNotFound) {
+		return ErrConflict
+	}
+
+	return err
+}
+
+func setInvoiceNumber(invoice *models.Invoice, number models.FiscalNumber) {
+	invoice.FiscalNumber = &number
+}
@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+)
+
+func newTestInvoice(t *testing.T, invoices InvoiceRepository) models.Invoice {
+	pending := models.PaymentStatusPending
+	grandTotal := models.Money{Amount: 2000, Currency: "USD"}
+	invoice := models.Invoice{InvoiceID: "i1", PaymentStatus: &pending, GrandTotal: &grandTotal, Payments: []models.InvoicePayment{}}
+
+	if err := invoices.Insert(context.Background(), invoice); err != nil {
+		t.Fatal(err)
+	}
+
+	return invoice
+}
+
+func withPayment(invoice models.Invoice, paymentId string, amount int64) models.Invoice {
+	invoice.Payments = append(append([]models.InvoicePayment{}, invoice.Payments...), models.InvoicePayment{
+		PaymentID: paymentId,
+		Method:    models.PaymentMethodCash,
+		Amount:    models.Money{Amount: amount, Currency: "USD"},
+	})
+
+	return invoice
+}
+
+func TestMemoryInvoiceAddPayment(t *testing.T) {
+	ctx := context.Background()
+	invoices := NewMemoryStore().Invoices
+	invoice := newTestInvoice(t, invoices)
+
+	if err := invoices.AddPayment(ctx, withPayment(invoice, "p1", 500)); err != nil {
+		t.Fatal(err)
+	}
+
+	// A payment added to the copy read before the first one was added is
+	// refused rather than stored in its place.
+	if err := invoices.AddPayment(ctx, withPayment(invoice, "p2", 700)); !errors.Is(err, ErrConflict) {
+		t.Fatalf("got %v, want ErrConflict", err)
+	}
+
+	stored, err := invoices.FindByID(ctx, "i1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := invoices.AddPayment(ctx, withPayment(stored, "p2", 700)); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err = invoices.FindByID(ctx, "i1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stored.Payments) != 2 || stored.Payments[0].PaymentID != "p1" || stored.Payments[1].PaymentID != "p2" {
+		t.Errorf("got payments %+v, want p1 and p2", stored.Payments)
+	}
+}
+
+func TestMemoryInvoiceUpdate(t *testing.T) {
+	email := "guest@example.com"
+
+	tests := []struct {
+		name     string
+		payments int
+		wantErr  error
+	}{
+		{"no payment added meanwhile", 0, nil},
+		{"payment added meanwhile", 1, ErrConflict},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			invoices := NewMemoryStore().Invoices
+			invoice := newTestInvoice(t, invoices)
+
+			for i := 0; i < test.payments; i++ {
+				stored, err := invoices.FindByID(ctx, "i1")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := invoices.AddPayment(ctx, withPayment(stored, "p", 500)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			invoice.BillingEmail = &email
+			if err := invoices.Update(ctx, invoice); !errors.Is(err, test.wantErr) {
+				t.Fatalf("got %v, want %v", err, test.wantErr)
+			}
+
+			stored, err := invoices.FindByID(ctx, "i1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(stored.Payments) != test.payments {
+				t.Errorf("got %d payments, want %d", len(stored.Payments), test.payments)
+			}
+
+			if updated := stored.BillingEmail != nil; updated != (test.wantErr == nil) {
+				t.Errorf("got billing email %v, want it updated %v", stored.BillingEmail, test.wantErr == nil)
+			}
+		})
+	}
+}
+
+func TestMemoryInvoiceUpdateRefunded(t *testing.T) {
+	ctx := context.Background()
+	invoices := NewMemoryStore().Invoices
+	newTestInvoice(t, invoices)
+
+	first := models.Money{Amount: 500, Currency: "USD"}
+	second := models.Money{Amount: 1200, Currency: "USD"}
+
+	tests := []struct {
+		name          string
+		invoiceId     string
+		refunded      *models.Money
+		claimed       models.Money
+		refundedItems []string
+		wantErr       error
+	}{
+		{"first refund", "i1", nil, first, []string{"a"}, nil},
+		{"concurrent first refund", "i1", nil, first, []string{"a"}, ErrConflict},
+		{"refund from a stale amount", "i1", &second, second, []string{"a", "b"}, ErrConflict},
+		{"next refund", "i1", &first, second, []string{"a", "b"}, nil},
+		{"missing invoice", "i2", nil, first, nil, ErrConflict},
+	}
+
+	// Each refund claims its amount from what it read as refunded so far,
+	// so only one of the refunds made from the same amount goes through.
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := invoices.UpdateRefunded(ctx, test.invoiceId, test.refunded, test.claimed, test.refundedItems); !errors.Is(err, test.wantErr) {
+				t.Fatalf("got %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+
+	stored, err := invoices.FindByID(ctx, "i1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.AmountRefunded == nil || *stored.AmountRefunded != second || len(stored.RefundedItems) != 2 {
+		t.Errorf("got %v refunded for %v, want %+v for a and b", stored.AmountRefunded, stored.RefundedItems, second)
+	}
+}
@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type LedgerRepository interface {
+	FindAll(ctx context.Context) ([]models.LedgerEntry, error)
+	FindByOrder(ctx context.Context, orderId string) ([]models.LedgerEntry, error)
+	FindByInvoice(ctx context.Context, invoiceId string) ([]models.LedgerEntry, error)
+	Insert(ctx context.Context, entry models.LedgerEntry) error
+	// InsertNumbered inserts the entry with the next fiscal number of the
+	// series and returns it numbered.
+	InsertNumbered(ctx context.Context, entry models.LedgerEntry, series models.FiscalNumber) (models.LedgerEntry, error)
+}
+
+type mongoLedgerRepository struct {
+	entries mongoCollection[models.LedgerEntry]
+}
+
+func (r *mongoLedgerRepository) FindAll(ctx context.Context) ([]models.LedgerEntry, error) {
+	return r.entries.find(ctx, bson.M{})
+}
+
+func (r *mongoLedgerRepository) FindByOrder(ctx context.Context, orderId string) ([]models.LedgerEntry, error) {
+	return r.entries.find(ctx, bson.M{"order_id": orderId})
+}
+
+func (r *mongoLedgerRepository) FindByInvoice(ctx context.Context, invoiceId string) ([]models.LedgerEntry, error) {
+	return r.entries.find(ctx, bson.M{"invoice_id": invoiceId})
+}
+
+func (r *mongoLedgerRepository) Insert(ctx context.Context, entry models.LedgerEntry) error {
+	return r.entries.insert(ctx, entry)
+}
+
+func (r *mongoLedgerRepository) InsertNumbered(ctx context.Context, entry models.LedgerEntry, series models.FiscalNumber) (models.LedgerEntry, error) {
+	numbered, err := r.entries.insertNumbered(ctx, []models.LedgerEntry{entry}, series, setEntryNumber)
+	if err != nil {
+		return models.LedgerEntry{}, err
+	}
+
+	return numbered[0], nil
+}
+
+type memoryLedgerRepository struct {
+	entries *memoryCollection[models.LedgerEntry]
+}
+
+func (r *memoryLedgerRepository) FindAll(ctx context.Context) ([]models.LedgerEntry, error) {
+	return r.entries.find(nil)
+}
+
+func (r *memoryLedgerRepository) FindByOrder(ctx context.Context, orderId string) ([]models.LedgerEntry, error) {
+	return r.entries.find(func(entry models.LedgerEntry) bool {
+		return entry.OrderID == orderId
+	})
+}
+
+func (r *memoryLedgerRepository) FindByInvoice(ctx context.Context, invoiceId string) ([]models.LedgerEntry, error) {
+	return r.entries.find(func(entry models.LedgerEntry) bool {
+		return entry.InvoiceID != nil && *entry.InvoiceID == invoiceId
+	})
+}
+
+func (r *memoryLedgerRepository) Insert(ctx context.Context, entry models.LedgerEntry) error {
+	return r.entries.insert(entry)
+}
+
+func (r *memoryLedgerRepository) InsertNumbered(ctx context.Context, entry models.LedgerEntry, series models.FiscalNumber) (models.LedgerEntry, error) {
+	numbered, err := r.entries.insertNumbered([]models.LedgerEntry{entry}, series, func(entry models.LedgerEntry) *models.FiscalNumber {
+		return entry.FiscalNumber
+	}, setEntryNumber)
+	if err != nil {
+		return models.LedgerEntry{}, err
+	}
+
+	return numbered[0], nil
+}
+
+func setEntryNumber(entry *models.LedgerEntry, number models.FiscalNumber) {
+	entry.FiscalNumber = &number
+}
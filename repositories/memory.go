@@ -0,0 +1,181 @@
+package repositories
+
+import (
+	"sync"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+)
+
+// bsonRegistry decodes documents the way the Mongo client is set up to.
+var bsonRegistry = models.NewBSONRegistry()
+
+// memoryCollection keeps documents in insertion order behind a mutex. Every
+// document is copied on the way in and out through a BSON round trip, so
+// callers never share slices or pointers with the stored value and the data
+// goes through the same encoding it would get in MongoDB.
+type memoryCollection[T any] struct {
+	mu   sync.RWMutex
+	ids  []string
+	docs map[string]T
+	id   func(T) string
+}
+
+func newMemoryCollection[T any](id func(T) string) *memoryCollection[T] {
+	return &memoryCollection[T]{docs: map[string]T{}, id: id}
+}
+
+func (m *memoryCollection[T]) find(match func(T) bool) ([]T, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	docs := []T{}
+	for _, id := range m.ids {
+		doc := m.docs[id]
+		if match != nil && !match(doc) {
+			continue
+		}
+
+		copied, err := clone(doc)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, copied)
+	}
+
+	return docs, nil
+}
+
+func (m *memoryCollection[T]) findOne(match func(T) bool) (T, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, id := range m.ids {
+		if doc := m.docs[id]; match(doc) {
+			return clone(doc)
+		}
+	}
+
+	var doc T
+	return doc, ErrNotFound
+}
+
+func (m *memoryCollection[T]) findByID(id string) (T, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	doc, ok := m.docs[id]
+	if !ok {
+		return doc, ErrNotFound
+	}
+
+	return clone(doc)
+}
+
+func (m *memoryCollection[T]) count(match func(T) bool) int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var count int64
+	for _, doc := range m.docs {
+		if match == nil || match(doc) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (m *memoryCollection[T]) insert(doc T) error {
+	return m.insertMany([]T{doc})
+}
+
+func (m *memoryCollection[T]) insertMany(docs []T) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.insertLocked(docs)
+}
+
+// insertLocked stores the documents, or none of them if one already exists.
+// The caller holds the write lock.
+func (m *memoryCollection[T]) insertLocked(docs []T) error {
+	copies := make([]T, 0, len(docs))
+	for _, doc := range docs {
+		if _, exists := m.docs[m.id(doc)]; exists {
+			return ErrConflict
+		}
+
+		copied, err := clone(doc)
+		if err != nil {
+			return err
+		}
+		copies = append(copies, copied)
+	}
+
+	for _, doc := range copies {
+		m.ids = append(m.ids, m.id(doc))
+		m.docs[m.id(doc)] = doc
+	}
+
+	return nil
+}
+
+func (m *memoryCollection[T]) replace(id string, doc T) error {
+	return m.update(id, func(stored *T) error {
+		*stored = doc
+		return nil
+	})
+}
+
+// update applies change to the stored document while holding the write lock,
+// which makes read-modify-write operations atomic. The document is left
+// untouched when change returns an error.
+func (m *memoryCollection[T]) update(id string, change func(*T) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.docs[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	doc, err := clone(stored)
+	if err != nil {
+		return err
+	}
+
+	if err := change(&doc); err != nil {
+		return err
+	}
+
+	doc, err = clone(doc)
+	if err != nil {
+		return err
+	}
+	m.docs[id] = doc
+
+	return nil
+}
+
+func clone[T any](doc T) (T, error) {
+	var copied T
+
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return copied, err
+	}
+
+	decoder, err := bson.NewDecoder(bsonrw.NewBSONDocumentReader(data))
+	if err != nil {
+		return copied, err
+	}
+
+	if err := decoder.SetRegistry(bsonRegistry); err != nil {
+		return copied, err
+	}
+
+	err = decoder.Decode(&copied)
+	return copied, err
+}
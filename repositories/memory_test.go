@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+)
+
+func newTestCollection() *memoryCollection[models.Order] {
+	return newMemoryCollection(func(order models.Order) string { return order.OrderID })
+}
+
+func TestMemoryCollectionCopies(t *testing.T) {
+	orders := newTestCollection()
+	table := "t1"
+	order := models.Order{OrderID: "o1", TableID: &table, StatusHistory: []models.OrderStatusChange{{To: models.OrderStatusOpen}}}
+
+	if err := orders.insert(order); err != nil {
+		t.Fatal(err)
+	}
+
+	// Neither the inserted value nor a value read back share memory with
+	// the stored document.
+	table = "t2"
+	order.StatusHistory[0].To = models.OrderStatusVoided
+
+	found, err := orders.findByID("o1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	*found.TableID = "t3"
+	found.StatusHistory[0].To = models.OrderStatusClosed
+
+	stored, err := orders.findByID("o1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *stored.TableID != "t1" || stored.StatusHistory[0].To != models.OrderStatusOpen {
+		t.Errorf("stored order was changed: table %s, status %s", *stored.TableID, stored.StatusHistory[0].To)
+	}
+}
+
+func TestMemoryCollectionInsertMany(t *testing.T) {
+	orders := newTestCollection()
+
+	if err := orders.insert(models.Order{OrderID: "o1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := orders.insertMany([]models.Order{{OrderID: "o2"}, {OrderID: "o1"}})
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("got %v, want ErrConflict", err)
+	}
+
+	if _, err := orders.findByID("o2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want none of the orders inserted", err)
+	}
+
+	if err := orders.insertMany([]models.Order{{OrderID: "o3"}, {OrderID: "o2"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := orders.find(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []string{}
+	for _, order := range all {
+		ids = append(ids, order.OrderID)
+	}
+	if len(ids) != 3 || ids[0] != "o1" || ids[1] != "o3" || ids[2] != "o2" {
+		t.Errorf("got %v, want the orders in insertion order", ids)
+	}
+}
+
+func TestMemoryCollectionUpdate(t *testing.T) {
+	errRefused := errors.New("refused")
+
+	tests := []struct {
+		name    string
+		id      string
+		change  func(*models.Order) error
+		wantErr error
+		want    string
+	}{
+		{"changed", "o1", func(order *models.Order) error {
+			waiter := "w2"
+			order.WaiterID = &waiter
+			return nil
+		}, nil, "w2"},
+		{"refused", "o1", func(order *models.Order) error {
+			waiter := "w2"
+			order.WaiterID = &waiter
+			return errRefused
+		}, errRefused, "w1"},
+		{"missing", "o2", func(order *models.Order) error {
+			return nil
+		}, ErrNotFound, "w1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			orders := newTestCollection()
+			waiter := "w1"
+			if err := orders.insert(models.Order{OrderID: "o1", WaiterID: &waiter}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := orders.update(test.id, test.change); !errors.Is(err, test.wantErr) {
+				t.Fatalf("got %v, want %v", err, test.wantErr)
+			}
+
+			stored, err := orders.findByID("o1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if *stored.WaiterID != test.want {
+				t.Errorf("got waiter %s, want %s", *stored.WaiterID, test.want)
+			}
+		})
+	}
+}
+
+func TestMemoryCollectionNilMoney(t *testing.T) {
+	invoices := newMemoryCollection(func(invoice models.Invoice) string { return invoice.InvoiceID })
+	grandTotal := models.Money{Amount: 1050, Currency: "USD"}
+
+	if err := invoices.insert(models.Invoice{InvoiceID: "i1", GrandTotal: &grandTotal}); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := invoices.findByID("i1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.AmountRefunded != nil {
+		t.Errorf("got amount refunded %+v, want nil", *stored.AmountRefunded)
+	}
+
+	if stored.GrandTotal == nil || *stored.GrandTotal != grandTotal {
+		t.Errorf("got grand total %v, want %+v", stored.GrandTotal, grandTotal)
+	}
+}
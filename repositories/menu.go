@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type MenuRepository interface {
+	FindAll(ctx context.Context) ([]models.Menu, error)
+	FindByID(ctx context.Context, menuId string) (models.Menu, error)
+	Insert(ctx context.Context, menu models.Menu) error
+	Update(ctx context.Context, menu models.Menu) error
+}
+
+type mongoMenuRepository struct {
+	menus mongoCollection[models.Menu]
+}
+
+func (r *mongoMenuRepository) FindAll(ctx context.Context) ([]models.Menu, error) {
+	return r.menus.find(ctx, bson.M{})
+}
+
+func (r *mongoMenuRepository) FindByID(ctx context.Context, menuId string) (models.Menu, error) {
+	return r.menus.findByID(ctx, menuId)
+}
+
+func (r *mongoMenuRepository) Insert(ctx context.Context, menu models.Menu) error {
+	return r.menus.insert(ctx, menu)
+}
+
+func (r *mongoMenuRepository) Update(ctx context.Context, menu models.Menu) error {
+	return r.menus.replace(ctx, menu.MenuID, menu)
+}
+
+type memoryMenuRepository struct {
+	menus *memoryCollection[models.Menu]
+}
+
+func (r *memoryMenuRepository) FindAll(ctx context.Context) ([]models.Menu, error) {
+	return r.menus.find(nil)
+}
+
+func (r *memoryMenuRepository) FindByID(ctx context.Context, menuId string) (models.Menu, error) {
+	return r.menus.findByID(menuId)
+}
+
+func (r *memoryMenuRepository) Insert(ctx context.Context, menu models.Menu) error {
+	return r.menus.insert(menu)
+}
+
+func (r *memoryMenuRepository) Update(ctx context.Context, menu models.Menu) error {
+	return r.menus.replace(menu.MenuID, menu)
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/EnesDemirtas/restaurant-management/database"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MigrateMoney stores every document holding amounts of money again, which
+// converts the amounts still stored as plain numbers to minor units. It
+// returns the number of documents migrated.
+func MigrateMoney(ctx context.Context, client *mongo.Client) (int, error) {
+	migrations := []func() (int, error){
+		func() (int, error) {
+			return resave(ctx, newMongoCollection[models.Food](database.OpenCollection(client, "food"), "food_id"), func(food models.Food) string { return food.FoodID })
+		},
+		func() (int, error) {
+			return resave(ctx, newMongoCollection[models.OrderItem](database.OpenCollection(client, "orderItem"), "order_item_id"), func(orderItem models.OrderItem) string { return orderItem.OrderItemID })
+		},
+		func() (int, error) {
+			return resave(ctx, newMongoCollection[models.Invoice](database.OpenCollection(client, "invoice"), "invoice_id"), func(invoice models.Invoice) string { return invoice.InvoiceID })
+		},
+		func() (int, error) {
+			return resave(ctx, newMongoCollection[models.LedgerEntry](database.OpenCollection(client, "ledger"), "ledger_entry_id"), func(entry models.LedgerEntry) string { return entry.LedgerEntryID })
+		},
+	}
+
+	migrated := 0
+	for _, migrate := range migrations {
+		count, err := migrate()
+		migrated += count
+		if err != nil {
+			return migrated, err
+		}
+	}
+
+	return migrated, nil
+}
+
+func resave[T any](ctx context.Context, collection mongoCollection[T], id func(T) string) (int, error) {
+	docs, err := collection.find(ctx, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+
+	for i, doc := range docs {
+		if err := collection.replace(ctx, id(doc), doc); err != nil {
+			return i, err
+		}
+	}
+
+	return len(docs), nil
+}
@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// mongoCollection holds the queries shared by every Mongo repository. Documents
+// are looked up by their string id field (food_id, order_id, ...) rather than _id.
+type mongoCollection[T any] struct {
+	collection *mongo.Collection
+	idField    string
+}
+
+func newMongoCollection[T any](collection *mongo.Collection, idField string) mongoCollection[T] {
+	return mongoCollection[T]{collection: collection, idField: idField}
+}
+
+func (m mongoCollection[T]) find(ctx context.Context, filter interface{}) ([]T, error) {
+	cursor, err := m.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := []T{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	return docs, nil
+}
+
+func (m mongoCollection[T]) findOne(ctx context.Context, filter interface{}) (T, error) {
+	var doc T
+
+	err := m.collection.FindOne(ctx, filter).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return doc, ErrNotFound
+	}
+
+	return doc, err
+}
+
+func (m mongoCollection[T]) findByID(ctx context.Context, id string) (T, error) {
+	return m.findOne(ctx, bson.M{m.idField: id})
+}
+
+func (m mongoCollection[T]) count(ctx context.Context, filter interface{}) (int64, error) {
+	return m.collection.CountDocuments(ctx, filter)
+}
+
+func (m mongoCollection[T]) insert(ctx context.Context, doc T) error {
+	_, err := m.collection.InsertOne(ctx, doc)
+	return err
+}
+
+func (m mongoCollection[T]) insertMany(ctx context.Context, docs []T) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	toBeInserted := make([]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		toBeInserted = append(toBeInserted, doc)
+	}
+
+	_, err := m.collection.InsertMany(ctx, toBeInserted)
+	return err
+}
+
+func (m mongoCollection[T]) replace(ctx context.Context, id string, doc T) error {
+	result, err := m.collection.ReplaceOne(ctx, bson.M{m.idField: id}, doc)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (m mongoCollection[T]) updateOne(ctx context.Context, filter interface{}, update interface{}) error {
+	result, err := m.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
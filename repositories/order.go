@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type OrderRepository interface {
+	FindAll(ctx context.Context) ([]models.Order, error)
+	FindByID(ctx context.Context, orderId string) (models.Order, error)
+	Insert(ctx context.Context, order models.Order) error
+	// Update stores the table and waiter of the order. Its status is only
+	// changed with UpdateStatus, so that no transition made meanwhile is lost.
+	Update(ctx context.Context, order models.Order) error
+	// UpdateStatus moves the order to change.To and appends change to its
+	// history, but only while the order is still in change.From; otherwise it
+	// returns ErrConflict. Orders stored without a status count as open.
+	UpdateStatus(ctx context.Context, orderId string, change models.OrderStatusChange) error
+}
+
+type mongoOrderRepository struct {
+	orders mongoCollection[models.Order]
+}
+
+func (r *mongoOrderRepository) FindAll(ctx context.Context) ([]models.Order, error) {
+	return r.orders.find(ctx, bson.M{})
+}
+
+func (r *mongoOrderRepository) FindByID(ctx context.Context, orderId string) (models.Order, error) {
+	return r.orders.findByID(ctx, orderId)
+}
+
+func (r *mongoOrderRepository) Insert(ctx context.Context, order models.Order) error {
+	return r.orders.insert(ctx, order)
+}
+
+func (r *mongoOrderRepository) Update(ctx context.Context, order models.Order) error {
+	return r.orders.updateOne(ctx, bson.M{"order_id": order.OrderID}, bson.M{"$set": bson.M{
+		"table_id":   order.TableID,
+		"waiter_id":  order.WaiterID,
+		"updated_at": order.UpdatedAt,
+	}})
+}
+
+func (r *mongoOrderRepository) UpdateStatus(ctx context.Context, orderId string, change models.OrderStatusChange) error {
+	filter := bson.M{"order_id": orderId, "status": change.From}
+	if change.From == models.OrderStatusOpen {
+		filter["status"] = bson.M{"$in": bson.A{change.From, nil}}
+	}
+
+	err := r.orders.updateOne(
+		ctx,
+		filter,
+		bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "status", Value: change.To},
+				{Key: "updated_at", Value: change.At},
+			}},
+			{Key: "$push", Value: bson.D{
+				{Key: "status_history", Value: change},
+			}},
+		},
+	)
+
+	if errors.Is(err, ErrNotFound) {
+		return ErrConflict
+	}
+
+	return err
+}
+
+type memoryOrderRepository struct {
+	orders *memoryCollection[models.Order]
+}
+
+func (r *memoryOrderRepository) FindAll(ctx context.Context) ([]models.Order, error) {
+	return r.orders.find(nil)
+}
+
+func (r *memoryOrderRepository) FindByID(ctx context.Context, orderId string) (models.Order, error) {
+	return r.orders.findByID(orderId)
+}
+
+func (r *memoryOrderRepository) Insert(ctx context.Context, order models.Order) error {
+	return r.orders.insert(order)
+}
+
+func (r *memoryOrderRepository) Update(ctx context.Context, order models.Order) error {
+	return r.orders.update(order.OrderID, func(stored *models.Order) error {
+		stored.TableID = order.TableID
+		stored.WaiterID = order.WaiterID
+		stored.UpdatedAt = order.UpdatedAt
+		return nil
+	})
+}
+
+func (r *memoryOrderRepository) UpdateStatus(ctx context.Context, orderId string, change models.OrderStatusChange) error {
+	err := r.orders.update(orderId, func(order *models.Order) error {
+		status := models.OrderStatusOpen
+		if order.Status != nil && *order.Status != "" {
+			status = *order.Status
+		}
+
+		if status != change.From {
+			return ErrConflict
+		}
+
+		order.Status = &change.To
+		order.StatusHistory = append(order.StatusHistory, change)
+		order.UpdatedAt = change.At
+		return nil
+	})
+
+	if errors.Is(err, ErrNotFound) {
+		return ErrConflict
+	}
+
+	return err
+}
@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type OrderItemRepository interface {
+	FindAll(ctx context.Context) ([]models.OrderItem, error)
+	FindByID(ctx context.Context, orderItemId string) (models.OrderItem, error)
+	FindByOrder(ctx context.Context, orderId string) ([]models.OrderItem, error)
+	FindByKitchenStatus(ctx context.Context, statuses ...string) ([]models.OrderItem, error)
+	InsertMany(ctx context.Context, orderItems []models.OrderItem) error
+	Update(ctx context.Context, orderItem models.OrderItem) error
+	// UpdateKitchenStatus moves the item from one kitchen status to another and
+	// returns ErrConflict if it is no longer in the from status.
+	UpdateKitchenStatus(ctx context.Context, orderItemId, from, to string, updatedAt time.Time) error
+}
+
+type mongoOrderItemRepository struct {
+	orderItems mongoCollection[models.OrderItem]
+}
+
+func (r *mongoOrderItemRepository) FindAll(ctx context.Context) ([]models.OrderItem, error) {
+	return r.orderItems.find(ctx, bson.M{})
+}
+
+func (r *mongoOrderItemRepository) FindByID(ctx context.Context, orderItemId string) (models.OrderItem, error) {
+	return r.orderItems.findByID(ctx, orderItemId)
+}
+
+func (r *mongoOrderItemRepository) FindByOrder(ctx context.Context, orderId string) ([]models.OrderItem, error) {
+	return r.orderItems.find(ctx, bson.M{"order_id": orderId})
+}
+
+func (r *mongoOrderItemRepository) FindByKitchenStatus(ctx context.Context, statuses ...string) ([]models.OrderItem, error) {
+	return r.orderItems.find(ctx, bson.M{"kitchen_status": bson.M{"$in": statuses}})
+}
+
+func (r *mongoOrderItemRepository) InsertMany(ctx context.Context, orderItems []models.OrderItem) error {
+	return r.orderItems.insertMany(ctx, orderItems)
+}
+
+func (r *mongoOrderItemRepository) Update(ctx context.Context, orderItem models.OrderItem) error {
+	return r.orderItems.replace(ctx, orderItem.OrderItemID, orderItem)
+}
+
+func (r *mongoOrderItemRepository) UpdateKitchenStatus(ctx context.Context, orderItemId, from, to string, updatedAt time.Time) error {
+	err := r.orderItems.updateOne(
+		ctx,
+		bson.M{"order_item_id": orderItemId, "kitchen_status": from},
+		bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "kitchen_status", Value: to},
+				{Key: "updated_at", Value: updatedAt},
+			}},
+		},
+	)
+
+	if errors.Is(err, ErrNotFound) {
+		return ErrConflict
+	}
+
+	return err
+}
+
+type memoryOrderItemRepository struct {
+	orderItems *memoryCollection[models.OrderItem]
+}
+
+func (r *memoryOrderItemRepository) FindAll(ctx context.Context) ([]models.OrderItem, error) {
+	return r.orderItems.find(nil)
+}
+
+func (r *memoryOrderItemRepository) FindByID(ctx context.Context, orderItemId string) (models.OrderItem, error) {
+	return r.orderItems.findByID(orderItemId)
+}
+
+func (r *memoryOrderItemRepository) FindByOrder(ctx context.Context, orderId string) ([]models.OrderItem, error) {
+	return r.orderItems.find(func(orderItem models.OrderItem) bool {
+		return orderItem.OrderID == orderId
+	})
+}
+
+func (r *memoryOrderItemRepository) FindByKitchenStatus(ctx context.Context, statuses ...string) ([]models.OrderItem, error) {
+	return r.orderItems.find(func(orderItem models.OrderItem) bool {
+		for _, status := range statuses {
+			if orderItem.KitchenStatus != nil && *orderItem.KitchenStatus == status {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (r *memoryOrderItemRepository) InsertMany(ctx context.Context, orderItems []models.OrderItem) error {
+	return r.orderItems.insertMany(orderItems)
+}
+
+func (r *memoryOrderItemRepository) Update(ctx context.Context, orderItem models.OrderItem) error {
+	return r.orderItems.replace(orderItem.OrderItemID, orderItem)
+}
+
+func (r *memoryOrderItemRepository) UpdateKitchenStatus(ctx context.Context, orderItemId, from, to string, updatedAt time.Time) error {
+	err := r.orderItems.update(orderItemId, func(orderItem *models.OrderItem) error {
+		if orderItem.KitchenStatus == nil || *orderItem.KitchenStatus != from {
+			return ErrConflict
+		}
+
+		orderItem.KitchenStatus = &to
+		orderItem.UpdatedAt = updatedAt
+		return nil
+	})
+
+	if errors.Is(err, ErrNotFound) {
+		return ErrConflict
+	}
+
+	return err
+}
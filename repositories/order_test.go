@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+)
+
+func TestMemoryOrderUpdateStatus(t *testing.T) {
+	served := models.OrderStatusServed
+
+	tests := []struct {
+		name    string
+		status  *string
+		orderId string
+		change  models.OrderStatusChange
+		wantErr error
+		want    string
+	}{
+		{"from the stored status", &served, "o1", models.OrderStatusChange{From: models.OrderStatusServed, To: models.OrderStatusBilled}, nil, models.OrderStatusBilled},
+		{"from a stale status", &served, "o1", models.OrderStatusChange{From: models.OrderStatusSentToKitchen, To: models.OrderStatusServed}, ErrConflict, models.OrderStatusServed},
+		{"without a status", nil, "o1", models.OrderStatusChange{From: models.OrderStatusOpen, To: models.OrderStatusSentToKitchen}, nil, models.OrderStatusSentToKitchen},
+		{"missing order", &served, "o2", models.OrderStatusChange{From: models.OrderStatusServed, To: models.OrderStatusBilled}, ErrConflict, models.OrderStatusServed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			orders := NewMemoryStore().Orders
+
+			if err := orders.Insert(ctx, models.Order{OrderID: "o1", Status: test.status}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := orders.UpdateStatus(ctx, test.orderId, test.change); !errors.Is(err, test.wantErr) {
+				t.Fatalf("got %v, want %v", err, test.wantErr)
+			}
+
+			stored, err := orders.FindByID(ctx, "o1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			status := models.OrderStatusOpen
+			if stored.Status != nil {
+				status = *stored.Status
+			}
+			if status != test.want {
+				t.Errorf("got status %s, want %s", status, test.want)
+			}
+
+			if test.wantErr == nil && (len(stored.StatusHistory) != 1 || stored.StatusHistory[0] != test.change) {
+				t.Errorf("got history %+v, want %+v", stored.StatusHistory, test.change)
+			}
+		})
+	}
+}
+
+func TestMemoryOrderUpdateKeepsStatus(t *testing.T) {
+	ctx := context.Background()
+	orders := NewMemoryStore().Orders
+	status := models.OrderStatusOpen
+	table := "t1"
+
+	if err := orders.Insert(ctx, models.Order{OrderID: "o1", Status: &status, TableID: &table}); err != nil {
+		t.Fatal(err)
+	}
+
+	// An update made from a copy read before the order was sent to the
+	// kitchen must not move it back.
+	stale, err := orders.FindByID(ctx, "o1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := orders.UpdateStatus(ctx, "o1", models.OrderStatusChange{From: models.OrderStatusOpen, To: models.OrderStatusSentToKitchen}); err != nil {
+		t.Fatal(err)
+	}
+
+	newTable := "t2"
+	stale.TableID = &newTable
+	if err := orders.Update(ctx, stale); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := orders.FindByID(ctx, "o1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *stored.Status != models.OrderStatusSentToKitchen || *stored.TableID != "t2" {
+		t.Errorf("got status %s and table %s, want %s and t2", *stored.Status, *stored.TableID, models.OrderStatusSentToKitchen)
+	}
+}
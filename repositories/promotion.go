@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type PromotionRepository interface {
+	FindAll(ctx context.Context) ([]models.Promotion, error)
+	FindByID(ctx context.Context, promotionId string) (models.Promotion, error)
+	FindByCouponCode(ctx context.Context, couponCode string) (models.Promotion, error)
+	Insert(ctx context.Context, promotion models.Promotion) error
+	// Update stores the promotion but leaves its usage count as it is, which
+	// is only changed with Redeem and Unredeem so that no use counted
+	// meanwhile is lost.
+	Update(ctx context.Context, promotion models.Promotion) error
+	// Redeem counts one more use of the promotion, or returns ErrConflict if
+	// it has reached its usage limit.
+	Redeem(ctx context.Context, promotionId string, updatedAt time.Time) error
+	// Unredeem gives back a use counted by Redeem.
+	Unredeem(ctx context.Context, promotionId string, updatedAt time.Time) error
+}
+
+type mongoPromotionRepository struct {
+	promotions mongoCollection[models.Promotion]
+}
+
+func (r *mongoPromotionRepository) FindAll(ctx context.Context) ([]models.Promotion, error) {
+	return r.promotions.find(ctx, bson.M{})
+}
+
+func (r *mongoPromotionRepository) FindByID(ctx context.Context, promotionId string) (models.Promotion, error) {
+	return r.promotions.findByID(ctx, promotionId)
+}
+
+func (r *mongoPromotionRepository) FindByCouponCode(ctx context.Context, couponCode string) (models.Promotion, error) {
+	return r.promotions.findOne(ctx, bson.M{"coupon_code": couponCode})
+}
+
+func (r *mongoPromotionRepository) Insert(ctx context.Context, promotion models.Promotion) error {
+	return r.promotions.insert(ctx, promotion)
+}
+
+func (r *mongoPromotionRepository) Update(ctx context.Context, promotion models.Promotion) error {
+	return r.promotions.updateOne(ctx, bson.M{"promotion_id": promotion.PromotionID}, bson.M{"$set": bson.M{
+		"name":         promotion.Name,
+		"type":         promotion.Type,
+		"scope":        promotion.Scope,
+		"value":        promotion.Value,
+		"food_ids":     promotion.FoodIDs,
+		"buy_quantity": promotion.BuyQuantity,
+		"get_quantity": promotion.GetQuantity,
+		"coupon_code":  promotion.CouponCode,
+		"usage_limit":  promotion.UsageLimit,
+		"starts_at":    promotion.StartsAt,
+		"expires_at":   promotion.ExpiresAt,
+		"stackable":    promotion.Stackable,
+		"priority":     promotion.Priority,
+		"active":       promotion.Active,
+		"updated_at":   promotion.UpdatedAt,
+	}})
+}
+
+func (r *mongoPromotionRepository) Redeem(ctx context.Context, promotionId string, updatedAt time.Time) error {
+	err := r.promotions.updateOne(
+		ctx,
+		bson.M{
+			"promotion_id": promotionId,
+			"$or": bson.A{
+				bson.M{"usage_limit": nil},
+				bson.M{"$expr": bson.M{"$lt": bson.A{"$usage_count", "$usage_limit"}}},
+			},
+		},
+		bson.D{
+			{Key: "$inc", Value: bson.D{{Key: "usage_count", Value: 1}}},
+			{Key: "$set", Value: bson.D{{Key: "updated_at", Value: updatedAt}}},
+		},
+	)
+
+	if errors.Is(err, ErrNotFound) {
+		return ErrConflict
+	}
+
+	return err
+}
+
+func (r *mongoPromotionRepository) Unredeem(ctx context.Context, promotionId string, updatedAt time.Time) error {
+	return r.promotions.updateOne(
+		ctx,
+		bson.M{"promotion_id": promotionId, "usage_count": bson.M{"$gt": 0}},
+		bson.D{
+			{Key: "$inc", Value: bson.D{{Key: "usage_count", Value: -1}}},
+			{Key: "$set", Value: bson.D{{Key: "updated_at", Value: updatedAt}}},
+		},
+	)
+}
+
+type memoryPromotionRepository struct {
+	promotions *memoryCollection[models.Promotion]
+}
+
+func (r *memoryPromotionRepository) FindAll(ctx context.Context) ([]models.Promotion, error) {
+	return r.promotions.find(nil)
+}
+
+func (r *memoryPromotionRepository) FindByID(ctx context.Context, promotionId string) (models.Promotion, error) {
+	return r.promotions.findByID(promotionId)
+}
+
+func (r *memoryPromotionRepository) FindByCouponCode(ctx context.Context, couponCode string) (models.Promotion, error) {
+	return r.promotions.findOne(func(promotion models.Promotion) bool {
+		return promotion.CouponCode != nil && *promotion.CouponCode == couponCode
+	})
+}
+
+func (r *memoryPromotionRepository) Insert(ctx context.Context, promotion models.Promotion) error {
+	return r.promotions.insert(promotion)
+}
+
+func (r *memoryPromotionRepository) Update(ctx context.Context, promotion models.Promotion) error {
+	return r.promotions.update(promotion.PromotionID, func(stored *models.Promotion) error {
+		promotion.UsageCount = stored.UsageCount
+		*stored = promotion
+		return nil
+	})
+}
+
+func (r *memoryPromotionRepository) Unredeem(ctx context.Context, promotionId string, updatedAt time.Time) error {
+	return r.promotions.update(promotionId, func(promotion *models.Promotion) error {
+		if promotion.UsageCount > 0 {
+			promotion.UsageCount--
+		}
+		promotion.UpdatedAt = updatedAt
+		return nil
+	})
+}
+
+func (r *memoryPromotionRepository) Redeem(ctx context.Context, promotionId string, updatedAt time.Time) error {
+	err := r.promotions.update(promotionId, func(promotion *models.Promotion) error {
+		if promotion.UsageLimit != nil && promotion.UsageCount >= *promotion.UsageLimit {
+			return ErrConflict
+		}
+
+		promotion.UsageCount++
+		promotion.UpdatedAt = updatedAt
+		return nil
+	})
+
+	if errors.Is(err, ErrNotFound) {
+		return ErrConflict
+	}
+
+	return err
+}
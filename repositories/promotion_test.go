@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+)
+
+func TestMemoryPromotionRedeem(t *testing.T) {
+	ctx := context.Background()
+	promotions := NewMemoryStore().Promotions
+	limit := 3
+
+	if err := promotions.Insert(ctx, models.Promotion{PromotionID: "p1", UsageLimit: &limit}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = promotions.Redeem(ctx, "p1", time.Now())
+		}(i)
+	}
+	wg.Wait()
+
+	redeemed := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			redeemed++
+		case !errors.Is(err, ErrConflict):
+			t.Fatalf("got %v, want ErrConflict", err)
+		}
+	}
+
+	if redeemed != limit {
+		t.Errorf("got %d uses, want %d", redeemed, limit)
+	}
+
+	if err := promotions.Unredeem(ctx, "p1", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := promotions.Redeem(ctx, "p1", time.Now()); err != nil {
+		t.Errorf("got %v redeeming a use given back, want nil", err)
+	}
+
+	if err := promotions.Redeem(ctx, "p2", time.Now()); !errors.Is(err, ErrConflict) {
+		t.Errorf("got %v redeeming a missing promotion, want ErrConflict", err)
+	}
+}
+
+func TestMemoryPromotionUpdateKeepsUsageCount(t *testing.T) {
+	ctx := context.Background()
+	promotions := NewMemoryStore().Promotions
+	name := "Happy hour"
+
+	if err := promotions.Insert(ctx, models.Promotion{PromotionID: "p1", Name: &name}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A promotion edited from a copy read before it was redeemed must not
+	// lose the use.
+	stale, err := promotions.FindByID(ctx, "p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := promotions.Redeem(ctx, "p1", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	newName := "Early bird"
+	stale.Name = &newName
+	if err := promotions.Update(ctx, stale); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := promotions.FindByID(ctx, "p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.UsageCount != 1 || *stored.Name != newName {
+		t.Errorf("got %d uses of %s, want 1 of %s", stored.UsageCount, *stored.Name, newName)
+	}
+}
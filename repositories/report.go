@@ -0,0 +1,728 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ReportRepository aggregates sales over a range of time. Revenue is the
+// grand total of the invoices created in the range less what was refunded on
+// them, leaving voided invoices out. Food and category sales are the items of the orders opened in the
+// range and billed, leaving voided items out, since the lines of an invoice
+// split evenly repeat every item. Combos are left out of food and category
+// sales, since their price is not split between their foods. Amounts are in
+// the base currency.
+type ReportRepository interface {
+	RevenueByPeriod(ctx context.Context, reportRange models.ReportRange, period string) ([]models.PeriodRevenue, error)
+	Tickets(ctx context.Context, reportRange models.ReportRange) (models.TicketSummary, error)
+	RevenueByTable(ctx context.Context, reportRange models.ReportRange) ([]models.TableRevenue, error)
+	TopFoods(ctx context.Context, reportRange models.ReportRange, limit int) ([]models.FoodSales, error)
+	SalesByCategory(ctx context.Context, reportRange models.ReportRange) ([]models.CategorySales, error)
+}
+
+// periodFormats are the $dateToString formats of the report periods.
+var periodFormats = map[string]string{
+	models.ReportPeriodDay:   "%Y-%m-%d",
+	models.ReportPeriodWeek:  "%G-W%V",
+	models.ReportPeriodMonth: "%Y-%m",
+}
+
+// billedStatuses are the statuses of the orders whose items were sold.
+var billedStatuses = []string{models.OrderStatusBilled, models.OrderStatusClosed}
+
+type mongoReportRepository struct {
+	invoices   *mongo.Collection
+	orderItems *mongo.Collection
+}
+
+// invoiceStages match the invoices in the range that were billed and not
+// voided, and work out their revenue less their refunds.
+func invoiceStages(reportRange models.ReportRange) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"created_at":  bson.M{"$gte": reportRange.From, "$lt": reportRange.To},
+			"grand_total": bson.M{"$ne": nil},
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": "ledger",
+			"let":  bson.M{"invoice_id": "$invoice_id"},
+			"pipeline": bson.A{bson.M{"$match": bson.M{"$expr": bson.M{"$and": bson.A{
+				bson.M{"$eq": bson.A{"$invoice_id", "$$invoice_id"}},
+				bson.M{"$eq": bson.A{"$type", models.LedgerEntryVoid}},
+			}}}}},
+			"as": "voids",
+		}}},
+		{{Key: "$match", Value: bson.M{"voids": bson.M{"$size": 0}}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": "ledger",
+			"let":  bson.M{"invoice_id": "$invoice_id"},
+			"pipeline": bson.A{bson.M{"$match": bson.M{"$expr": bson.M{"$and": bson.A{
+				bson.M{"$eq": bson.A{"$invoice_id", "$$invoice_id"}},
+				bson.M{"$eq": bson.A{"$type", models.LedgerEntryRefund}},
+			}}}}},
+			"as": "refunds",
+		}}},
+		{{Key: "$addFields", Value: bson.M{
+			"revenue": bson.M{"$subtract": bson.A{"$grand_total.amount", bson.M{"$sum": "$refunds.amount.amount"}}},
+		}}},
+	}
+}
+
+// orderItemStages match the items of the orders opened in the range that
+// were billed, leaving out voided items and combos, and look up their food.
+func orderItemStages(reportRange models.ReportRange) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"food_id": bson.M{"$ne": nil}}}},
+		{{Key: "$lookup", Value: bson.M{"from": "order", "localField": "order_id", "foreignField": "order_id", "as": "order"}}},
+		{{Key: "$unwind", Value: "$order"}},
+		{{Key: "$match", Value: bson.M{
+			"order.created_at": bson.M{"$gte": reportRange.From, "$lt": reportRange.To},
+			"order.status":     bson.M{"$in": billedStatuses},
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": "ledger",
+			"let":  bson.M{"order_item_id": "$order_item_id"},
+			"pipeline": bson.A{bson.M{"$match": bson.M{"$expr": bson.M{"$and": bson.A{
+				bson.M{"$eq": bson.A{"$order_item_id", "$$order_item_id"}},
+				bson.M{"$eq": bson.A{"$type", models.LedgerEntryVoid}},
+			}}}}},
+			"as": "voids",
+		}}},
+		{{Key: "$match", Value: bson.M{"voids": bson.M{"$size": 0}}}},
+		{{Key: "$lookup", Value: bson.M{"from": "food", "localField": "food_id", "foreignField": "food_id", "as": "food"}}},
+		{{Key: "$unwind", Value: bson.M{"path": "$food", "preserveNullAndEmptyArrays": true}}},
+	}
+}
+
+// salesFields are the $group fields adding up the quantity and price of
+// order items.
+var salesFields = bson.M{
+	"quantity": bson.M{"$sum": "$quantity"},
+	"revenue":  bson.M{"$sum": bson.M{"$multiply": bson.A{"$quantity", "$unit_price.amount"}}},
+}
+
+// aggregate runs the pipeline on the collection and decodes its results.
+func aggregate[T any](ctx context.Context, collection *mongo.Collection, pipeline mongo.Pipeline) ([]T, error) {
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []T{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (r *mongoReportRepository) RevenueByPeriod(ctx context.Context, reportRange models.ReportRange, period string) ([]models.PeriodRevenue, error) {
+	format, ok := periodFormats[period]
+	if !ok {
+		return nil, fmt.Errorf("unknown report period %q", period)
+	}
+
+	pipeline := append(invoiceStages(reportRange),
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"$dateToString": bson.M{
+				"format":   format,
+				"date":     "$created_at",
+				"timezone": reportRange.Location.String(),
+			}},
+			"invoices": bson.M{"$sum": 1},
+			"revenue":  bson.M{"$sum": "$revenue"},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	)
+
+	results, err := aggregate[struct {
+		Period   string `bson:"_id"`
+		Invoices int    `bson:"invoices"`
+		Revenue  int64  `bson:"revenue"`
+	}](ctx, r.invoices, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	revenues := make([]models.PeriodRevenue, 0, len(results))
+	for _, result := range results {
+		revenues = append(revenues, models.PeriodRevenue{
+			Period:   result.Period,
+			Invoices: result.Invoices,
+			Revenue:  baseAmount(result.Revenue),
+		})
+	}
+
+	return revenues, nil
+}
+
+func (r *mongoReportRepository) Tickets(ctx context.Context, reportRange models.ReportRange) (models.TicketSummary, error) {
+	pipeline := append(invoiceStages(reportRange),
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":      nil,
+			"orders":   bson.M{"$addToSet": "$order_id"},
+			"invoices": bson.M{"$sum": 1},
+			"revenue":  bson.M{"$sum": "$revenue"},
+		}}},
+		bson.D{{Key: "$project", Value: bson.M{"orders": bson.M{"$size": "$orders"}, "invoices": 1, "revenue": 1}}},
+	)
+
+	results, err := aggregate[struct {
+		Orders   int   `bson:"orders"`
+		Invoices int   `bson:"invoices"`
+		Revenue  int64 `bson:"revenue"`
+	}](ctx, r.invoices, pipeline)
+	if err != nil || len(results) == 0 {
+		return ticketSummary(0, 0, 0), err
+	}
+
+	return ticketSummary(results[0].Orders, results[0].Invoices, results[0].Revenue), nil
+}
+
+func (r *mongoReportRepository) RevenueByTable(ctx context.Context, reportRange models.ReportRange) ([]models.TableRevenue, error) {
+	pipeline := append(invoiceStages(reportRange),
+		bson.D{{Key: "$lookup", Value: bson.M{"from": "order", "localField": "order_id", "foreignField": "order_id", "as": "order"}}},
+		bson.D{{Key: "$unwind", Value: "$order"}},
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":      "$order.table_id",
+			"orders":   bson.M{"$addToSet": "$order_id"},
+			"invoices": bson.M{"$sum": 1},
+			"revenue":  bson.M{"$sum": "$revenue"},
+		}}},
+		bson.D{{Key: "$lookup", Value: bson.M{"from": "table", "localField": "_id", "foreignField": "table_id", "as": "table"}}},
+		bson.D{{Key: "$project", Value: bson.M{
+			"table_number": bson.M{"$arrayElemAt": bson.A{"$table.table_number", 0}},
+			"orders":       bson.M{"$size": "$orders"},
+			"invoices":     1,
+			"revenue":      1,
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "revenue", Value: -1}, {Key: "_id", Value: 1}}}},
+	)
+
+	results, err := aggregate[struct {
+		TableID     string `bson:"_id"`
+		TableNumber *int   `bson:"table_number"`
+		Orders      int    `bson:"orders"`
+		Invoices    int    `bson:"invoices"`
+		Revenue     int64  `bson:"revenue"`
+	}](ctx, r.invoices, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	revenues := make([]models.TableRevenue, 0, len(results))
+	for _, result := range results {
+		revenues = append(revenues, models.TableRevenue{
+			TableID:     result.TableID,
+			TableNumber: result.TableNumber,
+			Orders:      result.Orders,
+			Invoices:    result.Invoices,
+			Revenue:     baseAmount(result.Revenue),
+		})
+	}
+
+	return revenues, nil
+}
+
+// TopFoods adds up the items by food and variant first, then the variants of
+// each food.
+func (r *mongoReportRepository) TopFoods(ctx context.Context, reportRange models.ReportRange, limit int) ([]models.FoodSales, error) {
+	variantGroup := bson.M{
+		"_id":           bson.M{"food_id": "$food_id", "variant_id": "$variant_id"},
+		"name":          bson.M{"$first": "$food.name"},
+		"food_variants": bson.M{"$first": "$food.variants"},
+	}
+	for field, sum := range salesFields {
+		variantGroup[field] = sum
+	}
+
+	group := bson.M{
+		"_id":           "$_id.food_id",
+		"name":          bson.M{"$first": "$name"},
+		"food_variants": bson.M{"$first": "$food_variants"},
+		"quantity":      bson.M{"$sum": "$quantity"},
+		"revenue":       bson.M{"$sum": "$revenue"},
+		"variants": bson.M{"$push": bson.M{
+			"variant_id": "$_id.variant_id",
+			"quantity":   "$quantity",
+			"revenue":    "$revenue",
+		}},
+	}
+
+	pipeline := append(orderItemStages(reportRange),
+		bson.D{{Key: "$group", Value: variantGroup}},
+		bson.D{{Key: "$group", Value: group}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "quantity", Value: -1}, {Key: "revenue", Value: -1}, {Key: "_id", Value: 1}}}},
+		bson.D{{Key: "$limit", Value: limit}},
+	)
+
+	results, err := aggregate[struct {
+		FoodID       string               `bson:"_id"`
+		Name         *string              `bson:"name"`
+		FoodVariants []models.FoodVariant `bson:"food_variants"`
+		Quantity     int                  `bson:"quantity"`
+		Revenue      int64                `bson:"revenue"`
+		Variants     []struct {
+			VariantID string `bson:"variant_id"`
+			Quantity  int    `bson:"quantity"`
+			Revenue   int64  `bson:"revenue"`
+		} `bson:"variants"`
+	}](ctx, r.orderItems, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	sales := make([]models.FoodSales, 0, len(results))
+	for _, result := range results {
+		variants := []models.VariantSales{}
+		for _, variant := range result.Variants {
+			variants = append(variants, models.VariantSales{
+				VariantID: variant.VariantID,
+				Quantity:  variant.Quantity,
+				Revenue:   baseAmount(variant.Revenue),
+			})
+		}
+
+		sales = append(sales, models.FoodSales{
+			FoodID:   result.FoodID,
+			Name:     result.Name,
+			Quantity: result.Quantity,
+			Revenue:  baseAmount(result.Revenue),
+			Variants: variantSales(result.FoodVariants, variants),
+		})
+	}
+
+	return sales, nil
+}
+
+func (r *mongoReportRepository) SalesByCategory(ctx context.Context, reportRange models.ReportRange) ([]models.CategorySales, error) {
+	group := bson.M{"_id": bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$menu.category", 0}}, ""}}}
+	for field, sum := range salesFields {
+		group[field] = sum
+	}
+
+	pipeline := append(orderItemStages(reportRange),
+		bson.D{{Key: "$lookup", Value: bson.M{"from": "menu", "localField": "food.menu_id", "foreignField": "menu_id", "as": "menu"}}},
+		bson.D{{Key: "$group", Value: group}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "revenue", Value: -1}, {Key: "_id", Value: 1}}}},
+	)
+
+	results, err := aggregate[struct {
+		Category string `bson:"_id"`
+		Quantity int    `bson:"quantity"`
+		Revenue  int64  `bson:"revenue"`
+	}](ctx, r.orderItems, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	sales := make([]models.CategorySales, 0, len(results))
+	for _, result := range results {
+		sales = append(sales, models.CategorySales{
+			Category: result.Category,
+			Quantity: result.Quantity,
+			Revenue:  baseAmount(result.Revenue),
+		})
+	}
+
+	return sales, nil
+}
+
+type memoryReportRepository struct {
+	invoices   *memoryCollection[models.Invoice]
+	orders     *memoryCollection[models.Order]
+	orderItems *memoryCollection[models.OrderItem]
+	foods      *memoryCollection[models.Food]
+	menus      *memoryCollection[models.Menu]
+	tables     *memoryCollection[models.Table]
+	ledger     *memoryCollection[models.LedgerEntry]
+}
+
+// billedInvoice is an invoice along with its revenue less its refunds.
+type billedInvoice struct {
+	models.Invoice
+	revenue int64
+}
+
+// voided returns the ids of the voided invoices and order items.
+func (r *memoryReportRepository) voided() (map[string]bool, error) {
+	entries, err := r.ledger.find(func(entry models.LedgerEntry) bool {
+		return entry.Type == models.LedgerEntryVoid
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	voided := map[string]bool{}
+	for _, entry := range entries {
+		if entry.InvoiceID != nil {
+			voided[*entry.InvoiceID] = true
+		}
+		if entry.OrderItemID != nil {
+			voided[*entry.OrderItemID] = true
+		}
+	}
+
+	return voided, nil
+}
+
+// refunded returns the amount refunded on each invoice.
+func (r *memoryReportRepository) refunded() (map[string]int64, error) {
+	entries, err := r.ledger.find(func(entry models.LedgerEntry) bool {
+		return entry.Type == models.LedgerEntryRefund && entry.InvoiceID != nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refunded := map[string]int64{}
+	for _, entry := range entries {
+		refunded[*entry.InvoiceID] += entry.Amount.Amount
+	}
+
+	return refunded, nil
+}
+
+func (r *memoryReportRepository) billedInvoices(reportRange models.ReportRange) ([]billedInvoice, error) {
+	voided, err := r.voided()
+	if err != nil {
+		return nil, err
+	}
+
+	refunded, err := r.refunded()
+	if err != nil {
+		return nil, err
+	}
+
+	invoices, err := r.invoices.find(func(invoice models.Invoice) bool {
+		return !invoice.CreatedAt.Before(reportRange.From) && invoice.CreatedAt.Before(reportRange.To) &&
+			invoice.GrandTotal != nil && !voided[invoice.InvoiceID]
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	billed := make([]billedInvoice, 0, len(invoices))
+	for _, invoice := range invoices {
+		billed = append(billed, billedInvoice{Invoice: invoice, revenue: invoice.GrandTotal.Amount - refunded[invoice.InvoiceID]})
+	}
+
+	return billed, nil
+}
+
+// soldItems returns the items of the orders opened in the range that were
+// billed, along with the food of each.
+func (r *memoryReportRepository) soldItems(reportRange models.ReportRange) ([]models.OrderItem, map[string]models.Food, error) {
+	voided, err := r.voided()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	orders, err := r.orders.find(func(order models.Order) bool {
+		if order.Status == nil || (*order.Status != models.OrderStatusBilled && *order.Status != models.OrderStatusClosed) {
+			return false
+		}
+
+		return !order.CreatedAt.Before(reportRange.From) && order.CreatedAt.Before(reportRange.To)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	billed := map[string]bool{}
+	for _, order := range orders {
+		billed[order.OrderID] = true
+	}
+
+	items, err := r.orderItems.find(func(item models.OrderItem) bool {
+		return billed[item.OrderID] && !voided[item.OrderItemID] && item.FoodID != nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	allFoods, err := r.foods.find(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	foods := map[string]models.Food{}
+	for _, food := range allFoods {
+		foods[food.FoodID] = food
+	}
+
+	return items, foods, nil
+}
+
+func (r *memoryReportRepository) RevenueByPeriod(ctx context.Context, reportRange models.ReportRange, period string) ([]models.PeriodRevenue, error) {
+	if _, ok := periodFormats[period]; !ok {
+		return nil, fmt.Errorf("unknown report period %q", period)
+	}
+
+	invoices, err := r.billedInvoices(reportRange)
+	if err != nil {
+		return nil, err
+	}
+
+	byPeriod := map[string]*models.PeriodRevenue{}
+	revenues := []*models.PeriodRevenue{}
+	for _, invoice := range invoices {
+		key := periodOf(invoice.CreatedAt.In(reportRange.Location), period)
+		if byPeriod[key] == nil {
+			byPeriod[key] = &models.PeriodRevenue{Period: key, Revenue: baseAmount(0)}
+			revenues = append(revenues, byPeriod[key])
+		}
+
+		byPeriod[key].Invoices++
+		byPeriod[key].Revenue.Amount += invoice.revenue
+	}
+
+	sort.Slice(revenues, func(i, j int) bool {
+		return revenues[i].Period < revenues[j].Period
+	})
+
+	results := make([]models.PeriodRevenue, 0, len(revenues))
+	for _, revenue := range revenues {
+		results = append(results, *revenue)
+	}
+
+	return results, nil
+}
+
+func (r *memoryReportRepository) Tickets(ctx context.Context, reportRange models.ReportRange) (models.TicketSummary, error) {
+	invoices, err := r.billedInvoices(reportRange)
+	if err != nil {
+		return ticketSummary(0, 0, 0), err
+	}
+
+	orders := map[string]bool{}
+	var revenue int64
+	for _, invoice := range invoices {
+		orders[invoice.OrderID] = true
+		revenue += invoice.revenue
+	}
+
+	return ticketSummary(len(orders), len(invoices), revenue), nil
+}
+
+func (r *memoryReportRepository) RevenueByTable(ctx context.Context, reportRange models.ReportRange) ([]models.TableRevenue, error) {
+	invoices, err := r.billedInvoices(reportRange)
+	if err != nil {
+		return nil, err
+	}
+
+	byTable := map[string]*models.TableRevenue{}
+	orders := map[string]map[string]bool{}
+	revenues := []*models.TableRevenue{}
+	for _, invoice := range invoices {
+		order, err := r.orders.findByID(invoice.OrderID)
+		if err != nil || order.TableID == nil {
+			continue
+		}
+
+		tableId := *order.TableID
+		if byTable[tableId] == nil {
+			byTable[tableId] = &models.TableRevenue{TableID: tableId, Revenue: baseAmount(0)}
+			if table, err := r.tables.findByID(tableId); err == nil {
+				byTable[tableId].TableNumber = table.TableNumber
+			}
+			orders[tableId] = map[string]bool{}
+			revenues = append(revenues, byTable[tableId])
+		}
+
+		orders[tableId][invoice.OrderID] = true
+		byTable[tableId].Orders = len(orders[tableId])
+		byTable[tableId].Invoices++
+		byTable[tableId].Revenue.Amount += invoice.revenue
+	}
+
+	sort.SliceStable(revenues, func(i, j int) bool {
+		if revenues[i].Revenue.Amount != revenues[j].Revenue.Amount {
+			return revenues[i].Revenue.Amount > revenues[j].Revenue.Amount
+		}
+		return revenues[i].TableID < revenues[j].TableID
+	})
+
+	results := make([]models.TableRevenue, 0, len(revenues))
+	for _, revenue := range revenues {
+		results = append(results, *revenue)
+	}
+
+	return results, nil
+}
+
+func (r *memoryReportRepository) TopFoods(ctx context.Context, reportRange models.ReportRange, limit int) ([]models.FoodSales, error) {
+	items, foods, err := r.soldItems(reportRange)
+	if err != nil {
+		return nil, err
+	}
+
+	byFood := map[string]*models.FoodSales{}
+	byVariant := map[string]map[string]*models.VariantSales{}
+	sales := []*models.FoodSales{}
+	for _, item := range items {
+		foodId := *item.FoodID
+		if byFood[foodId] == nil {
+			byFood[foodId] = &models.FoodSales{FoodID: foodId, Revenue: baseAmount(0)}
+			if food, ok := foods[foodId]; ok {
+				byFood[foodId].Name = food.Name
+			}
+			byVariant[foodId] = map[string]*models.VariantSales{}
+			sales = append(sales, byFood[foodId])
+		}
+
+		revenue := item.UnitPrice.Amount * int64(*item.Quantity)
+		byFood[foodId].Quantity += *item.Quantity
+		byFood[foodId].Revenue.Amount += revenue
+
+		variantId := ""
+		if item.VariantID != nil {
+			variantId = *item.VariantID
+		}
+		if byVariant[foodId][variantId] == nil {
+			byVariant[foodId][variantId] = &models.VariantSales{VariantID: variantId, Revenue: baseAmount(0)}
+		}
+		byVariant[foodId][variantId].Quantity += *item.Quantity
+		byVariant[foodId][variantId].Revenue.Amount += revenue
+	}
+
+	for _, sale := range sales {
+		variants := []models.VariantSales{}
+		for _, variant := range byVariant[sale.FoodID] {
+			variants = append(variants, *variant)
+		}
+		sale.Variants = variantSales(foods[sale.FoodID].Variants, variants)
+	}
+
+	sort.SliceStable(sales, func(i, j int) bool {
+		if sales[i].Quantity != sales[j].Quantity {
+			return sales[i].Quantity > sales[j].Quantity
+		}
+		if sales[i].Revenue.Amount != sales[j].Revenue.Amount {
+			return sales[i].Revenue.Amount > sales[j].Revenue.Amount
+		}
+		return sales[i].FoodID < sales[j].FoodID
+	})
+
+	results := make([]models.FoodSales, 0, min(limit, len(sales)))
+	for _, sale := range sales[:min(limit, len(sales))] {
+		results = append(results, *sale)
+	}
+
+	return results, nil
+}
+
+func (r *memoryReportRepository) SalesByCategory(ctx context.Context, reportRange models.ReportRange) ([]models.CategorySales, error) {
+	items, foods, err := r.soldItems(reportRange)
+	if err != nil {
+		return nil, err
+	}
+
+	byCategory := map[string]*models.CategorySales{}
+	sales := []*models.CategorySales{}
+	for _, item := range items {
+		category := ""
+		if food, ok := foods[*item.FoodID]; ok && food.MenuID != nil {
+			if menu, err := r.menus.findByID(*food.MenuID); err == nil {
+				category = menu.Category
+			}
+		}
+
+		if byCategory[category] == nil {
+			byCategory[category] = &models.CategorySales{Category: category, Revenue: baseAmount(0)}
+			sales = append(sales, byCategory[category])
+		}
+
+		byCategory[category].Quantity += *item.Quantity
+		byCategory[category].Revenue.Amount += item.UnitPrice.Amount * int64(*item.Quantity)
+	}
+
+	sort.SliceStable(sales, func(i, j int) bool {
+		if sales[i].Revenue.Amount != sales[j].Revenue.Amount {
+			return sales[i].Revenue.Amount > sales[j].Revenue.Amount
+		}
+		return sales[i].Category < sales[j].Category
+	})
+
+	results := make([]models.CategorySales, 0, len(sales))
+	for _, sale := range sales {
+		results = append(results, *sale)
+	}
+
+	return results, nil
+}
+
+// variantSales leaves out the items ordered without a variant, names the
+// variants after the food's, and sorts them like foods.
+func variantSales(foodVariants []models.FoodVariant, sales []models.VariantSales) []models.VariantSales {
+	results := []models.VariantSales{}
+	for _, sale := range sales {
+		if sale.VariantID == "" {
+			continue
+		}
+
+		for _, variant := range foodVariants {
+			if variant.VariantID == sale.VariantID {
+				sale.Name = variant.Name
+				sale.SKU = variant.SKU
+			}
+		}
+		results = append(results, sale)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Quantity != results[j].Quantity {
+			return results[i].Quantity > results[j].Quantity
+		}
+		if results[i].Revenue.Amount != results[j].Revenue.Amount {
+			return results[i].Revenue.Amount > results[j].Revenue.Amount
+		}
+		return results[i].VariantID < results[j].VariantID
+	})
+
+	return results
+}
+
+// periodOf formats the time like the $dateToString format of the period.
+func periodOf(at time.Time, period string) string {
+	switch period {
+	case models.ReportPeriodWeek:
+		year, week := at.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case models.ReportPeriodMonth:
+		return at.Format("2006-01")
+	default:
+		return at.Format(time.DateOnly)
+	}
+}
+
+func baseAmount(amount int64) models.Money {
+	return models.Money{Amount: amount, Currency: models.DefaultCurrency}
+}
+
+// ticketSummary works out the average ticket, rounded to the minor unit,
+// halves away from zero.
+func ticketSummary(orders, invoices int, revenue int64) models.TicketSummary {
+	summary := models.TicketSummary{
+		Orders:        orders,
+		Invoices:      invoices,
+		Revenue:       baseAmount(revenue),
+		AverageTicket: baseAmount(0),
+	}
+
+	if orders > 0 {
+		average := (2*revenue + int64(orders)) / (2 * int64(orders))
+		if revenue < 0 {
+			average = -((-2*revenue + int64(orders)) / (2 * int64(orders)))
+		}
+		summary.AverageTicket.Amount = average
+	}
+
+	return summary
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+)
+
+func TestMemoryReportRevenue(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tableId := "t1"
+	if err := store.Orders.Insert(ctx, models.Order{OrderID: "o1", TableID: &tableId}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, invoiceId := range []string{"paid", "refunded", "voided"} {
+		grandTotal := baseAmount(3000)
+		invoice := models.Invoice{InvoiceID: invoiceId, OrderID: "o1", CreatedAt: createdAt, GrandTotal: &grandTotal}
+		if err := store.Invoices.Insert(ctx, invoice); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	refunded, voided := "refunded", "voided"
+	entries := []models.LedgerEntry{
+		{LedgerEntryID: "r1", Type: models.LedgerEntryRefund, InvoiceID: &refunded, Amount: baseAmount(1000)},
+		{LedgerEntryID: "r2", Type: models.LedgerEntryRefund, InvoiceID: &refunded, Amount: baseAmount(500)},
+		{LedgerEntryID: "v1", Type: models.LedgerEntryVoid, InvoiceID: &voided, Amount: baseAmount(3000)},
+	}
+	for _, entry := range entries {
+		if err := store.Ledger.Insert(ctx, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reportRange := models.ReportRange{From: createdAt.Add(-time.Hour), To: createdAt.Add(time.Hour), Location: time.UTC}
+
+	// 30.00 paid and 30.00 less the 15.00 refunded, leaving the voided
+	// invoice out.
+	want := baseAmount(4500)
+
+	revenues, err := store.Reports.RevenueByPeriod(ctx, reportRange, models.ReportPeriodDay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revenues) != 1 || revenues[0].Revenue != want || revenues[0].Invoices != 2 {
+		t.Errorf("got revenue by period %+v, want %s over 2 invoices", revenues, want)
+	}
+
+	tickets, err := store.Reports.Tickets(ctx, reportRange)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tickets.Revenue != want || tickets.AverageTicket != want {
+		t.Errorf("got tickets %+v, want %s from one order", tickets, want)
+	}
+
+	tables, err := store.Reports.RevenueByTable(ctx, reportRange)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 1 || tables[0].Revenue != want {
+		t.Errorf("got revenue by table %+v, want %s", tables, want)
+	}
+}
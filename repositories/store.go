@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/EnesDemirtas/restaurant-management/database"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrNotFound = errors.New("document not found")
+	ErrConflict = errors.New("document was changed by another request")
+)
+
+// Store groups the repositories of every aggregate so they can be handed to
+// the services as a single dependency.
+type Store struct {
+	Foods         FoodRepository
+	Menus         MenuRepository
+	Tables        TableRepository
+	Orders        OrderRepository
+	OrderItems    OrderItemRepository
+	Invoices      InvoiceRepository
+	Users         UserRepository
+	TaxRates      TaxRateRepository
+	Ledger        LedgerRepository
+	Promotions    PromotionRepository
+	Combos        ComboRepository
+	ExchangeRates ExchangeRateRepository
+	BusinessDays  BusinessDayRepository
+	Reports       ReportRepository
+}
+
+func NewMongoStore(client *mongo.Client) Store {
+	return Store{
+		Foods:         &mongoFoodRepository{newMongoCollection[models.Food](database.OpenCollection(client, "food"), "food_id")},
+		Menus:         &mongoMenuRepository{newMongoCollection[models.Menu](database.OpenCollection(client, "menu"), "menu_id")},
+		Tables:        &mongoTableRepository{newMongoCollection[models.Table](database.OpenCollection(client, "table"), "table_id")},
+		Orders:        &mongoOrderRepository{newMongoCollection[models.Order](database.OpenCollection(client, "order"), "order_id")},
+		OrderItems:    &mongoOrderItemRepository{newMongoCollection[models.OrderItem](database.OpenCollection(client, "orderItem"), "order_item_id")},
+		Invoices:      &mongoInvoiceRepository{newMongoCollection[models.Invoice](database.OpenCollection(client, "invoice"), "invoice_id")},
+		Users:         &mongoUserRepository{newMongoCollection[models.User](database.OpenCollection(client, "user"), "user_id")},
+		TaxRates:      &mongoTaxRateRepository{newMongoCollection[models.TaxRate](database.OpenCollection(client, "taxRate"), "tax_rate_id")},
+		Ledger:        &mongoLedgerRepository{newMongoCollection[models.LedgerEntry](database.OpenCollection(client, "ledger"), "ledger_entry_id")},
+		Promotions:    &mongoPromotionRepository{newMongoCollection[models.Promotion](database.OpenCollection(client, "promotion"), "promotion_id")},
+		Combos:        &mongoComboRepository{newMongoCollection[models.Combo](database.OpenCollection(client, "combo"), "combo_id")},
+		ExchangeRates: &mongoExchangeRateRepository{newMongoCollection[models.ExchangeRate](database.OpenCollection(client, "exchangeRate"), "exchange_rate_id")},
+		BusinessDays:  &mongoBusinessDayRepository{newMongoCollection[models.BusinessDay](database.OpenCollection(client, "businessDay"), "business_day_id")},
+		Reports:       &mongoReportRepository{database.OpenCollection(client, "invoice"), database.OpenCollection(client, "orderItem")},
+	}
+}
+
+func NewMemoryStore() Store {
+	foods := newMemoryCollection(func(food models.Food) string { return food.FoodID })
+	menus := newMemoryCollection(func(menu models.Menu) string { return menu.MenuID })
+	tables := newMemoryCollection(func(table models.Table) string { return table.TableID })
+	orders := newMemoryCollection(func(order models.Order) string { return order.OrderID })
+	orderItems := newMemoryCollection(func(orderItem models.OrderItem) string { return orderItem.OrderItemID })
+	invoices := newMemoryCollection(func(invoice models.Invoice) string { return invoice.InvoiceID })
+	ledger := newMemoryCollection(func(entry models.LedgerEntry) string { return entry.LedgerEntryID })
+
+	return Store{
+		Foods:         &memoryFoodRepository{foods},
+		Menus:         &memoryMenuRepository{menus},
+		Tables:        &memoryTableRepository{tables},
+		Orders:        &memoryOrderRepository{orders},
+		OrderItems:    &memoryOrderItemRepository{orderItems},
+		Invoices:      &memoryInvoiceRepository{invoices},
+		Users:         &memoryUserRepository{newMemoryCollection(func(user models.User) string { return user.UserID })},
+		TaxRates:      &memoryTaxRateRepository{newMemoryCollection(func(taxRate models.TaxRate) string { return taxRate.TaxRateID })},
+		Ledger:        &memoryLedgerRepository{ledger},
+		Promotions:    &memoryPromotionRepository{newMemoryCollection(func(promotion models.Promotion) string { return promotion.PromotionID })},
+		Combos:        &memoryComboRepository{newMemoryCollection(func(combo models.Combo) string { return combo.ComboID })},
+		ExchangeRates: &memoryExchangeRateRepository{newMemoryCollection(func(exchangeRate models.ExchangeRate) string { return exchangeRate.ExchangeRateID })},
+		BusinessDays:  &memoryBusinessDayRepository{newMemoryCollection(func(day models.BusinessDay) string { return day.BusinessDayID })},
+		Reports:       &memoryReportRepository{invoices, orders, orderItems, foods, menus, tables, ledger},
+	}
+}
@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type TableRepository interface {
+	FindAll(ctx context.Context) ([]models.Table, error)
+	FindByID(ctx context.Context, tableId string) (models.Table, error)
+	Insert(ctx context.Context, table models.Table) error
+	Update(ctx context.Context, table models.Table) error
+}
+
+type mongoTableRepository struct {
+	tables mongoCollection[models.Table]
+}
+
+func (r *mongoTableRepository) FindAll(ctx context.Context) ([]models.Table, error) {
+	return r.tables.find(ctx, bson.M{})
+}
+
+func (r *mongoTableRepository) FindByID(ctx context.Context, tableId string) (models.Table, error) {
+	return r.tables.findByID(ctx, tableId)
+}
+
+func (r *mongoTableRepository) Insert(ctx context.Context, table models.Table) error {
+	return r.tables.insert(ctx, table)
+}
+
+func (r *mongoTableRepository) Update(ctx context.Context, table models.Table) error {
+	return r.tables.replace(ctx, table.TableID, table)
+}
+
+type memoryTableRepository struct {
+	tables *memoryCollection[models.Table]
+}
+
+func (r *memoryTableRepository) FindAll(ctx context.Context) ([]models.Table, error) {
+	return r.tables.find(nil)
+}
+
+func (r *memoryTableRepository) FindByID(ctx context.Context, tableId string) (models.Table, error) {
+	return r.tables.findByID(tableId)
+}
+
+func (r *memoryTableRepository) Insert(ctx context.Context, table models.Table) error {
+	return r.tables.insert(table)
+}
+
+func (r *memoryTableRepository) Update(ctx context.Context, table models.Table) error {
+	return r.tables.replace(table.TableID, table)
+}
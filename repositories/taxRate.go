@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type TaxRateRepository interface {
+	FindAll(ctx context.Context) ([]models.TaxRate, error)
+	FindByID(ctx context.Context, taxRateId string) (models.TaxRate, error)
+	Insert(ctx context.Context, taxRate models.TaxRate) error
+	Update(ctx context.Context, taxRate models.TaxRate) error
+}
+
+type mongoTaxRateRepository struct {
+	taxRates mongoCollection[models.TaxRate]
+}
+
+func (r *mongoTaxRateRepository) FindAll(ctx context.Context) ([]models.TaxRate, error) {
+	return r.taxRates.find(ctx, bson.M{})
+}
+
+func (r *mongoTaxRateRepository) FindByID(ctx context.Context, taxRateId string) (models.TaxRate, error) {
+	return r.taxRates.findByID(ctx, taxRateId)
+}
+
+func (r *mongoTaxRateRepository) Insert(ctx context.Context, taxRate models.TaxRate) error {
+	return r.taxRates.insert(ctx, taxRate)
+}
+
+func (r *mongoTaxRateRepository) Update(ctx context.Context, taxRate models.TaxRate) error {
+	return r.taxRates.replace(ctx, taxRate.TaxRateID, taxRate)
+}
+
+type memoryTaxRateRepository struct {
+	taxRates *memoryCollection[models.TaxRate]
+}
+
+func (r *memoryTaxRateRepository) FindAll(ctx context.Context) ([]models.TaxRate, error) {
+	return r.taxRates.find(nil)
+}
+
+func (r *memoryTaxRateRepository) FindByID(ctx context.Context, taxRateId string) (models.TaxRate, error) {
+	return r.taxRates.findByID(taxRateId)
+}
+
+func (r *memoryTaxRateRepository) Insert(ctx context.Context, taxRate models.TaxRate) error {
+	return r.taxRates.insert(taxRate)
+}
+
+func (r *memoryTaxRateRepository) Update(ctx context.Context, taxRate models.TaxRate) error {
+	return r.taxRates.replace(taxRate.TaxRateID, taxRate)
+}
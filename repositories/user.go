@@ -0,0 +1,248 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type UserRepository interface {
+	FindAll(ctx context.Context) ([]models.User, error)
+	FindByID(ctx context.Context, userId string) (models.User, error)
+	FindByEmail(ctx context.Context, email string) (models.User, error)
+	CountByEmail(ctx context.Context, email string) (int64, error)
+	CountByPhone(ctx context.Context, phone string) (int64, error)
+	Count(ctx context.Context) (int64, error)
+	Insert(ctx context.Context, user models.User) error
+	// InsertFirst inserts the first user of the restaurant, or returns
+	// ErrConflict if another user was inserted first.
+	InsertFirst(ctx context.Context, user models.User) error
+	// UpdateRole sets the role of the user and leaves the rest of it,
+	// tokens included, alone.
+	UpdateRole(ctx context.Context, userId, role string, updatedAt time.Time) error
+	// AddSession stores a new session of the user, dropping the sessions that
+	// were last used before expiredBefore. Refresh tokens are only ever stored
+	// and compared as hashes.
+	AddSession(ctx context.Context, userId string, session models.UserSession, expiredBefore time.Time) error
+	// RotateTokens replaces the refresh token of the session of the family,
+	// but only while usedRefreshTokenHash is still the hash of its current
+	// refresh token; otherwise it returns ErrConflict.
+	RotateTokens(ctx context.Context, userId, family, usedRefreshTokenHash, refreshTokenHash string, updatedAt time.Time) error
+	// RevokeTokens ends the session of the family, leaving the other sessions
+	// of the user alone. It returns ErrNotFound if there is no such session.
+	RevokeTokens(ctx context.Context, userId, family string, updatedAt time.Time) error
+}
+
+type mongoUserRepository struct {
+	users mongoCollection[models.User]
+}
+
+func (r *mongoUserRepository) FindAll(ctx context.Context) ([]models.User, error) {
+	return r.users.find(ctx, bson.M{})
+}
+
+func (r *mongoUserRepository) FindByID(ctx context.Context, userId string) (models.User, error) {
+	return r.users.findByID(ctx, userId)
+}
+
+func (r *mongoUserRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	return r.users.findOne(ctx, bson.M{"email": email})
+}
+
+func (r *mongoUserRepository) CountByEmail(ctx context.Context, email string) (int64, error) {
+	return r.users.count(ctx, bson.M{"email": email})
+}
+
+func (r *mongoUserRepository) CountByPhone(ctx context.Context, phone string) (int64, error) {
+	return r.users.count(ctx, bson.M{"phone": phone})
+}
+
+func (r *mongoUserRepository) Count(ctx context.Context) (int64, error) {
+	return r.users.count(ctx, bson.M{})
+}
+
+func (r *mongoUserRepository) Insert(ctx context.Context, user models.User) error {
+	return r.users.insert(ctx, user)
+}
+
+// InsertFirst relies on the unique index on first_user, which only one user
+// can ever be inserted with.
+func (r *mongoUserRepository) InsertFirst(ctx context.Context, user models.User) error {
+	firstUser := true
+	user.FirstUser = &firstUser
+
+	err := r.users.insert(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrConflict
+	}
+
+	return err
+}
+
+func (r *mongoUserRepository) UpdateRole(ctx context.Context, userId, role string, updatedAt time.Time) error {
+	return r.users.updateOne(
+		ctx,
+		bson.M{"user_id": userId},
+		bson.M{"$set": bson.M{"role": role, "updated_at": updatedAt}},
+	)
+}
+
+func (r *mongoUserRepository) AddSession(ctx context.Context, userId string, session models.UserSession, expiredBefore time.Time) error {
+	return r.users.updateOne(
+		ctx,
+		bson.M{"user_id": userId},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"sessions": bson.M{"$concatArrays": bson.A{
+					bson.M{"$filter": bson.M{
+						"input": bson.M{"$ifNull": bson.A{"$sessions", bson.A{}}},
+						"cond":  bson.M{"$gte": bson.A{"$$this.updated_at", expiredBefore}},
+					}},
+					bson.A{session},
+				}},
+				"updated_at": session.CreatedAt,
+			}}},
+		},
+	)
+}
+
+func (r *mongoUserRepository) RotateTokens(ctx context.Context, userId, family, usedRefreshTokenHash, refreshTokenHash string, updatedAt time.Time) error {
+	err := r.users.updateOne(
+		ctx,
+		bson.M{"user_id": userId, "sessions": bson.M{"$elemMatch": bson.M{"family": family, "refresh_token": usedRefreshTokenHash}}},
+		bson.M{"$set": bson.M{
+			"sessions.$.refresh_token": refreshTokenHash,
+			"sessions.$.updated_at":    updatedAt,
+		}},
+	)
+
+	if errors.Is(err, ErrNotFound) {
+		return ErrConflict
+	}
+
+	return err
+}
+
+func (r *mongoUserRepository) RevokeTokens(ctx context.Context, userId, family string, updatedAt time.Time) error {
+	return r.users.updateOne(
+		ctx,
+		bson.M{"user_id": userId, "sessions.family": family},
+		bson.M{
+			"$pull": bson.M{"sessions": bson.M{"family": family}},
+			"$set":  bson.M{"updated_at": updatedAt},
+		},
+	)
+}
+
+type memoryUserRepository struct {
+	users *memoryCollection[models.User]
+}
+
+func (r *memoryUserRepository) FindAll(ctx context.Context) ([]models.User, error) {
+	return r.users.find(nil)
+}
+
+func (r *memoryUserRepository) FindByID(ctx context.Context, userId string) (models.User, error) {
+	return r.users.findByID(userId)
+}
+
+func (r *memoryUserRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	return r.users.findOne(func(user models.User) bool {
+		return user.Email != nil && *user.Email == email
+	})
+}
+
+func (r *memoryUserRepository) CountByEmail(ctx context.Context, email string) (int64, error) {
+	return r.users.count(func(user models.User) bool {
+		return user.Email != nil && *user.Email == email
+	}), nil
+}
+
+func (r *memoryUserRepository) CountByPhone(ctx context.Context, phone string) (int64, error) {
+	return r.users.count(func(user models.User) bool {
+		return user.Phone != nil && *user.Phone == phone
+	}), nil
+}
+
+func (r *memoryUserRepository) Count(ctx context.Context) (int64, error) {
+	return r.users.count(nil), nil
+}
+
+func (r *memoryUserRepository) Insert(ctx context.Context, user models.User) error {
+	return r.users.insert(user)
+}
+
+func (r *memoryUserRepository) InsertFirst(ctx context.Context, user models.User) error {
+	r.users.mu.Lock()
+	defer r.users.mu.Unlock()
+
+	if len(r.users.docs) > 0 {
+		return ErrConflict
+	}
+
+	firstUser := true
+	user.FirstUser = &firstUser
+
+	return r.users.insertLocked([]models.User{user})
+}
+
+func (r *memoryUserRepository) UpdateRole(ctx context.Context, userId, role string, updatedAt time.Time) error {
+	return r.users.update(userId, func(user *models.User) error {
+		user.Role = &role
+		user.UpdatedAt = updatedAt
+		return nil
+	})
+}
+
+func (r *memoryUserRepository) AddSession(ctx context.Context, userId string, session models.UserSession, expiredBefore time.Time) error {
+	return r.users.update(userId, func(user *models.User) error {
+		sessions := []models.UserSession{}
+		for _, stored := range user.Sessions {
+			if !stored.UpdatedAt.Before(expiredBefore) {
+				sessions = append(sessions, stored)
+			}
+		}
+
+		user.Sessions = append(sessions, session)
+		user.UpdatedAt = session.CreatedAt
+		return nil
+	})
+}
+
+func (r *memoryUserRepository) RotateTokens(ctx context.Context, userId, family, usedRefreshTokenHash, refreshTokenHash string, updatedAt time.Time) error {
+	err := r.users.update(userId, func(user *models.User) error {
+		for i, session := range user.Sessions {
+			if session.Family == family && session.RefreshToken == usedRefreshTokenHash {
+				user.Sessions[i].RefreshToken = refreshTokenHash
+				user.Sessions[i].UpdatedAt = updatedAt
+				return nil
+			}
+		}
+
+		return ErrConflict
+	})
+
+	if errors.Is(err, ErrNotFound) {
+		return ErrConflict
+	}
+
+	return err
+}
+
+func (r *memoryUserRepository) RevokeTokens(ctx context.Context, userId, family string, updatedAt time.Time) error {
+	return r.users.update(userId, func(user *models.User) error {
+		for i, session := range user.Sessions {
+			if session.Family == family {
+				user.Sessions = append(user.Sessions[:i], user.Sessions[i+1:]...)
+				user.UpdatedAt = updatedAt
+				return nil
+			}
+		}
+
+		return ErrNotFound
+	})
+}
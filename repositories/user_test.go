@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+)
+
+func TestMemoryUserInsertFirst(t *testing.T) {
+	ctx := context.Background()
+	users := NewMemoryStore().Users
+
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = users.InsertFirst(ctx, models.User{UserID: fmt.Sprintf("u%d", i)})
+		}(i)
+	}
+	wg.Wait()
+
+	inserted := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			inserted++
+		case !errors.Is(err, ErrConflict):
+			t.Fatalf("got %v, want ErrConflict", err)
+		}
+	}
+
+	if inserted != 1 {
+		t.Errorf("got %d first users, want 1", inserted)
+	}
+}
+
+func TestMemoryUserUpdateRoleKeepsTokens(t *testing.T) {
+	ctx := context.Background()
+	users := NewMemoryStore().Users
+	now := time.Now().UTC().Truncate(time.Second)
+
+	if err := users.Insert(ctx, models.User{UserID: "u1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A role read along with the old tokens is changed after the tokens were
+	// rotated, and must not bring the old tokens back.
+	if err := users.AddSession(ctx, "u1", models.UserSession{Family: "f1", RefreshToken: "hash", CreatedAt: now, UpdatedAt: now}, now); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := users.RotateTokens(ctx, "u1", "f1", "hash", "rotated hash", now); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := users.UpdateRole(ctx, "u1", models.RoleManager, now); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := users.FindByID(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.Role == nil || *stored.Role != models.RoleManager {
+		t.Errorf("got role %v, want %s", stored.Role, models.RoleManager)
+	}
+
+	if len(stored.Sessions) != 1 || stored.Sessions[0].RefreshToken != "rotated hash" {
+		t.Errorf("got sessions %+v, want the rotated one", stored.Sessions)
+	}
+
+	if err := users.UpdateRole(ctx, "u2", models.RoleManager, now); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryUserSessions(t *testing.T) {
+	ctx := context.Background()
+	users := NewMemoryStore().Users
+	now := time.Now().UTC().Truncate(time.Second)
+
+	if err := users.Insert(ctx, models.User{UserID: "u1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions := []models.UserSession{
+		{Family: "expired", RefreshToken: "e1", CreatedAt: now.Add(-10 * time.Hour), UpdatedAt: now.Add(-9 * time.Hour)},
+		{Family: "tablet", RefreshToken: "t1", CreatedAt: now.Add(-time.Hour), UpdatedAt: now.Add(-time.Hour)},
+		{Family: "phone", RefreshToken: "p1", CreatedAt: now, UpdatedAt: now},
+	}
+	for _, session := range sessions {
+		if err := users.AddSession(ctx, "u1", session, now.Add(-8*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		family  string
+		used    string
+		next    string
+		wantErr error
+	}{
+		{"current token", "tablet", "t1", "t2", nil},
+		{"reused token", "tablet", "t1", "t3", ErrConflict},
+		{"token of another session", "phone", "t2", "p2", ErrConflict},
+		{"expired session", "expired", "e1", "e2", ErrConflict},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := users.RotateTokens(ctx, "u1", test.family, test.used, test.next, now); !errors.Is(err, test.wantErr) {
+				t.Fatalf("got %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+
+	// Revoking the session whose token was reused leaves the other one alone.
+	if err := users.RevokeTokens(ctx, "u1", "tablet", now); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := users.RevokeTokens(ctx, "u1", "tablet", now); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v revoking a revoked session, want ErrNotFound", err)
+	}
+
+	stored, err := users.FindByID(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stored.Sessions) != 1 || stored.Sessions[0].Family != "phone" || stored.Sessions[0].RefreshToken != "p1" {
+		t.Errorf("got sessions %+v, want only the phone one", stored.Sessions)
+	}
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/EnesDemirtas/restaurant-management/controllers"
+	"github.com/EnesDemirtas/restaurant-management/middlewares"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/gin-gonic/gin"
+)
+
+func BusinessDay(incomingRoutes *gin.Engine) {
+	incomingRoutes.GET("/businessDays", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.GetBusinessDays())
+	incomingRoutes.GET("/businessDays/current", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleCashier), controllers.GetCurrentBusinessDay())
+	incomingRoutes.GET("/businessDays/:id", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleCashier), controllers.GetBusinessDay())
+	incomingRoutes.POST("/businessDays", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleCashier), controllers.OpenBusinessDay())
+	incomingRoutes.POST("/businessDays/:id/cashDrops", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleCashier), controllers.AddCashDrop())
+	incomingRoutes.GET("/businessDays/:id/report", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleCashier), controllers.GetBusinessDayReport())
+	incomingRoutes.POST("/businessDays/:id/close", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleCashier), controllers.CloseBusinessDay())
+}
\ No newline at end of file
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/EnesDemirtas/restaurant-management/controllers"
+	"github.com/EnesDemirtas/restaurant-management/middlewares"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/gin-gonic/gin"
+)
+
+func Combo(incomingRoutes *gin.Engine) {
+	incomingRoutes.GET("/combos", middlewares.Authorization(models.StaffRoles...), controllers.GetCombos())
+	incomingRoutes.GET("/combos/:id", middlewares.Authorization(models.StaffRoles...), controllers.GetCombo())
+	incomingRoutes.POST("/combos", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.CreateCombo())
+	incomingRoutes.PATCH("/combos/:id", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.UpdateCombo())
+}
\ No newline at end of file
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/EnesDemirtas/restaurant-management/controllers"
+	"github.com/EnesDemirtas/restaurant-management/middlewares"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/gin-gonic/gin"
+)
+
+func ExchangeRate(incomingRoutes *gin.Engine) {
+	incomingRoutes.GET("/exchangeRates", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.GetExchangeRates())
+	incomingRoutes.GET("/exchangeRates/:id", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.GetExchangeRate())
+	incomingRoutes.POST("/exchangeRates", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.CreateExchangeRate())
+	incomingRoutes.PATCH("/exchangeRates/:id", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.UpdateExchangeRate())
+}
\ No newline at end of file
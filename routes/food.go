@@ -2,13 +2,15 @@ package routes
 
 import (
 	"github.com/EnesDemirtas/restaurant-management/controllers"
-
+	"github.com/EnesDemirtas/restaurant-management/middlewares"
+	"github.com/EnesDemirtas/restaurant-management/models"
 	"github.com/gin-gonic/gin"
 )
 
 func Food(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/foods/", controllers.GetFoods())
-	incomingRoutes.GET("/foods/:id", controllers.GetFood())
-	incomingRoutes.POST("/foods", controllers.CreateFood())
-	incomingRoutes.PATCH("/food/:id", controllers.UpdateFood())
+	incomingRoutes.GET("/foods/", middlewares.Authorization(models.StaffRoles...), controllers.GetFoods())
+	incomingRoutes.GET("/foods/:id", middlewares.Authorization(models.StaffRoles...), controllers.GetFood())
+	incomingRoutes.POST("/foods", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.CreateFood())
+	incomingRoutes.PATCH("/food/:id", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.UpdateFood())
+	incomingRoutes.PATCH("/foods/:id/availability", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleKitchen), controllers.SetFoodAvailability())
 }
\ No newline at end of file
@@ -2,12 +2,20 @@ package routes
 
 import (
 	"github.com/EnesDemirtas/restaurant-management/controllers"
+	"github.com/EnesDemirtas/restaurant-management/middlewares"
+	"github.com/EnesDemirtas/restaurant-management/models"
 	"github.com/gin-gonic/gin"
 )
 
 func Invoice(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/invoices", controllers.GetInvoices())
-	incomingRoutes.GET("/invoices/:id", controllers.GetInvoice())
-	incomingRoutes.POST("/invoices", controllers.CreateInvoice())
-	incomingRoutes.PATCH("/invoices/:id", controllers.UpdateInvoice())
+	incomingRoutes.GET("/invoices", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleWaiter, models.RoleCashier), controllers.GetInvoices())
+	incomingRoutes.GET("/invoices/:id", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleWaiter, models.RoleCashier), controllers.GetInvoice())
+	incomingRoutes.GET("/invoices/:id/receipt", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleWaiter, models.RoleCashier), controllers.GetReceipt())
+	incomingRoutes.POST("/invoices", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleWaiter, models.RoleCashier), controllers.CreateInvoice())
+	incomingRoutes.PATCH("/invoices/:id", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleCashier), controllers.UpdateInvoice())
+	incomingRoutes.GET("/tips", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.GetTips())
+	incomingRoutes.GET("/invoices/:id/payments", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleWaiter, models.RoleCashier), controllers.GetPayments())
+	incomingRoutes.POST("/invoices/:id/payments", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleCashier), controllers.CreatePayment())
+	incomingRoutes.POST("/invoices/:id/refunds", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleCashier), controllers.RefundInvoice())
+	incomingRoutes.POST("/invoices/:id/void", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleCashier), controllers.VoidInvoice())
 }
\ No newline at end of file
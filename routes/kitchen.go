@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/EnesDemirtas/restaurant-management/controllers"
+	"github.com/EnesDemirtas/restaurant-management/middlewares"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/gin-gonic/gin"
+)
+
+func Kitchen(incomingRoutes *gin.Engine) {
+	incomingRoutes.GET("/kitchen/tickets", middlewares.Authorization(models.StaffRoles...), controllers.GetKitchenTickets())
+	incomingRoutes.GET("/kitchen/stream", middlewares.Authorization(models.StaffRoles...), controllers.StreamKitchen())
+	incomingRoutes.PATCH("/kitchen/items/:id", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleKitchen), controllers.UpdateKitchenStatus())
+}
\ No newline at end of file
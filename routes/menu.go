@@ -2,12 +2,15 @@ package routes
 
 import (
 	"github.com/EnesDemirtas/restaurant-management/controllers"
+	"github.com/EnesDemirtas/restaurant-management/middlewares"
+	"github.com/EnesDemirtas/restaurant-management/models"
 	"github.com/gin-gonic/gin"
 )
 
 func Menu(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/menus", controllers.GetMenus())
-	incomingRoutes.GET("/menus/:id", controllers.GetMenu())
-	incomingRoutes.POST("/menus", controllers.CreateMenu())
-	incomingRoutes.PATCH("/menus/:id", controllers.UpdateMenu())
+	incomingRoutes.GET("/menus", middlewares.Authorization(models.StaffRoles...), controllers.GetMenus())
+	incomingRoutes.GET("/menus/active", middlewares.Authorization(models.StaffRoles...), controllers.GetActiveMenus())
+	incomingRoutes.GET("/menus/:id", middlewares.Authorization(models.StaffRoles...), controllers.GetMenu())
+	incomingRoutes.POST("/menus", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.CreateMenu())
+	incomingRoutes.PATCH("/menus/:id", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.UpdateMenu())
 }
\ No newline at end of file
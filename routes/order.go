@@ -2,12 +2,15 @@ package routes
 
 import (
 	"github.com/EnesDemirtas/restaurant-management/controllers"
+	"github.com/EnesDemirtas/restaurant-management/middlewares"
+	"github.com/EnesDemirtas/restaurant-management/models"
 	"github.com/gin-gonic/gin"
 )
 
 func Order(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/orders", controllers.GetOrders())
-	incomingRoutes.GET("/orders/:id", controllers.GetOrder())
-	incomingRoutes.POST("/orders", controllers.CreateOrder())
-	incomingRoutes.PATCH("/orders/:id", controllers.UpdateOrder())
+	incomingRoutes.GET("/orders", middlewares.Authorization(models.StaffRoles...), controllers.GetOrders())
+	incomingRoutes.GET("/orders/:id", middlewares.Authorization(models.StaffRoles...), controllers.GetOrder())
+	incomingRoutes.POST("/orders", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleWaiter), controllers.CreateOrder())
+	incomingRoutes.PATCH("/orders/:id", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleWaiter), controllers.UpdateOrder())
+	incomingRoutes.PATCH("/orders/:id/status", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleWaiter, models.RoleKitchen), controllers.UpdateOrderStatus())
 }
\ No newline at end of file
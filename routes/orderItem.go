@@ -2,13 +2,16 @@ package routes
 
 import (
 	"github.com/EnesDemirtas/restaurant-management/controllers"
+	"github.com/EnesDemirtas/restaurant-management/middlewares"
+	"github.com/EnesDemirtas/restaurant-management/models"
 	"github.com/gin-gonic/gin"
 )
 
 func OrderItem(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/orderItems", controllers.GetOrderItems())
-	incomingRoutes.GET("/orderItems/:id", controllers.GetOrderItem())
-	incomingRoutes.GET("/orderItems-order/:id", controllers.GetOrderItemsByOrder())
-	incomingRoutes.POST("/orderItems", controllers.CreateOrderItem())
-	incomingRoutes.PATCH("/orderItems/:id", controllers.UpdateOrderItem())
+	incomingRoutes.GET("/orderItems", middlewares.Authorization(models.StaffRoles...), controllers.GetOrderItems())
+	incomingRoutes.GET("/orderItems/:id", middlewares.Authorization(models.StaffRoles...), controllers.GetOrderItem())
+	incomingRoutes.GET("/orderItems-order/:id", middlewares.Authorization(models.StaffRoles...), controllers.GetOrderItemsByOrder())
+	incomingRoutes.POST("/orderItems", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleWaiter), controllers.CreateOrderItem())
+	incomingRoutes.PATCH("/orderItems/:id", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleWaiter), controllers.UpdateOrderItem())
+	incomingRoutes.POST("/orderItems/:id/void", middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleWaiter), controllers.VoidOrderItem())
 }
\ No newline at end of file
@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"github.com/EnesDemirtas/restaurant-management/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+// PaymentWebhook is called by the payment provider, which signs its requests
+// instead of logging in, so it is registered before authentication.
+func PaymentWebhook(incomingRoutes *gin.Engine) {
+	incomingRoutes.POST("/payments/webhook", controllers.HandlePaymentWebhook())
+}
\ No newline at end of file
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/EnesDemirtas/restaurant-management/controllers"
+	"github.com/EnesDemirtas/restaurant-management/middlewares"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/gin-gonic/gin"
+)
+
+func Promotion(incomingRoutes *gin.Engine) {
+	incomingRoutes.GET("/promotions", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.GetPromotions())
+	incomingRoutes.GET("/promotions/:id", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.GetPromotion())
+	incomingRoutes.POST("/promotions", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.CreatePromotion())
+	incomingRoutes.PATCH("/promotions/:id", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.UpdatePromotion())
+}
\ No newline at end of file
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/EnesDemirtas/restaurant-management/controllers"
+	"github.com/EnesDemirtas/restaurant-management/middlewares"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/gin-gonic/gin"
+)
+
+func Report(incomingRoutes *gin.Engine) {
+	incomingRoutes.GET("/reports/revenue", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.GetRevenueReport())
+	incomingRoutes.GET("/reports/averageTicket", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.GetTicketReport())
+	incomingRoutes.GET("/reports/tables", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.GetTableReport())
+	incomingRoutes.GET("/reports/topFoods", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.GetTopFoodsReport())
+	incomingRoutes.GET("/reports/categories", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.GetCategoryReport())
+}
\ No newline at end of file
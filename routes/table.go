@@ -2,12 +2,14 @@ package routes
 
 import (
 	"github.com/EnesDemirtas/restaurant-management/controllers"
+	"github.com/EnesDemirtas/restaurant-management/middlewares"
+	"github.com/EnesDemirtas/restaurant-management/models"
 	"github.com/gin-gonic/gin"
 )
 
 func Table(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/tables", controllers.GetTables())
-	incomingRoutes.GET("/tables/:id", controllers.GetTable())
-	incomingRoutes.POST("/tables", controllers.CreateTable())
-	incomingRoutes.PATCH("/tables/:id", controllers.UpdateTable())
+	incomingRoutes.GET("/tables", middlewares.Authorization(models.StaffRoles...), controllers.GetTables())
+	incomingRoutes.GET("/tables/:id", middlewares.Authorization(models.StaffRoles...), controllers.GetTable())
+	incomingRoutes.POST("/tables", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.CreateTable())
+	incomingRoutes.PATCH("/tables/:id", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.UpdateTable())
 }
\ No newline at end of file
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/EnesDemirtas/restaurant-management/controllers"
+	"github.com/EnesDemirtas/restaurant-management/middlewares"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TaxRate(incomingRoutes *gin.Engine) {
+	incomingRoutes.GET("/taxRates", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.GetTaxRates())
+	incomingRoutes.GET("/taxRates/:id", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.GetTaxRate())
+	incomingRoutes.POST("/taxRates", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.CreateTaxRate())
+	incomingRoutes.PATCH("/taxRates/:id", middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.UpdateTaxRate())
+}
\ No newline at end of file
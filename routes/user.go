@@ -2,12 +2,17 @@ package routes
 
 import (
 	"github.com/EnesDemirtas/restaurant-management/controllers"
+	"github.com/EnesDemirtas/restaurant-management/middlewares"
+	"github.com/EnesDemirtas/restaurant-management/models"
 	"github.com/gin-gonic/gin"
 )
 
 func User(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/users", controllers.GetUsers())
-	incomingRoutes.GET("/users/:id", controllers.GetUser())
+	incomingRoutes.GET("/users", middlewares.Authentication(), middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.GetUsers())
+	incomingRoutes.GET("/users/:id", middlewares.Authentication(), middlewares.Authorization(models.RoleAdmin, models.RoleManager), controllers.GetUser())
 	incomingRoutes.POST("/users/signup", controllers.SignUp())
 	incomingRoutes.POST("/users/login", controllers.Login())
+	incomingRoutes.POST("/users/refresh", controllers.RefreshTokens())
+	incomingRoutes.POST("/users/logout", middlewares.Authentication(), controllers.Logout())
+	incomingRoutes.PATCH("/users/:id/role", middlewares.Authentication(), middlewares.Authorization(models.RoleAdmin), controllers.UpdateUserRole())
 }
\ No newline at end of file
@@ -0,0 +1,497 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/helpers"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/EnesDemirtas/restaurant-management/repositories"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CashDropRequest is cash taken out of the drawer.
+type CashDropRequest struct {
+	Amount *models.Money `json:"amount" validate:"required"`
+	Note   *string       `json:"note"`
+}
+
+// CloseRequest is the cash counted in the drawer at closing.
+type CloseRequest struct {
+	CountedCash *models.Money `json:"counted_cash" validate:"required"`
+}
+
+func GetBusinessDays(c *gin.Context) ([]models.BusinessDay, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	allDays, err := store.BusinessDays.FindAll(ctx)
+	if err != nil {
+		return nil, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while listing business days",
+		}
+	}
+
+	return allDays, nil
+}
+
+func GetBusinessDay(c *gin.Context) (models.BusinessDay, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	day, err := store.BusinessDays.FindByID(ctx, c.Param("id"))
+	if err != nil {
+		return models.BusinessDay{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "business day was not found",
+		}
+	}
+
+	return day, nil
+}
+
+func GetCurrentBusinessDay(c *gin.Context) (models.BusinessDay, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	day, err := store.BusinessDays.FindOpen(ctx)
+	if err != nil {
+		return models.BusinessDay{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "no business day is open",
+		}
+	}
+
+	return day, nil
+}
+
+func OpenBusinessDay(c *gin.Context) (models.BusinessDay, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	var day models.BusinessDay
+
+	if err := c.BindJSON(&day); err != nil {
+		return models.BusinessDay{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	validationErr := validate.Struct(day)
+	if validationErr != nil {
+		return models.BusinessDay{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: validationErr.Error(),
+		}
+	}
+
+	if day.OpeningFloat.Amount < 0 {
+		return models.BusinessDay{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "opening float cannot be negative",
+		}
+	}
+
+	day.Status = models.BusinessDayOpen
+	day.CashDrops = []models.CashDrop{}
+	day.CountedCash = nil
+	day.Report = nil
+	day.ClosedBy = nil
+	day.ClosedAt = nil
+	day.OpenedBy = c.GetString("uid")
+	day.OpenedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	day.ID = primitive.NewObjectID()
+	day.BusinessDayID = day.ID.Hex()
+
+	err := store.BusinessDays.Open(ctx, day)
+	if errors.Is(err, repositories.ErrConflict) {
+		return models.BusinessDay{}, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: "another business day is already open",
+		}
+	}
+
+	if err != nil {
+		return models.BusinessDay{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "business day was not opened",
+		}
+	}
+
+	return day, nil
+}
+
+func AddCashDrop(c *gin.Context) (models.BusinessDay, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	var request CashDropRequest
+
+	if err := c.BindJSON(&request); err != nil {
+		return models.BusinessDay{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	validationErr := validate.Struct(request)
+	if validationErr != nil {
+		return models.BusinessDay{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: validationErr.Error(),
+		}
+	}
+
+	if request.Amount.Amount <= 0 {
+		return models.BusinessDay{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "cash drop must be more than zero",
+		}
+	}
+
+	day, err := store.BusinessDays.FindByID(ctx, c.Param("id"))
+	if err != nil {
+		return models.BusinessDay{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "business day was not found",
+		}
+	}
+
+	drop := models.CashDrop{
+		CashDropID: primitive.NewObjectID().Hex(),
+		Amount:     *request.Amount,
+		Note:       request.Note,
+		RecordedBy: c.GetString("uid"),
+	}
+	drop.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	err = store.BusinessDays.AddCashDrop(ctx, day.BusinessDayID, drop)
+	if errors.Is(err, repositories.ErrConflict) {
+		return models.BusinessDay{}, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: "business day is closed",
+		}
+	}
+
+	if err != nil {
+		return models.BusinessDay{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "cash drop was not recorded",
+		}
+	}
+
+	day.CashDrops = append(day.CashDrops, drop)
+
+	return day, nil
+}
+
+// GetBusinessDayReport returns the report of a closed day as it was when it
+// closed, or the report of the open day so far.
+func GetBusinessDayReport(c *gin.Context) (models.ZReport, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	day, err := store.BusinessDays.FindByID(ctx, c.Param("id"))
+	if err != nil {
+		return models.ZReport{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "business day was not found",
+		}
+	}
+
+	if day.Report != nil {
+		return *day.Report, nil
+	}
+
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	return businessDayReport(ctx, day, now)
+}
+
+// CloseBusinessDay counts the drawer against the report of the day and locks
+// the day.
+func CloseBusinessDay(c *gin.Context) (models.BusinessDay, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	var request CloseRequest
+
+	if err := c.BindJSON(&request); err != nil {
+		return models.BusinessDay{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	validationErr := validate.Struct(request)
+	if validationErr != nil {
+		return models.BusinessDay{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: validationErr.Error(),
+		}
+	}
+
+	day, err := store.BusinessDays.FindByID(ctx, c.Param("id"))
+	if err != nil {
+		return models.BusinessDay{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "business day was not found",
+		}
+	}
+
+	if day.Status != models.BusinessDayOpen {
+		return models.BusinessDay{}, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: "business day is already closed",
+		}
+	}
+
+	closedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	closedBy := c.GetString("uid")
+
+	report, err := businessDayReport(ctx, day, closedAt)
+	if err != nil {
+		return models.BusinessDay{}, err
+	}
+
+	difference := request.CountedCash.Sub(report.ExpectedCash)
+	report.CountedCash = request.CountedCash
+	report.CashDifference = &difference
+
+	day.Status = models.BusinessDayClosed
+	day.CountedCash = request.CountedCash
+	day.Report = &report
+	day.ClosedBy = &closedBy
+	day.ClosedAt = &closedAt
+
+	err = store.BusinessDays.Close(ctx, day)
+	if errors.Is(err, repositories.ErrConflict) {
+		return models.BusinessDay{}, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: "business day is already closed",
+		}
+	}
+
+	if err != nil {
+		return models.BusinessDay{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "business day was not closed",
+		}
+	}
+
+	return day, nil
+}
+
+// businessDayReport totals the invoices created, and the payments and refunds
+// made, from the opening of the day to the time.
+func businessDayReport(ctx context.Context, day models.BusinessDay, to time.Time) (models.ZReport, error) {
+	allInvoices, err := store.Invoices.FindAll(ctx)
+	if err != nil {
+		return models.ZReport{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while listing all invoices",
+		}
+	}
+
+	allEntries, err := store.Ledger.FindAll(ctx)
+	if err != nil {
+		return models.ZReport{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while listing ledger entries",
+		}
+	}
+
+	during := func(at time.Time) bool {
+		return !at.Before(day.OpenedAt) && !at.After(to)
+	}
+
+	report := models.ZReport{
+		From:          day.OpenedAt,
+		To:            to,
+		DiscountTotal: sumMoney(),
+		Subtotal:      sumMoney(),
+		ServiceCharge: sumMoney(),
+		TaxLines:      []models.InvoiceTaxLine{},
+		TaxTotal:      sumMoney(),
+		GrandTotal:    sumMoney(),
+		Payments:      []models.MethodTotal{},
+		Refunds:       []models.MethodTotal{},
+		RefundTotal:   sumMoney(),
+		TipTotal:      sumMoney(),
+		OpeningFloat:  *day.OpeningFloat,
+		CashDrops:     sumMoney(),
+	}
+
+	invoicesById := map[string]models.Invoice{}
+	for _, invoice := range allInvoices {
+		invoicesById[invoice.InvoiceID] = invoice
+	}
+
+	voided := map[string]bool{}
+	cashRefunded := sumMoney()
+	foreignCash := map[string]models.Money{}
+
+	for _, entry := range allEntries {
+		if entry.Type == models.LedgerEntryVoid && entry.InvoiceID != nil {
+			voided[*entry.InvoiceID] = true
+		}
+
+		if entry.Type != models.LedgerEntryRefund || !during(entry.CreatedAt) {
+			continue
+		}
+
+		method := "UNKNOWN"
+		if entry.Method != nil {
+			method = *entry.Method
+		}
+
+		report.Refunds = addMethodTotal(report.Refunds, method, entry.Amount, sumMoney())
+		report.RefundTotal = report.RefundTotal.Add(entry.Amount)
+		if method != models.PaymentMethodCash {
+			continue
+		}
+
+		// Cash is given back in the currency the invoice was paid in.
+		var invoice models.Invoice
+		if entry.InvoiceID != nil {
+			invoice = invoicesById[*entry.InvoiceID]
+		}
+		if currency := paymentCurrency(invoice); currency != models.DefaultCurrency && invoice.ExchangeRate != nil {
+			foreignCash[currency] = foreignCash[currency].Sub(entry.Amount.Convert(*invoice.ExchangeRate, currency))
+		} else {
+			cashRefunded = cashRefunded.Add(entry.Amount)
+		}
+	}
+
+	cashReceived := sumMoney()
+
+	for _, invoice := range allInvoices {
+		for _, payment := range invoice.Payments {
+			if !during(payment.CreatedAt) {
+				continue
+			}
+
+			report.Payments = addMethodTotal(report.Payments, payment.Method, payment.Amount, payment.Tip)
+			report.TipTotal = report.TipTotal.Add(payment.Tip)
+			if payment.Method != models.PaymentMethodCash {
+				continue
+			}
+
+			if payment.Currency != "" && payment.Currency != models.DefaultCurrency {
+				foreignCash[payment.Currency] = foreignCash[payment.Currency].Add(payment.ConvertedAmount).Add(payment.Tip.Convert(payment.ExchangeRate, payment.Currency))
+			} else {
+				cashReceived = cashReceived.Add(payment.Amount).Add(payment.Tip)
+			}
+		}
+
+		if !during(invoice.CreatedAt) {
+			continue
+		}
+
+		report.Invoices++
+		if voided[invoice.InvoiceID] {
+			report.VoidedInvoices++
+			continue
+		}
+
+		for _, total := range []struct {
+			sum    *models.Money
+			amount *models.Money
+		}{
+			{&report.DiscountTotal, invoice.DiscountTotal},
+			{&report.Subtotal, invoice.Subtotal},
+			{&report.ServiceCharge, invoice.ServiceCharge},
+			{&report.TaxTotal, invoice.TaxTotal},
+			{&report.GrandTotal, invoice.GrandTotal},
+		} {
+			if total.amount != nil {
+				*total.sum = total.sum.Add(*total.amount)
+			}
+		}
+
+		report.TaxLines = addTaxLines(report.TaxLines, invoice.TaxLines)
+	}
+
+	report.Tips = tipsByWaiter(allInvoices, func(payment models.InvoicePayment) bool {
+		return during(payment.CreatedAt)
+	})
+
+	for _, drop := range day.CashDrops {
+		report.CashDrops = report.CashDrops.Add(drop.Amount)
+	}
+
+	report.ExpectedCash = sumMoney(report.OpeningFloat, cashReceived).Sub(cashRefunded).Sub(report.CashDrops)
+
+	report.ExpectedForeignCash = []models.Money{}
+	for _, cash := range foreignCash {
+		report.ExpectedForeignCash = append(report.ExpectedForeignCash, cash)
+	}
+	sort.Slice(report.ExpectedForeignCash, func(i, j int) bool {
+		return report.ExpectedForeignCash[i].Currency < report.ExpectedForeignCash[j].Currency
+	})
+
+	return report, nil
+}
+
+func addMethodTotal(totals []models.MethodTotal, method string, amount, tip models.Money) []models.MethodTotal {
+	for i := range totals {
+		if totals[i].Method == method {
+			totals[i].Count++
+			totals[i].Amount = totals[i].Amount.Add(amount)
+			totals[i].Tips = totals[i].Tips.Add(tip)
+			return totals
+		}
+	}
+
+	return append(totals, models.MethodTotal{Method: method, Count: 1, Amount: sumMoney(amount), Tips: sumMoney(tip)})
+}
+
+// addTaxLines adds up the tax lines of an invoice with those of the same rate.
+func addTaxLines(totals []models.InvoiceTaxLine, taxLines []models.InvoiceTaxLine) []models.InvoiceTaxLine {
+	for _, taxLine := range taxLines {
+		found := false
+		for i := range totals {
+			if totals[i].TaxRateID == taxLine.TaxRateID && totals[i].Rate == taxLine.Rate {
+				totals[i].TaxableAmount = totals[i].TaxableAmount.Add(taxLine.TaxableAmount)
+				totals[i].Amount = totals[i].Amount.Add(taxLine.Amount)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			totals = append(totals, taxLine)
+		}
+	}
+
+	return totals
+}
+
+// checkDayOpen returns an error if the time falls in a business day that was
+// closed, whose invoices can no longer be changed.
+func checkDayOpen(ctx context.Context, at time.Time) error {
+	allDays, err := store.BusinessDays.FindAll(ctx)
+	if err != nil {
+		return helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while listing business days",
+		}
+	}
+
+	for _, day := range allDays {
+		if day.ClosedAt != nil && !at.Before(day.OpenedAt) && !at.After(*day.ClosedAt) {
+			return helpers.HttpError{
+				Code:    http.StatusConflict,
+				Message: fmt.Sprintf("the business day opened on %s is closed", day.OpenedAt.Format(time.DateOnly)),
+			}
+		}
+	}
+
+	return nil
+}
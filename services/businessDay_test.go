@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/EnesDemirtas/restaurant-management/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+func TestBusinessDayReportCash(t *testing.T) {
+	ctx := context.Background()
+	UseStore(repositories.NewMemoryStore())
+
+	openedAt := time.Now().Add(-time.Hour)
+	during := openedAt.Add(time.Minute)
+	before := openedAt.Add(-time.Minute)
+
+	eur := "EUR"
+	rate := 0.9
+	invoices := []models.Invoice{
+		{InvoiceID: "usd", CreatedAt: during, Payments: []models.InvoicePayment{
+			{Method: models.PaymentMethodCash, Amount: money(20), Tip: money(2), CreatedAt: during},
+			{Method: models.PaymentMethodCard, Amount: money(30), Tip: money(3), CreatedAt: during},
+			{Method: models.PaymentMethodCash, Amount: money(50), Tip: money(0), CreatedAt: before},
+		}},
+		{InvoiceID: "eur", CreatedAt: during, PaymentCurrency: &eur, ExchangeRate: &rate, Payments: []models.InvoicePayment{
+			{Method: models.PaymentMethodCash, Amount: money(10), Tip: money(1), Currency: eur, ExchangeRate: rate, ConvertedAmount: models.Money{Amount: 900, Currency: eur}, CreatedAt: during},
+		}},
+	}
+	for _, invoice := range invoices {
+		if err := store.Invoices.Insert(ctx, invoice); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cash := models.PaymentMethodCash
+	for _, invoiceId := range []string{"usd", "eur"} {
+		refund := models.LedgerEntry{LedgerEntryID: "r-" + invoiceId, Type: models.LedgerEntryRefund, InvoiceID: &invoiceId, Method: &cash, Amount: money(5), CreatedAt: during}
+		if err := store.Ledger.Insert(ctx, refund); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	openingFloat := money(100)
+	day := models.BusinessDay{
+		OpeningFloat: &openingFloat,
+		OpenedAt:     openedAt,
+		CashDrops:    []models.CashDrop{{Amount: money(40), CreatedAt: during}},
+	}
+
+	report, err := businessDayReport(ctx, day, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The float, plus 20 in cash and its 2 tip, less a 5 refund and a 40 drop.
+	if want := money(77); report.ExpectedCash != want {
+		t.Errorf("got expected cash %s, want %s", report.ExpectedCash, want)
+	}
+
+	// 9.00 EUR for the 10 paid and 0.90 EUR for its tip, less 4.50 EUR given
+	// back for the 5 refunded.
+	want := models.Money{Amount: 540, Currency: eur}
+	if len(report.ExpectedForeignCash) != 1 || report.ExpectedForeignCash[0] != want {
+		t.Errorf("got expected foreign cash %+v, want %+v", report.ExpectedForeignCash, want)
+	}
+}
+
+func TestClosedDayInvoices(t *testing.T) {
+	ctx := context.Background()
+	UseStore(repositories.NewMemoryStore())
+
+	openedAt := time.Now().Add(-2 * time.Hour)
+	closedAt := openedAt.Add(time.Hour)
+	openingFloat := money(100)
+	day := models.BusinessDay{BusinessDayID: "d1", Status: models.BusinessDayOpen, OpeningFloat: &openingFloat, OpenedAt: openedAt}
+	if err := store.BusinessDays.Open(ctx, day); err != nil {
+		t.Fatal(err)
+	}
+
+	day.Status = models.BusinessDayClosed
+	day.ClosedAt = &closedAt
+	if err := store.BusinessDays.Close(ctx, day); err != nil {
+		t.Fatal(err)
+	}
+
+	grandTotal := money(30)
+	pending := models.PaymentStatusPending
+	paid := models.PaymentStatusPaid
+	method := models.PaymentMethodCash
+	invoices := []models.Invoice{
+		{InvoiceID: "unpaid", OrderID: "o1", CreatedAt: openedAt.Add(time.Minute), PaymentStatus: &pending, GrandTotal: &grandTotal, Payments: []models.InvoicePayment{}},
+		{InvoiceID: "paid", OrderID: "o1", CreatedAt: openedAt.Add(time.Minute), PaymentStatus: &paid, PaymentMethod: &method, GrandTotal: &grandTotal, AmountPaid: &grandTotal,
+			Payments: []models.InvoicePayment{{PaymentID: "p1", Method: method, Amount: grandTotal}}},
+	}
+	for _, invoice := range invoices {
+		if err := store.Invoices.Insert(ctx, invoice); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := CreatePayment(newTestContext(models.RoleCashier, gin.Params{{Key: "id", Value: "unpaid"}}, map[string]any{"method": "CASH", "amount": 30}))
+	if status := httpStatus(err); status != http.StatusConflict {
+		t.Errorf("got status %d (%v) paying an invoice of a closed day, want %d", status, err, http.StatusConflict)
+	}
+
+	_, err = RefundInvoice(newTestContext(models.RoleManager, gin.Params{{Key: "id", Value: "paid"}}, map[string]any{"reason_code": "OTHER"}))
+	if status := httpStatus(err); status != http.StatusConflict {
+		t.Errorf("got status %d (%v) refunding an invoice of a closed day, want %d", status, err, http.StatusConflict)
+	}
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/gateway"
+	"github.com/EnesDemirtas/restaurant-management/helpers"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/EnesDemirtas/restaurant-management/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+var paymentProvider gateway.Provider
+
+// UsePaymentProvider sets the provider card payments are charged through.
+// Without one, card payments are only recorded, as if charged on a standalone
+// terminal.
+func UsePaymentProvider(provider gateway.Provider) {
+	paymentProvider = provider
+}
+
+// HandlePaymentWebhook records the status a provider reports for one of the
+// card payments it charged, e.g. when a capture settles or is charged back.
+func HandlePaymentWebhook(c *gin.Context) (models.InvoicePayment, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	if paymentProvider == nil {
+		return models.InvoicePayment{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "no payment provider is configured",
+		}
+	}
+
+	payload, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return models.InvoicePayment{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	event, err := paymentProvider.VerifyWebhook(payload, c.GetHeader("X-Signature"))
+	if err != nil {
+		return models.InvoicePayment{}, helpers.HttpError{
+			Code:    http.StatusUnauthorized,
+			Message: err.Error(),
+		}
+	}
+
+	invoice, err := store.Invoices.FindByTransaction(ctx, event.TransactionID)
+	if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		return models.InvoicePayment{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while looking up the payment",
+		}
+	}
+
+	for i, payment := range invoice.Payments {
+		if payment.TransactionID == nil || *payment.TransactionID != event.TransactionID {
+			continue
+		}
+
+		if event.Status != "" {
+			updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+			if err := store.Invoices.UpdatePaymentStatus(ctx, invoice.InvoiceID, event.TransactionID, event.Status, updatedAt); err != nil {
+				return models.InvoicePayment{}, helpers.HttpError{
+					Code:    http.StatusInternalServerError,
+					Message: "payment update failed",
+				}
+			}
+			invoice.Payments[i].ProviderStatus = &event.Status
+		}
+
+		return invoice.Payments[i], nil
+	}
+
+	return models.InvoicePayment{}, helpers.HttpError{
+		Code:    http.StatusNotFound,
+		Message: "payment was not found",
+	}
+}
+
+// chargeCard authorizes and captures a card payment, tip included, through the
+// payment provider. An authorization that can't be captured is voided.
+func chargeCard(ctx context.Context, payment *models.InvoicePayment, invoiceId string, cardToken *string) error {
+	if paymentProvider == nil || payment.Method != models.PaymentMethodCard {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, gateway.Timeout())
+	defer cancel()
+
+	request := gateway.AuthorizeRequest{
+		Amount:    payment.Amount.Add(payment.Tip),
+		Reference: invoiceId,
+	}
+	if cardToken != nil {
+		request.Token = *cardToken
+	}
+
+	authorization, err := paymentProvider.Authorize(ctx, request)
+	if err != nil {
+		return providerError(err)
+	}
+
+	capture, err := paymentProvider.Capture(ctx, authorization.ID, request.Amount)
+	if err != nil {
+		// The hold is released rather than left on the card until it expires.
+		// The capture may have used up the context, so the void gets its own.
+		voidCtx, cancel := context.WithTimeout(context.Background(), gateway.Timeout())
+		defer cancel()
+
+		paymentProvider.Void(voidCtx, authorization.ID)
+		return providerError(err)
+	}
+
+	provider := paymentProvider.Name()
+	payment.Provider = &provider
+	payment.TransactionID = &capture.ID
+	payment.ProviderStatus = &capture.Status
+
+	return nil
+}
+
+// cancelCharge gives back a card payment that was charged but could not be
+// recorded.
+func cancelCharge(ctx context.Context, payment models.InvoicePayment) {
+	if payment.TransactionID == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, gateway.Timeout())
+	defer cancel()
+
+	paymentProvider.Refund(ctx, *payment.TransactionID, payment.Amount.Add(payment.Tip))
+}
+
+// refundCard refunds a card refund entry on the card payment of the invoice
+// that has enough left to cover it. Invoices paid on a standalone terminal
+// are refunded there and only recorded.
+func refundCard(ctx context.Context, invoice models.Invoice, entries []models.LedgerEntry, entry *models.LedgerEntry) error {
+	if paymentProvider == nil || entry.Method == nil || *entry.Method != models.PaymentMethodCard {
+		return nil
+	}
+
+	charged := false
+	for _, payment := range invoice.Payments {
+		if payment.TransactionID == nil || payment.Method != models.PaymentMethodCard {
+			continue
+		}
+		charged = true
+
+		refundable := payment.Amount
+		for _, refunded := range entries {
+			if refunded.PaymentID != nil && *refunded.PaymentID == payment.PaymentID {
+				refundable = refundable.Sub(refunded.Amount)
+			}
+		}
+
+		if refundable.Amount < entry.Amount.Amount {
+			continue
+		}
+
+		refundCtx, cancel := context.WithTimeout(ctx, gateway.Timeout())
+		defer cancel()
+
+		refund, err := paymentProvider.Refund(refundCtx, *payment.TransactionID, entry.Amount)
+		if err != nil {
+			return providerError(err)
+		}
+
+		entry.PaymentID = &payment.PaymentID
+		entry.TransactionID = &refund.ID
+
+		return nil
+	}
+
+	if !charged {
+		return nil
+	}
+
+	return helpers.HttpError{
+		Code:    http.StatusConflict,
+		Message: fmt.Sprintf("no card payment of the invoice has %s left to refund, refund it in smaller parts", entry.Amount),
+	}
+}
+
+func providerError(err error) error {
+	switch {
+	case errors.Is(err, gateway.ErrDeclined):
+		return helpers.HttpError{
+			Code:    http.StatusPaymentRequired,
+			Message: err.Error(),
+		}
+	case errors.Is(err, gateway.ErrTimeout):
+		return helpers.HttpError{
+			Code:    http.StatusGatewayTimeout,
+			Message: err.Error(),
+		}
+	default:
+		return helpers.HttpError{
+			Code:    http.StatusBadGateway,
+			Message: err.Error(),
+		}
+	}
+}
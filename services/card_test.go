@@ -0,0 +1,123 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EnesDemirtas/restaurant-management/gateway"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/EnesDemirtas/restaurant-management/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+func useTestProvider(t *testing.T) *gateway.Mock {
+	mock, err := gateway.NewMock(gateway.OutcomeApprove, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	UsePaymentProvider(mock)
+	t.Cleanup(func() { UsePaymentProvider(nil) })
+
+	return mock
+}
+
+func TestChargeCard(t *testing.T) {
+	t.Setenv("PAYMENT_TIMEOUT", "1")
+	useTestProvider(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		token      string
+		wantStatus int
+		charged    bool
+	}{
+		{"approved", models.PaymentMethodCard, "tok_approve", http.StatusOK, true},
+		{"declined", models.PaymentMethodCard, "tok_decline", http.StatusPaymentRequired, false},
+		{"timed out", models.PaymentMethodCard, "tok_timeout", http.StatusGatewayTimeout, false},
+		{"cash", models.PaymentMethodCash, "tok_decline", http.StatusOK, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payment := models.InvoicePayment{Method: test.method, Amount: money(20), Tip: money(2)}
+
+			err := chargeCard(context.Background(), &payment, "i1", &test.token)
+			if status := httpStatus(err); status != test.wantStatus {
+				t.Fatalf("got status %d (%v), want %d", status, err, test.wantStatus)
+			}
+
+			if charged := payment.TransactionID != nil; charged != test.charged {
+				t.Fatalf("got transaction %v, want charged %v", payment.TransactionID, test.charged)
+			}
+
+			if test.charged && (*payment.Provider != "mock" || *payment.ProviderStatus != gateway.StatusCaptured) {
+				t.Errorf("got %s payment %s, want it captured by mock", *payment.Provider, *payment.ProviderStatus)
+			}
+		})
+	}
+}
+
+func TestHandlePaymentWebhook(t *testing.T) {
+	mock := useTestProvider(t)
+	UseStore(repositories.NewMemoryStore())
+
+	transactionId := "mock_1"
+	captured := gateway.StatusCaptured
+	invoice := models.Invoice{
+		InvoiceID: "i1",
+		Payments: []models.InvoicePayment{
+			{PaymentID: "p1", Method: models.PaymentMethodCash, Amount: money(5)},
+			{PaymentID: "p2", Method: models.PaymentMethodCard, Amount: money(15), TransactionID: &transactionId, ProviderStatus: &captured},
+		},
+	}
+	if err := store.Invoices.Insert(context.Background(), invoice); err != nil {
+		t.Fatal(err)
+	}
+
+	chargeback := []byte(`{"type":"chargeback","transaction_id":"mock_1","status":"CHARGED_BACK"}`)
+	unknown := []byte(`{"type":"chargeback","transaction_id":"mock_2","status":"CHARGED_BACK"}`)
+
+	tests := []struct {
+		name       string
+		payload    []byte
+		signature  string
+		wantStatus int
+		want       string
+	}{
+		{"forged", chargeback, "bad", http.StatusUnauthorized, gateway.StatusCaptured},
+		{"unknown transaction", unknown, mock.Sign(unknown), http.StatusNotFound, gateway.StatusCaptured},
+		{"chargeback", chargeback, mock.Sign(chargeback), http.StatusOK, "CHARGED_BACK"},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/payments/webhook", bytes.NewReader(test.payload))
+			c.Request.Header.Set("X-Signature", test.signature)
+
+			payment, err := HandlePaymentWebhook(c)
+			if status := httpStatus(err); status != test.wantStatus {
+				t.Fatalf("got status %d (%v), want %d", status, err, test.wantStatus)
+			}
+
+			if err == nil && payment.PaymentID != "p2" {
+				t.Errorf("got payment %s, want p2", payment.PaymentID)
+			}
+
+			stored, err := store.Invoices.FindByID(context.Background(), "i1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if status := *stored.Payments[1].ProviderStatus; status != test.want {
+				t.Errorf("got provider status %s, want %s", status, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/helpers"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func GetCombos(c *gin.Context) ([]models.Combo, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	allCombos, err := store.Combos.FindAll(ctx)
+	if err != nil {
+		return nil, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while listing combos",
+		}
+	}
+
+	return allCombos, nil
+}
+
+func GetCombo(c *gin.Context) (models.Combo, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	combo, err := store.Combos.FindByID(ctx, c.Param("id"))
+	if err != nil {
+		return models.Combo{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "combo was not found",
+		}
+	}
+
+	return combo, nil
+}
+
+func CreateCombo(c *gin.Context) (models.Combo, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	var combo models.Combo
+
+	if err := c.BindJSON(&combo); err != nil {
+		return models.Combo{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	validationErr := validate.Struct(combo)
+	if validationErr != nil {
+		return models.Combo{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: validationErr.Error(),
+		}
+	}
+
+	if err := prepareComboSlots(ctx, combo.Slots); err != nil {
+		return models.Combo{}, err
+	}
+
+	combo.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	combo.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	combo.ID = primitive.NewObjectID()
+	combo.ComboID = combo.ID.Hex()
+
+	err := store.Combos.Insert(ctx, combo)
+	if err != nil {
+		return models.Combo{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "combo was not created",
+		}
+	}
+
+	return combo, nil
+}
+
+func UpdateCombo(c *gin.Context) (models.Combo, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	var combo models.Combo
+
+	if err := c.BindJSON(&combo); err != nil {
+		return models.Combo{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	foundCombo, err := store.Combos.FindByID(ctx, c.Param("id"))
+	if err != nil {
+		return models.Combo{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "combo was not found",
+		}
+	}
+
+	if combo.Name != nil {
+		foundCombo.Name = combo.Name
+	}
+
+	if combo.Price != nil {
+		foundCombo.Price = combo.Price
+	}
+
+	// Slots are replaced as a whole. Combos already ordered keep the
+	// components they were ordered with.
+	if combo.Slots != nil {
+		foundCombo.Slots = combo.Slots
+	}
+
+	validationErr := validate.Struct(foundCombo)
+	if validationErr != nil {
+		return models.Combo{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: validationErr.Error(),
+		}
+	}
+
+	if err := prepareComboSlots(ctx, foundCombo.Slots); err != nil {
+		return models.Combo{}, err
+	}
+
+	foundCombo.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	err = store.Combos.Update(ctx, foundCombo)
+	if err != nil {
+		return models.Combo{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "combo update failed",
+		}
+	}
+
+	return foundCombo, nil
+}
+
+// prepareComboSlots gives the slots that are new an ID, and checks that every
+// food they offer exists, as one of its variants if it has any.
+func prepareComboSlots(ctx context.Context, slots []models.ComboSlot) error {
+	for i := range slots {
+		slot := &slots[i]
+		if slot.SlotID == "" {
+			slot.SlotID = primitive.NewObjectID().Hex()
+		}
+
+		for _, choice := range slot.Choices {
+			food, err := store.Foods.FindByID(ctx, *choice.FoodID)
+			if err != nil {
+				return helpers.HttpError{
+					Code:    http.StatusBadRequest,
+					Message: fmt.Sprintf("food %s of %s was not found", *choice.FoodID, *slot.Name),
+				}
+			}
+
+			if _, err := foodPrice(food, choice.VariantID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// comboComponents checks the foods chosen for a combo, one for each of its
+// slots, and fills in their names and upcharges. It returns the components
+// along with their foods and what they add to the price of the combo.
+func comboComponents(ctx context.Context, combo models.Combo, chosen []models.ComboComponent) ([]models.ComboComponent, []models.Food, models.Money, error) {
+	upcharge := models.Money{Currency: models.DefaultCurrency}
+	bySlot := map[string]models.ComboComponent{}
+
+	for _, component := range chosen {
+		if _, ok := bySlot[component.SlotID]; ok {
+			return nil, nil, models.Money{}, helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: fmt.Sprintf("slot %s of %s is chosen more than once", component.SlotID, *combo.Name),
+			}
+		}
+		bySlot[component.SlotID] = component
+	}
+
+	if len(bySlot) != len(chosen) || len(chosen) > len(combo.Slots) {
+		return nil, nil, models.Money{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: fmt.Sprintf("choose one food for each slot of %s", *combo.Name),
+		}
+	}
+
+	components := []models.ComboComponent{}
+	foods := []models.Food{}
+
+	for _, slot := range combo.Slots {
+		component, ok := bySlot[slot.SlotID]
+		if !ok {
+			return nil, nil, models.Money{}, helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: fmt.Sprintf("choose a food for %s of %s", *slot.Name, *combo.Name),
+			}
+		}
+
+		choice, ok := findComboChoice(slot, component)
+		if !ok {
+			return nil, nil, models.Money{}, helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: fmt.Sprintf("food %s can't be chosen for %s of %s", component.FoodID, *slot.Name, *combo.Name),
+			}
+		}
+
+		food, err := store.Foods.FindByID(ctx, *choice.FoodID)
+		if err != nil {
+			return nil, nil, models.Money{}, helpers.HttpError{
+				Code:    http.StatusNotFound,
+				Message: "food item was not found",
+			}
+		}
+
+		if err := checkFoodServed(ctx, food, time.Now()); err != nil {
+			return nil, nil, models.Money{}, err
+		}
+
+		component.VariantID = choice.VariantID
+		component.SlotName = *slot.Name
+		component.FoodName = *food.Name
+		component.VariantName = ""
+		if choice.VariantID != nil {
+			if variant, ok := findVariant(food, *choice.VariantID); ok {
+				component.VariantName = *variant.Name
+			}
+		}
+		component.Upcharge = choice.Upcharge
+
+		components = append(components, component)
+		foods = append(foods, food)
+		upcharge = upcharge.Add(choice.Upcharge)
+	}
+
+	return components, foods, upcharge, nil
+}
+
+// findComboChoice finds the choice of the slot for the food of the component,
+// and for its variant if one is given.
+func findComboChoice(slot models.ComboSlot, component models.ComboComponent) (models.ComboChoice, bool) {
+	for _, choice := range slot.Choices {
+		if *choice.FoodID != component.FoodID {
+			continue
+		}
+
+		if component.VariantID == nil || (choice.VariantID != nil && *choice.VariantID == *component.VariantID) {
+			return choice, true
+		}
+	}
+
+	return models.ComboChoice{}, false
+}
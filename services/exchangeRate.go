@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/helpers"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetExchangeRates lists the exchange rates, all of them or those of the
+// currency query, most recent first.
+func GetExchangeRates(c *gin.Context) ([]models.ExchangeRate, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	var allExchangeRates []models.ExchangeRate
+	var err error
+
+	if currency := c.Query("currency"); currency != "" {
+		allExchangeRates, err = store.ExchangeRates.FindByCurrency(ctx, strings.ToUpper(currency))
+	} else {
+		allExchangeRates, err = store.ExchangeRates.FindAll(ctx)
+	}
+
+	if err != nil {
+		return nil, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while listing exchange rates",
+		}
+	}
+
+	sort.SliceStable(allExchangeRates, func(i, j int) bool {
+		return allExchangeRates[i].EffectiveFrom.After(*allExchangeRates[j].EffectiveFrom)
+	})
+
+	return allExchangeRates, nil
+}
+
+func GetExchangeRate(c *gin.Context) (models.ExchangeRate, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	exchangeRate, err := store.ExchangeRates.FindByID(ctx, c.Param("id"))
+	if err != nil {
+		return models.ExchangeRate{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "exchange rate was not found",
+		}
+	}
+
+	return exchangeRate, nil
+}
+
+func CreateExchangeRate(c *gin.Context) (models.ExchangeRate, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	var exchangeRate models.ExchangeRate
+
+	if err := c.BindJSON(&exchangeRate); err != nil {
+		return models.ExchangeRate{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	if exchangeRate.EffectiveFrom == nil {
+		now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		exchangeRate.EffectiveFrom = &now
+	}
+
+	validationErr := validate.Struct(exchangeRate)
+	if validationErr != nil {
+		return models.ExchangeRate{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: validationErr.Error(),
+		}
+	}
+
+	if err := validateExchangeRate(&exchangeRate); err != nil {
+		return models.ExchangeRate{}, err
+	}
+
+	exchangeRate.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	exchangeRate.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	exchangeRate.ID = primitive.NewObjectID()
+	exchangeRate.ExchangeRateID = exchangeRate.ID.Hex()
+
+	insertErr := store.ExchangeRates.Insert(ctx, exchangeRate)
+	if insertErr != nil {
+		return models.ExchangeRate{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "exchange rate was not created",
+		}
+	}
+
+	return exchangeRate, nil
+}
+
+func UpdateExchangeRate(c *gin.Context) (models.ExchangeRate, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	var exchangeRate models.ExchangeRate
+
+	if err := c.BindJSON(&exchangeRate); err != nil {
+		return models.ExchangeRate{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	foundExchangeRate, err := store.ExchangeRates.FindByID(ctx, c.Param("id"))
+	if err != nil {
+		return models.ExchangeRate{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "exchange rate was not found",
+		}
+	}
+
+	if exchangeRate.Currency != nil {
+		foundExchangeRate.Currency = exchangeRate.Currency
+	}
+
+	if exchangeRate.Rate != nil {
+		foundExchangeRate.Rate = exchangeRate.Rate
+	}
+
+	if exchangeRate.EffectiveFrom != nil {
+		foundExchangeRate.EffectiveFrom = exchangeRate.EffectiveFrom
+	}
+
+	validationErr := validate.Struct(foundExchangeRate)
+	if validationErr != nil {
+		return models.ExchangeRate{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: validationErr.Error(),
+		}
+	}
+
+	if err := validateExchangeRate(&foundExchangeRate); err != nil {
+		return models.ExchangeRate{}, err
+	}
+
+	foundExchangeRate.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	err = store.ExchangeRates.Update(ctx, foundExchangeRate)
+	if err != nil {
+		return models.ExchangeRate{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "exchange rate update failed",
+		}
+	}
+
+	return foundExchangeRate, nil
+}
+
+// validateExchangeRate uppercases the currency, which must not be the base
+// currency that every rate converts from.
+func validateExchangeRate(exchangeRate *models.ExchangeRate) error {
+	currency := strings.ToUpper(*exchangeRate.Currency)
+	exchangeRate.Currency = &currency
+
+	if currency == models.DefaultCurrency {
+		return helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: fmt.Sprintf("%s is the base currency and has no exchange rate", currency),
+		}
+	}
+
+	return nil
+}
+
+// exchangeRateAt returns the rate of the currency in effect at the time, which
+// is 1 for the base currency.
+func exchangeRateAt(ctx context.Context, currency string, at time.Time) (float64, error) {
+	if currency == models.DefaultCurrency {
+		return 1, nil
+	}
+
+	allExchangeRates, err := store.ExchangeRates.FindByCurrency(ctx, currency)
+	if err != nil {
+		return 0, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while listing exchange rates",
+		}
+	}
+
+	var effective *models.ExchangeRate
+	for i, exchangeRate := range allExchangeRates {
+		if exchangeRate.EffectiveFrom.After(at) {
+			continue
+		}
+
+		if effective == nil || exchangeRate.EffectiveFrom.After(*effective.EffectiveFrom) {
+			effective = &allExchangeRates[i]
+		}
+	}
+
+	if effective == nil {
+		return 0, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: fmt.Sprintf("there is no exchange rate for %s in effect", currency),
+		}
+	}
+
+	return *effective.Rate, nil
+}
+
+// paymentCurrency returns the currency the invoice is paid in.
+func paymentCurrency(invoice models.Invoice) string {
+	if invoice.PaymentCurrency == nil {
+		return models.DefaultCurrency
+	}
+
+	return *invoice.PaymentCurrency
+}
+
+// convertInvoice converts the grand total and balance of the invoice to its
+// payment currency at the rate in effect at the time. It is called until the
+// invoice is paid, so a paid invoice keeps the rate of its last payment.
+func convertInvoice(ctx context.Context, invoice *models.Invoice, at time.Time) error {
+	if invoice.GrandTotal == nil {
+		return nil
+	}
+
+	currency := paymentCurrency(*invoice)
+
+	rate, err := exchangeRateAt(ctx, currency, at)
+	if err != nil {
+		return err
+	}
+
+	convertedTotal := invoice.GrandTotal.Convert(rate, currency)
+	convertedBalance := invoiceBalance(*invoice).Convert(rate, currency)
+
+	invoice.ExchangeRate = &rate
+	invoice.ConvertedTotal = &convertedTotal
+	invoice.ConvertedBalance = &convertedBalance
+
+	return nil
+}
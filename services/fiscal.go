@@ -0,0 +1,17 @@
+package services
+
+import (
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+)
+
+// fiscalSeries returns the series of the restaurant for the year of the time,
+// which documents created then are numbered in.
+func fiscalSeries(series string, at time.Time) models.FiscalNumber {
+	return models.FiscalNumber{
+		Restaurant: loadRestaurantSettings().Code,
+		Series:     series,
+		Year:       at.Year(),
+	}
+}
@@ -2,22 +2,22 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/EnesDemirtas/restaurant-management/database"
 	"github.com/EnesDemirtas/restaurant-management/helpers"
 	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/EnesDemirtas/restaurant-management/repositories"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var foodCollection *mongo.Collection = database.OpenCollection(database.Client, "food")
-
 func GetFoods(c *gin.Context) ([]bson.M, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
@@ -37,93 +37,85 @@ func GetFoods(c *gin.Context) ([]bson.M, error) {
 		startIndex = (page - 1) * recordPerPage
 	}
 
-	matchStage := bson.D{{Key: "$match", Value: bson.D{{}}}}
-	groupStage := bson.D{
-		{
-			Key: "$group", Value: bson.D{
-				{
-					Key: "_id", Value: bson.D{
-						{Key: "_id", Value: "null"},
-					},
-				},
-				{
-					Key: "total_count", Value: bson.D{
-						{Key: "$sum", Value: 1},
-					},
-				},
-				{
-					Key: "data", Value: bson.D{
-						{Key: "$push", Value: "$$ROOT"},
-					},
-				},
-			},
-		},
-	}
-	projectStage := bson.D{
-		{
-			Key: "$project", Value: bson.D{
-				{Key: "_id", Value: 0},
-				{Key: "total_count", Value: 1},
-				{Key: "food_items", Value: bson.D{{Key: "$slice", Value: []interface{}{
-					"$data", startIndex, recordPerPage,
-				}}}},
-			},
-		},
-	}
-
-	result, err := foodCollection.Aggregate(ctx, mongo.Pipeline{
-		{{Key: "$match", Value: matchStage}},
-		{{Key: "$group", Value: groupStage}},
-		{{Key: "$project", Value: projectStage}}},
-	)
-
+	allFoods, err := store.Foods.FindAll(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "	error occured while listing food items"})
 		return nil, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
 			Message: "error occured while listing food items",
 		}
 	}
 
-	var allFoods []bson.M
-	if err := result.All(ctx, &allFoods); err != nil {
-		return nil, helpers.HttpError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		}
+	if err := convertPrices(ctx, c.Query("currency"), allFoods); err != nil {
+		return nil, err
 	}
 
-	return allFoods, nil
+	return []bson.M{{
+		"total_count": len(allFoods),
+		"food_items":  paginate(allFoods, startIndex, recordPerPage),
+	}}, nil
 }
 
 func GetFood(c *gin.Context) (models.Food, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
-	foodId := c.Param("food_id")
-	var food models.Food
+	foodId := c.Param("id")
 
-	err := foodCollection.FindOne(ctx, bson.M{"food_id": foodId}).Decode(&food)
+	food, err := store.Foods.FindByID(ctx, foodId)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while fetching the food item"})
 		return models.Food{}, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
 			Message: "error occured while fetching the food item",
 		}
 	}
 
-	return food, nil
+	foods := []models.Food{food}
+	if err := convertPrices(ctx, c.Query("currency"), foods); err != nil {
+		return models.Food{}, err
+	}
+
+	return foods[0], nil
+}
+
+// convertPrices fills in the price of the foods in the currency, if one is
+// given, at the rate in effect now.
+func convertPrices(ctx context.Context, currency string, foods []models.Food) error {
+	if currency == "" {
+		return nil
+	}
+
+	currency = strings.ToUpper(currency)
+
+	rate, err := exchangeRateAt(ctx, currency, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for i, food := range foods {
+		for j, variant := range food.Variants {
+			convertedPrice := variant.Price.Convert(rate, currency)
+			foods[i].Variants[j].ConvertedPrice = &convertedPrice
+		}
+
+		if food.Price == nil {
+			continue
+		}
+
+		convertedPrice := food.Price.Convert(rate, currency)
+		foods[i].ConvertedPrice = &convertedPrice
+	}
+
+	return nil
 }
 
-func CreateFood(c *gin.Context) (*mongo.InsertOneResult, error) {
+func CreateFood(c *gin.Context) (models.Food, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
-	var menu models.Menu
 	var food models.Food
 
 	if err := c.BindJSON(&food); err != nil {
-		return nil, helpers.HttpError{
+		return models.Food{}, helpers.HttpError{
 			Code:    http.StatusBadRequest,
 			Message: err.Error(),
 		}
@@ -131,104 +123,424 @@ func CreateFood(c *gin.Context) (*mongo.InsertOneResult, error) {
 
 	validationErr := validate.Struct(food)
 	if validationErr != nil {
-		return nil, helpers.HttpError{
+		return models.Food{}, helpers.HttpError{
 			Code:    http.StatusBadRequest,
 			Message: validationErr.Error(),
 		}
 	}
 
-	err := menuCollection.FindOne(ctx, bson.M{"menu_id": food.MenuID}).Decode(&menu)
+	_, err := store.Menus.FindByID(ctx, *food.MenuID)
 	if err != nil {
-		return nil, helpers.HttpError{
+		return models.Food{}, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
 			Message: "menu was not found",
 		}
 	}
 
+	if err := prepareVariants(food.Variants); err != nil {
+		return models.Food{}, err
+	}
+
+	if err := prepareModifierGroups(food.ModifierGroups); err != nil {
+		return models.Food{}, err
+	}
+
 	food.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 	food.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 	food.ID = primitive.NewObjectID()
 	food.FoodID = food.ID.Hex()
-	var num = helpers.ToFixed(*food.Price, 2)
-	food.Price = &num
 
-	result, insertErr := foodCollection.InsertOne(ctx, food)
+	insertErr := store.Foods.Insert(ctx, food)
 	if insertErr != nil {
-		return nil, helpers.HttpError{
+		return models.Food{}, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
 			Message: "Food item was not created",
 		}
 	}
 
-	return result, nil
+	return food, nil
 }
 
-func UpdateFood(c *gin.Context) (*mongo.UpdateResult, error) {
+func UpdateFood(c *gin.Context) (models.Food, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
-	var menu models.Menu
 	var food models.Food
 
 	if err := c.BindJSON(&food); err != nil {
-		return nil, helpers.HttpError{
+		return models.Food{}, helpers.HttpError{
 			Code:    http.StatusBadRequest,
 			Message: err.Error(),
 		}
 	}
 
-	foodId := c.Param("food_id")
+	foodId := c.Param("id")
 
-	var updateObj primitive.D
+	foundFood, err := store.Foods.FindByID(ctx, foodId)
+	if err != nil {
+		return models.Food{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "food item was not found",
+		}
+	}
 
 	if food.Name != nil {
-		updateObj = append(updateObj, primitive.E{Key: "name", Value: food.Name})
+		foundFood.Name = food.Name
 	}
 
 	if food.Price != nil {
-		updateObj = append(updateObj, primitive.E{Key: "price", Value: food.Price})
+		foundFood.Price = food.Price
 	}
 
 	if food.FoodImage != nil {
-		updateObj = append(updateObj, primitive.E{Key: "food_image", Value: food.FoodImage})
+		foundFood.FoodImage = food.FoodImage
 	}
 
 	if food.MenuID != nil {
-		err := menuCollection.FindOne(ctx, bson.M{"menu_id": food.MenuID}).Decode(&menu)
+		_, err := store.Menus.FindByID(ctx, *food.MenuID)
 		if err != nil {
-			return nil, helpers.HttpError{
+			return models.Food{}, helpers.HttpError{
 				Code:    http.StatusInternalServerError,
 				Message: "menu was not found",
 			}
 		}
-		updateObj = append(updateObj, primitive.E{Key: "menu", Value: food.Price})
+		foundFood.MenuID = food.MenuID
 	}
 
-	food.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-	updateObj = append(updateObj, primitive.E{Key: "updated_at", Value: food.UpdatedAt})
+	// Variants and modifier groups are replaced as a whole. Items already
+	// ordered keep the price they were ordered at.
+	if food.Variants != nil {
+		if validationErr := validate.Var(food.Variants, "dive"); validationErr != nil {
+			return models.Food{}, helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: validationErr.Error(),
+			}
+		}
+
+		if err := prepareVariants(food.Variants); err != nil {
+			return models.Food{}, err
+		}
+		foundFood.Variants = food.Variants
+	}
+
+	if foundFood.Price == nil && len(foundFood.Variants) == 0 {
+		return models.Food{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "a food without variants needs a price",
+		}
+	}
 
-	upsert := true
-	filter := bson.M{"food_id": foodId}
+	// Modifier groups are replaced as a whole. Items already ordered keep the
+	// modifiers they were ordered with.
+	if food.ModifierGroups != nil {
+		if validationErr := validate.Var(food.ModifierGroups, "dive"); validationErr != nil {
+			return models.Food{}, helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: validationErr.Error(),
+			}
+		}
 
-	opt := options.UpdateOptions{
-		Upsert: &upsert,
+		if err := prepareModifierGroups(food.ModifierGroups); err != nil {
+			return models.Food{}, err
+		}
+		foundFood.ModifierGroups = food.ModifierGroups
 	}
 
-	result, err := foodCollection.UpdateOne(
-		ctx,
-		filter,
-		bson.D{
-			{Key: "$set", Value: updateObj},
-		},
-		&opt,
-	)
+	foundFood.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
+	err = store.Foods.Update(ctx, foundFood)
 	if err != nil {
-		return nil, helpers.HttpError{
+		return models.Food{}, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
 			Message: "food item update failed",
 		}
 	}
 
-	return result, nil
+	return foundFood, nil
+}
+
+// AvailabilityRequest marks a food available or sold out and sets how many
+// portions are left. Unlimited drops the count of portions.
+type AvailabilityRequest struct {
+	Available         *bool `json:"available"`
+	RemainingPortions *int  `json:"remaining_portions" validate:"omitempty,min=0"`
+	Unlimited         bool  `json:"unlimited"`
+}
+
+// SetFoodAvailability is used by the kitchen to take a food off, or put it
+// back on, what can be ordered.
+func SetFoodAvailability(c *gin.Context) (models.Food, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	var request AvailabilityRequest
+
+	if err := c.BindJSON(&request); err != nil {
+		return models.Food{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	validationErr := validate.Struct(request)
+	if validationErr != nil {
+		return models.Food{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: validationErr.Error(),
+		}
+	}
+
+	food, err := store.Foods.FindByID(ctx, c.Param("id"))
+	if err != nil {
+		return models.Food{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "food item was not found",
+		}
+	}
+
+	if request.Available != nil {
+		food.Available = request.Available
+	}
+
+	if request.RemainingPortions != nil {
+		food.RemainingPortions = request.RemainingPortions
+	}
+
+	if request.Unlimited {
+		food.RemainingPortions = nil
+	}
+
+	food.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	err = store.Foods.SetAvailability(ctx, food)
+	if err != nil {
+		return models.Food{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "food availability update failed",
+		}
+	}
+
+	return food, nil
+}
+
+// takePortions takes the portions ordered of a food, or returns an error if
+// it is sold out or too few portions are left.
+func takePortions(ctx context.Context, food models.Food, quantity int) error {
+	err := store.Foods.TakePortions(ctx, food.FoodID, quantity)
+	if errors.Is(err, repositories.ErrConflict) {
+		message := fmt.Sprintf("%s is sold out", *food.Name)
+		if stored, err := store.Foods.FindByID(ctx, food.FoodID); err == nil && (stored.Available == nil || *stored.Available) && stored.RemainingPortions != nil && *stored.RemainingPortions > 0 {
+			message = fmt.Sprintf("only %d of %s left", *stored.RemainingPortions, *food.Name)
+		}
+
+		return helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: message,
+		}
+	}
+
+	if err != nil {
+		return helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "food portions were not updated",
+		}
+	}
+
+	return nil
+}
+
+// returnPortions gives back the portions taken, by food, for an order that
+// was not placed.
+func returnPortions(ctx context.Context, taken map[string]int) {
+	for foodId, quantity := range taken {
+		if err := store.Foods.ReturnPortions(ctx, foodId, quantity); err != nil {
+			log.Printf("Portions of food %s were not given back: %s", foodId, err)
+		}
+	}
+}
+
+// itemPortions returns the portions an order item takes, by food: its
+// quantity of the food, or of every food of the combo.
+func itemPortions(orderItem models.OrderItem) map[string]int {
+	quantity := 1
+	if orderItem.Quantity != nil {
+		quantity = *orderItem.Quantity
+	}
+
+	portions := map[string]int{}
+	if orderItem.FoodID != nil {
+		portions[*orderItem.FoodID] += quantity
+	}
+
+	for _, component := range orderItem.ComboComponents {
+		portions[component.FoodID] += quantity
+	}
+
+	return portions
+}
+
+// prepareVariants gives the variants that are new an ID, and checks that no
+// two variants share a SKU.
+func prepareVariants(variants []models.FoodVariant) error {
+	skus := map[string]bool{}
+
+	for i := range variants {
+		if variants[i].VariantID == "" {
+			variants[i].VariantID = primitive.NewObjectID().Hex()
+		}
+
+		if sku := variants[i].SKU; sku != nil {
+			if skus[*sku] {
+				return helpers.HttpError{
+					Code:    http.StatusBadRequest,
+					Message: fmt.Sprintf("SKU %s is used by more than one variant", *sku),
+				}
+			}
+			skus[*sku] = true
+		}
+	}
+
+	return nil
+}
+
+// foodPrice returns the price of the food, or of its variant when the food has
+// variants, which one of them must then be.
+func foodPrice(food models.Food, variantId *string) (models.Money, error) {
+	if len(food.Variants) == 0 {
+		if variantId != nil {
+			return models.Money{}, helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: fmt.Sprintf("%s has no variants", *food.Name),
+			}
+		}
+
+		return *food.Price, nil
+	}
+
+	if variantId == nil {
+		return models.Money{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: fmt.Sprintf("pick a variant of %s", *food.Name),
+		}
+	}
+
+	variant, ok := findVariant(food, *variantId)
+	if !ok {
+		return models.Money{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: fmt.Sprintf("variant %s is not offered with %s", *variantId, *food.Name),
+		}
+	}
+
+	return *variant.Price, nil
+}
+
+func findVariant(food models.Food, variantId string) (models.FoodVariant, bool) {
+	for _, variant := range food.Variants {
+		if variant.VariantID == variantId {
+			return variant, true
+		}
+	}
+
+	return models.FoodVariant{}, false
+}
+
+// prepareModifierGroups gives the groups, and their options, that are new an
+// ID, and checks that enough options are offered to pick from.
+func prepareModifierGroups(groups []models.ModifierGroup) error {
+	for i := range groups {
+		group := &groups[i]
+		if group.GroupID == "" {
+			group.GroupID = primitive.NewObjectID().Hex()
+		}
+
+		if minSelections(*group) > len(group.Options) {
+			return helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: fmt.Sprintf("%s offers fewer options than must be picked", *group.Name),
+			}
+		}
+
+		for j := range group.Options {
+			if group.Options[j].OptionID == "" {
+				group.Options[j].OptionID = primitive.NewObjectID().Hex()
+			}
+		}
+	}
+
+	return nil
+}
+
+// minSelections returns how many options of the group must be picked.
+func minSelections(group models.ModifierGroup) int {
+	if group.Required {
+		return max(group.MinSelections, 1)
+	}
+
+	return group.MinSelections
+}
+
+// selectModifiers checks the modifiers picked for a food against its modifier
+// groups and fills in their names and price deltas. It returns the modifiers
+// along with what they add to the price of the food.
+func selectModifiers(food models.Food, selected []models.SelectedModifier) ([]models.SelectedModifier, models.Money, error) {
+	priceDelta := models.Money{Currency: models.DefaultCurrency}
+	picked := map[string]int{}
+	seen := map[string]bool{}
+
+	for i, modifier := range selected {
+		option, group, ok := findModifierOption(food, modifier.GroupID, modifier.OptionID)
+		if !ok {
+			return nil, models.Money{}, helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: fmt.Sprintf("option %s is not offered with %s", modifier.OptionID, *food.Name),
+			}
+		}
+
+		if seen[option.OptionID] {
+			return nil, models.Money{}, helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: fmt.Sprintf("%s is picked more than once", *option.Name),
+			}
+		}
+		seen[option.OptionID] = true
+		picked[group.GroupID]++
+
+		selected[i].GroupName = *group.Name
+		selected[i].Name = *option.Name
+		selected[i].PriceDelta = option.PriceDelta
+		priceDelta = priceDelta.Add(option.PriceDelta)
+	}
+
+	for _, group := range food.ModifierGroups {
+		if count := picked[group.GroupID]; count < minSelections(group) {
+			return nil, models.Money{}, helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: fmt.Sprintf("pick at least %d of %s for %s", minSelections(group), *group.Name, *food.Name),
+			}
+		} else if group.MaxSelections > 0 && count > group.MaxSelections {
+			return nil, models.Money{}, helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: fmt.Sprintf("pick at most %d of %s for %s", group.MaxSelections, *group.Name, *food.Name),
+			}
+		}
+	}
+
+	return selected, priceDelta, nil
+}
+
+func findModifierOption(food models.Food, groupId, optionId string) (models.ModifierOption, models.ModifierGroup, bool) {
+	for _, group := range food.ModifierGroups {
+		if group.GroupID != groupId {
+			continue
+		}
+
+		for _, option := range group.Options {
+			if option.OptionID == optionId {
+				return option, group, true
+			}
+		}
+	}
+
+	return models.ModifierOption{}, models.ModifierGroup{}, false
 }
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/EnesDemirtas/restaurant-management/helpers"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/EnesDemirtas/restaurant-management/repositories"
+)
+
+func TestItemPortions(t *testing.T) {
+	soup, burger, fries := "soup", "burger", "fries"
+	two := 2
+
+	tests := []struct {
+		name      string
+		orderItem models.OrderItem
+		want      map[string]int
+	}{
+		{"food", models.OrderItem{FoodID: &soup, Quantity: &two}, map[string]int{soup: 2}},
+		{"no quantity", models.OrderItem{FoodID: &soup}, map[string]int{soup: 1}},
+		{"combo", models.OrderItem{Quantity: &two, ComboComponents: []models.ComboComponent{
+			{FoodID: burger}, {FoodID: fries}, {FoodID: fries},
+		}}, map[string]int{burger: 2, fries: 4}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := itemPortions(test.orderItem); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTakePortions(t *testing.T) {
+	ctx := context.Background()
+	UseStore(repositories.NewMemoryStore())
+
+	name := "Soup"
+	available, soldOut := true, false
+	three := 3
+	foods := []models.Food{
+		{FoodID: "counted", Name: &name, Available: &available, RemainingPortions: &three},
+		{FoodID: "sold out", Name: &name, Available: &soldOut},
+	}
+	for _, food := range foods {
+		if err := store.Foods.Insert(ctx, food); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		food        models.Food
+		quantity    int
+		wantStatus  int
+		wantMessage string
+	}{
+		{"portions left", foods[0], 2, http.StatusOK, ""},
+		{"too few portions", foods[0], 2, http.StatusConflict, "only 1 of Soup left"},
+		{"sold out", foods[1], 1, http.StatusConflict, "Soup is sold out"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := takePortions(ctx, test.food, test.quantity)
+			if status := httpStatus(err); status != test.wantStatus {
+				t.Fatalf("got status %d (%v), want %d", status, err, test.wantStatus)
+			}
+
+			var httpError helpers.HttpError
+			if errors.As(err, &httpError) && httpError.Message != test.wantMessage {
+				t.Errorf("got %q, want %q", httpError.Message, test.wantMessage)
+			}
+		})
+	}
+
+	// Portions of an order that was not placed are given back.
+	returnPortions(ctx, map[string]int{"counted": 1})
+
+	stored, err := store.Foods.FindByID(ctx, "counted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *stored.RemainingPortions != 2 {
+		t.Errorf("got %d portions left, want 2", *stored.RemainingPortions)
+	}
+}
@@ -2,49 +2,78 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
-	"github.com/EnesDemirtas/restaurant-management/database"
 	"github.com/EnesDemirtas/restaurant-management/helpers"
 	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/EnesDemirtas/restaurant-management/repositories"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type InvoiceViewFormat struct {
-	InvoiceID      string
-	PaymentMethod  string
-	OrderID        string
-	PaymentStatus  *string
-	PaymentDue     interface{}
-	TableNumber    interface{}
-	PaymentDueDate time.Time
-	OrderDetails   interface{}
+// InvoiceRequest is an invoice to create, optionally split between payers.
+type InvoiceRequest struct {
+	models.Invoice
+	Split *InvoiceSplit `json:"split"`
 }
 
-var invoiceCollection *mongo.Collection = database.OpenCollection(database.Client, "invoice")
+type InvoiceViewFormat struct {
+	InvoiceID        string
+	InvoiceNumber    *string
+	PaymentMethod    string
+	OrderID          string
+	PaymentStatus    *string
+	PaymentDue       interface{}
+	Discounts        []models.InvoiceDiscount
+	DiscountTotal    *models.Money
+	Subtotal         *models.Money
+	ServiceCharge    *models.Money
+	TaxLines         []models.InvoiceTaxLine
+	TaxTotal         *models.Money
+	GrandTotal       *models.Money
+	AmountPaid       *models.Money
+	Balance          *models.Money
+	TipTotal         *models.Money
+	BaseCurrency     string
+	PaymentCurrency  string
+	ExchangeRate     *float64
+	ConvertedTotal   *models.Money
+	ConvertedBalance *models.Money
+	Payments         []models.InvoicePayment
+	AmountRefunded   models.Money
+	Voided           bool
+	Ledger           []models.LedgerEntry
+	SplitGroupID     *string
+	SplitIndex       *int
+	SplitCount       *int
+	TableNumber      interface{}
+	PaymentDueDate   time.Time
+	OrderDetails     interface{}
+}
 
-func GetInvoices(c *gin.Context) ([]bson.M, error) {
+// GetInvoices lists the invoices, all of them or those with the payment status
+// of the status query, e.g. status=overdue.
+func GetInvoices(c *gin.Context) ([]models.Invoice, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
-	result, err := invoiceCollection.Find(context.TODO(), bson.M{})
-	if err != nil {
-		return nil, helpers.HttpError{
-			Code:    http.StatusInternalServerError,
-			Message: "error occured while listing all invoices",
-		}
+	var allInvoices []models.Invoice
+	var err error
+
+	if status := c.Query("status"); status != "" {
+		allInvoices, err = store.Invoices.FindByStatus(ctx, strings.ToUpper(status))
+	} else {
+		allInvoices, err = store.Invoices.FindAll(ctx)
 	}
 
-	var allInvoices []bson.M
-	if err = result.All(ctx, &allInvoices); err != nil {
+	if err != nil {
 		return nil, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
+			Message: "error occured while listing all invoices",
 		}
 	}
 
@@ -55,11 +84,9 @@ func GetInvoice(c *gin.Context) (InvoiceViewFormat, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
-	invoiceId := c.Param("invoice_id")
+	invoiceId := c.Param("id")
 
-	var invoice models.Invoice
-
-	err := invoiceCollection.FindOne(ctx, bson.M{"invoice_id": invoiceId}).Decode(&invoice)
+	invoice, err := store.Invoices.FindByID(ctx, invoiceId)
 	if err != nil {
 		return InvoiceViewFormat{}, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
@@ -69,13 +96,6 @@ func GetInvoice(c *gin.Context) (InvoiceViewFormat, error) {
 
 	var invoiceView InvoiceViewFormat
 
-	allOrderItems, err := ItemsByOrder(invoice.OrderID)
-	if err != nil {
-		return InvoiceViewFormat{}, helpers.HttpError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		}
-	}
 	invoiceView.OrderID = invoice.OrderID
 	invoiceView.PaymentDueDate = invoice.PaymentDueDate
 
@@ -85,30 +105,85 @@ func GetInvoice(c *gin.Context) (InvoiceViewFormat, error) {
 	}
 
 	invoiceView.InvoiceID = invoice.InvoiceID
+	if invoice.FiscalNumber != nil {
+		invoiceView.InvoiceNumber = &invoice.FiscalNumber.Number
+	}
 	invoiceView.PaymentStatus = invoice.PaymentStatus
-	invoiceView.PaymentDue = allOrderItems[0]["payment_due"]
-	invoiceView.TableNumber = allOrderItems[0]["table_number"]
-	invoiceView.OrderDetails = allOrderItems[0]["order_items"]
+	invoiceView.Discounts = invoice.Discounts
+	invoiceView.DiscountTotal = invoice.DiscountTotal
+	invoiceView.Subtotal = invoice.Subtotal
+	invoiceView.ServiceCharge = invoice.ServiceCharge
+	invoiceView.TaxLines = invoice.TaxLines
+	invoiceView.TaxTotal = invoice.TaxTotal
+	invoiceView.GrandTotal = invoice.GrandTotal
+	invoiceView.AmountPaid = invoice.AmountPaid
+	invoiceView.Balance = invoice.Balance
+	invoiceView.TipTotal = invoice.TipTotal
+	invoiceView.BaseCurrency = models.DefaultCurrency
+	invoiceView.PaymentCurrency = paymentCurrency(invoice)
+	invoiceView.ExchangeRate = invoice.ExchangeRate
+	invoiceView.ConvertedTotal = invoice.ConvertedTotal
+	invoiceView.ConvertedBalance = invoice.ConvertedBalance
+	invoiceView.Payments = invoice.Payments
+
+	ledger, err := invoiceLedger(ctx, invoice.InvoiceID)
+	if err != nil {
+		return InvoiceViewFormat{}, err
+	}
+	invoiceView.AmountRefunded = ledger.Refunded
+	invoiceView.Voided = ledger.Voided
+	invoiceView.Ledger = ledger.Entries
+	invoiceView.SplitGroupID = invoice.SplitGroupID
+	invoiceView.SplitIndex = invoice.SplitIndex
+	invoiceView.SplitCount = invoice.SplitCount
+
+	// Invoices billed before lines were stored are shown from the order.
+	if invoice.Lines == nil {
+		allOrderItems, err := ItemsByOrder(invoice.OrderID)
+		if err != nil {
+			return InvoiceViewFormat{}, helpers.HttpError{
+				Code:    http.StatusInternalServerError,
+				Message: err.Error(),
+			}
+		}
+
+		if len(allOrderItems) > 0 {
+			invoiceView.PaymentDue = allOrderItems[0]["payment_due"]
+			invoiceView.TableNumber = allOrderItems[0]["table_number"]
+			invoiceView.OrderDetails = allOrderItems[0]["order_items"]
+		}
+
+		if invoice.GrandTotal != nil {
+			invoiceView.PaymentDue = *invoice.GrandTotal
+		}
+
+		return invoiceView, nil
+	}
+
+	lines, table := billedLines(ctx, invoice)
+	invoiceView.PaymentDue = invoice.GrandTotal
+	invoiceView.TableNumber = table.TableNumber
+	invoiceView.OrderDetails = orderLineDetails(lines, invoice.OrderID, table)
 
 	return invoiceView, nil
 }
 
-func CreateInvoice(c *gin.Context) (*mongo.InsertOneResult, error) {
+func CreateInvoice(c *gin.Context) ([]models.Invoice, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
-	var invoice models.Invoice
+	var request InvoiceRequest
 
-	if err := c.BindJSON(&invoice); err != nil {
+	if err := c.BindJSON(&request); err != nil {
 		return nil, helpers.HttpError{
 			Code:    http.StatusBadRequest,
 			Message: err.Error(),
 		}
 	}
 
-	var order models.Order
+	invoice := request.Invoice
 
-	err := orderCollection.FindOne(ctx, bson.M{"order_id": invoice.OrderID}).Decode(&order)
+	order, err := store.Orders.FindByID(ctx, invoice.OrderID)
 	if err != nil {
 		return nil, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
@@ -116,12 +191,25 @@ func CreateInvoice(c *gin.Context) (*mongo.InsertOneResult, error) {
 		}
 	}
 
-	status := "PENDING"
-	if invoice.PaymentStatus == nil {
-		invoice.PaymentStatus = &status
+	if !canTransitionOrder(orderStatus(order), models.OrderStatusBilled) {
+		return nil, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: fmt.Sprintf("order is %s and cannot be invoiced", orderStatus(order)),
+		}
+	}
+
+	// Invoices only become paid through their payments.
+	status := models.PaymentStatusPending
+	invoice.PaymentStatus = &status
+
+	if invoice.PaymentCurrency != nil {
+		currency := strings.ToUpper(*invoice.PaymentCurrency)
+		invoice.PaymentCurrency = &currency
 	}
 
-	invoice.PaymentDueDate, _ = time.Parse(time.RFC3339, time.Now().AddDate(0, 0, 1).Format(time.RFC3339))
+	if invoice.PaymentDueDate.IsZero() {
+		invoice.PaymentDueDate, _ = time.Parse(time.RFC3339, time.Now().AddDate(0, 0, 1).Format(time.RFC3339))
+	}
 	invoice.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 	invoice.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 	invoice.ID = primitive.NewObjectID()
@@ -135,72 +223,339 @@ func CreateInvoice(c *gin.Context) (*mongo.InsertOneResult, error) {
 		}
 	}
 
-	result, insertErr := invoiceCollection.InsertOne(ctx, invoice)
+	couponCodes, coupons, err := checkCoupons(ctx, invoice.CouponCodes)
+	if err != nil {
+		return nil, err
+	}
+	invoice.CouponCodes = couponCodes
+
+	var invoices []models.Invoice
+
+	if request.Split != nil {
+		validationErr := validate.Struct(request.Split)
+		if validationErr != nil {
+			return nil, helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: validationErr.Error(),
+			}
+		}
+
+		invoices, err = splitInvoice(ctx, invoice, *request.Split)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if err := billInvoice(ctx, &invoice); err != nil {
+			return nil, err
+		}
+		invoices = []models.Invoice{invoice}
+	}
+
+	for i := range invoices {
+		if err := convertInvoice(ctx, &invoices[i], time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkCouponsApplied(coupons, invoices); err != nil {
+		return nil, err
+	}
+
+	// The order is billed first, so that billing it twice at the same time
+	// redeems the coupons only once.
+	billedOrder, err := transitionOrder(ctx, order, models.OrderStatusBilled)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := redeemCoupons(ctx, coupons); err != nil {
+		unbillOrder(ctx, billedOrder, orderStatus(order))
+		return nil, err
+	}
+
+	invoices, insertErr := store.Invoices.InsertNumbered(ctx, invoices, fiscalSeries(models.FiscalSeriesInvoice, invoice.CreatedAt))
 	if insertErr != nil {
+		unredeemCoupons(ctx, coupons)
+		unbillOrder(ctx, billedOrder, orderStatus(order))
 		return nil, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
 			Message: "invoice item was not created",
 		}
 	}
 
-	return result, nil
+	return invoices, nil
 }
 
-func UpdateInvoice(c *gin.Context) (*mongo.UpdateResult, error) {
+func UpdateInvoice(c *gin.Context) (models.Invoice, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
 	var invoice models.Invoice
 
-	invoiceId := c.Param("invoice_id")
+	invoiceId := c.Param("id")
 
 	if err := c.BindJSON(&invoice); err != nil {
-		return nil, helpers.HttpError{
+		return models.Invoice{}, helpers.HttpError{
 			Code:    http.StatusBadRequest,
 			Message: err.Error(),
 		}
 	}
 
-	filter := bson.M{"invoice_id": invoiceId}
+	foundInvoice, err := store.Invoices.FindByID(ctx, invoiceId)
+	if err != nil {
+		return models.Invoice{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "invoice was not found",
+		}
+	}
+
+	if isPaid(foundInvoice) {
+		return models.Invoice{}, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: "invoice is paid and can no longer be changed",
+		}
+	}
+
+	if err := checkDayOpen(ctx, foundInvoice.CreatedAt); err != nil {
+		return models.Invoice{}, err
+	}
 
-	var updateObj primitive.D
+	ledger, err := invoiceLedger(ctx, foundInvoice.InvoiceID)
+	if err != nil {
+		return models.Invoice{}, err
+	}
+
+	if ledger.Voided {
+		return models.Invoice{}, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: "invoice is voided and can no longer be changed",
+		}
+	}
 
 	if invoice.PaymentMethod != nil {
-		updateObj = append(updateObj, primitive.E{Key: "payment_method", Value: invoice.PaymentMethod})
+		foundInvoice.PaymentMethod = invoice.PaymentMethod
+	}
+
+	if invoice.PaymentCurrency != nil {
+		currency := strings.ToUpper(*invoice.PaymentCurrency)
+		if len(foundInvoice.Payments) > 0 && currency != paymentCurrency(foundInvoice) {
+			return models.Invoice{}, helpers.HttpError{
+				Code:    http.StatusConflict,
+				Message: "the payment currency cannot change once payments have been made",
+			}
+		}
+		foundInvoice.PaymentCurrency = &currency
 	}
 
 	if invoice.PaymentStatus != nil {
-		updateObj = append(updateObj, primitive.E{Key: "payment_status", Value: invoice.PaymentStatus})
+		return models.Invoice{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "the payment status follows from the payments of the invoice and cannot be set",
+		}
 	}
 
-	invoice.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-	updateObj = append(updateObj, primitive.E{Key: "updated_at", Value: invoice.UpdatedAt})
+	// Reminders start over for a new due date.
+	if !invoice.PaymentDueDate.IsZero() && !invoice.PaymentDueDate.Equal(foundInvoice.PaymentDueDate) {
+		foundInvoice.PaymentDueDate = invoice.PaymentDueDate
+		foundInvoice.RemindersSent = 0
+		foundInvoice.RemindedAt = nil
+	}
 
-	upsert := true
-	opt := options.UpdateOptions{
-		Upsert: &upsert,
+	if invoice.BillingEmail != nil {
+		foundInvoice.BillingEmail = invoice.BillingEmail
 	}
 
-	status := "PENDING"
-	if invoice.PaymentStatus == nil {
-		invoice.PaymentStatus = &status
+	// The invoice is pending, partially paid or overdue as its payments and
+	// due date say.
+	status := unpaidStatus(foundInvoice, time.Now())
+	foundInvoice.PaymentStatus = &status
+
+	// Billing a split invoice again would charge it the whole order, so it
+	// keeps the amounts it was split with. Amounts are also kept once
+	// payments have been made against them.
+	if foundInvoice.SplitGroupID == nil && len(foundInvoice.Payments) == 0 {
+		if err := billInvoice(ctx, &foundInvoice); err != nil {
+			return models.Invoice{}, err
+		}
+	}
+
+	if err := convertInvoice(ctx, &foundInvoice, time.Now()); err != nil {
+		return models.Invoice{}, err
 	}
 
-	result, err := invoiceCollection.UpdateOne(
-		ctx,
-		filter,
-		bson.D{
-			{Key: "$set", Value: updateObj},
-		},
-		&opt,
-	)
+	foundInvoice.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	err = store.Invoices.Update(ctx, foundInvoice)
+	if errors.Is(err, repositories.ErrConflict) {
+		return models.Invoice{}, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: "a payment was recorded for the invoice meanwhile, please retry",
+		}
+	}
 
 	if err != nil {
-		return nil, helpers.HttpError{
+		return models.Invoice{}, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
 			Message: "invoice item update failed",
 		}
 	}
 
-	return result, nil
+	return foundInvoice, nil
+}
+
+// billInvoice prices the items of the invoiced order, takes the promotions off
+// them and applies the active tax rates. It is called until the invoice is
+// paid, after which the stored lines and totals never change.
+func billInvoice(ctx context.Context, invoice *models.Invoice) error {
+	bill, err := billOrder(ctx, *invoice)
+	if err != nil {
+		return err
+	}
+
+	breakdown := calculateTaxes(bill.taxedLines(), bill.TaxRates)
+
+	invoice.Lines = []models.InvoiceLine{}
+	for _, line := range bill.Lines {
+		invoice.Lines = append(invoice.Lines, line.InvoiceLine)
+	}
+
+	invoice.Discounts = bill.Discounts
+	invoice.DiscountTotal = discountTotal(bill.Discounts)
+	invoice.Subtotal = &breakdown.Subtotal
+	invoice.ServiceCharge = &breakdown.ServiceCharge
+	invoice.TaxLines = breakdown.TaxLines
+	invoice.TaxTotal = &breakdown.TaxTotal
+	invoice.GrandTotal = &breakdown.GrandTotal
+
+	return nil
+}
+
+// bill is the invoiced order priced for billing: its discounted lines, the
+// service charge if the party is large enough, the discounts given on the
+// lines and the tax rates to apply.
+type bill struct {
+	Lines         []invoiceLine
+	ServiceCharge *invoiceLine
+	Discounts     []models.InvoiceDiscount
+	TaxRates      []models.TaxRate
+}
+
+// taxedLines returns the lines of the bill along with its service charge.
+func (b bill) taxedLines() []invoiceLine {
+	if b.ServiceCharge == nil {
+		return b.Lines
+	}
+
+	return append(append([]invoiceLine{}, b.Lines...), *b.ServiceCharge)
+}
+
+func billOrder(ctx context.Context, invoice models.Invoice) (bill, error) {
+	lines, table, err := orderLines(ctx, invoice.OrderID)
+	if err != nil {
+		return bill{}, err
+	}
+
+	automatic, coupons, err := billingPromotions(ctx, invoice.CouponCodes)
+	if err != nil {
+		return bill{}, err
+	}
+
+	lines, discounts := applyPromotions(lines, automatic, coupons)
+
+	taxRates, err := activeTaxRates(ctx)
+	if err != nil {
+		return bill{}, err
+	}
+
+	return bill{
+		Lines:         lines,
+		ServiceCharge: serviceChargeLine(table, applyTaxes(lines, taxRates).Subtotal),
+		Discounts:     discounts,
+		TaxRates:      taxRates,
+	}, nil
+}
+
+func discountTotal(discounts []models.InvoiceDiscount) *models.Money {
+	total := sumMoney()
+	for _, discount := range discounts {
+		total = total.Add(discount.Amount)
+	}
+
+	return &total
+}
+
+// billedLines returns the stored lines of the invoice with the current price
+// of each food, for comparison, and the table the order was served at.
+func billedLines(ctx context.Context, invoice models.Invoice) ([]invoiceLine, models.Table) {
+	var table models.Table
+	if order, err := store.Orders.FindByID(ctx, invoice.OrderID); err == nil && order.TableID != nil {
+		table, _ = store.Tables.FindByID(ctx, *order.TableID)
+	}
+
+	lines := []invoiceLine{}
+	for _, billed := range invoice.Lines {
+		line := invoiceLine{InvoiceLine: billed}
+
+		if food, err := store.Foods.FindByID(ctx, billed.FoodID); err == nil {
+			line.FoodImage = food.FoodImage
+			line.CurrentPrice = food.Price
+			if variant, ok := findVariant(food, billed.VariantID); ok {
+				line.CurrentPrice = variant.Price
+			}
+		} else if combo, err := store.Combos.FindByID(ctx, billed.ComboID); err == nil {
+			line.CurrentPrice = combo.Price
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, table
+}
+
+func isPaid(invoice models.Invoice) bool {
+	return invoice.PaymentStatus != nil && *invoice.PaymentStatus == models.PaymentStatusPaid
+}
+
+// settleOrder closes the billed order once every invoice of it has been paid
+// or voided, or voids it if all of them were voided.
+func settleOrder(ctx context.Context, orderId string) error {
+	order, err := store.Orders.FindByID(ctx, orderId)
+	if err != nil {
+		return helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "Order was not found",
+		}
+	}
+
+	if orderStatus(order) != models.OrderStatusBilled {
+		return nil
+	}
+
+	invoices, err := store.Invoices.FindByOrder(ctx, orderId)
+	if err != nil {
+		return helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while listing the invoices of the order",
+		}
+	}
+
+	to := models.OrderStatusVoided
+	for _, invoice := range invoices {
+		ledger, err := invoiceLedger(ctx, invoice.InvoiceID)
+		if err != nil {
+			return err
+		}
+
+		if ledger.Voided {
+			continue
+		}
+
+		if !isPaid(invoice) {
+			return nil
+		}
+		to = models.OrderStatusClosed
+	}
+
+	_, err = transitionOrder(ctx, order, to)
+	return err
 }
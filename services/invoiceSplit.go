@@ -0,0 +1,435 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"net/http"
+	"sort"
+
+	"github.com/EnesDemirtas/restaurant-management/helpers"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	SplitModeEven  = "EVEN"
+	SplitModeSeat  = "SEAT"
+	SplitModeItems = "ITEMS"
+)
+
+// InvoiceSplit describes how the bill of an order is split between payers:
+// evenly between Count payers, one payer per seat, or by listing the order
+// items each payer takes.
+type InvoiceSplit struct {
+	Mode   *string    `json:"mode" validate:"required,eq=EVEN|eq=SEAT|eq=ITEMS"`
+	Count  *int       `json:"count" validate:"omitempty,gte=2,lte=50"`
+	Payers [][]string `json:"payers"`
+}
+
+// splitInvoice bills the order of the invoice as one invoice per payer. Every
+// amount is split in cents so the invoices add up to the order total exactly:
+// the grand total is split first, then each tax line, and the subtotal of each
+// invoice is what is left of its grand total. Discounts are split the same
+// way, following the lines they were given on, and the service charge in
+// proportion to what each payer is billed for.
+func splitInvoice(ctx context.Context, invoice models.Invoice, split InvoiceSplit) ([]models.Invoice, error) {
+	bill, err := billOrder(ctx, invoice)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := bill.Lines
+	taxRates := bill.TaxRates
+
+	if len(lines) == 0 {
+		return nil, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "order has no items to split",
+		}
+	}
+
+	parts, err := splitLines(lines, split)
+	if err != nil {
+		return nil, err
+	}
+
+	if bill.ServiceCharge != nil {
+		parts = splitServiceCharge(*bill.ServiceCharge, parts)
+	}
+
+	whole := applyTaxes(bill.taxedLines(), taxRates)
+	orderTotal := calculateTaxes(bill.taxedLines(), taxRates).GrandTotal
+
+	partAmounts := make([]taxAmounts, len(parts))
+	partTotals := make([]int64, len(parts))
+	partServiceCharges := make([]int64, len(parts))
+	for i, part := range parts {
+		partAmounts[i] = applyTaxes(part, taxRates)
+		partTotal := sumMoney(partAmounts[i].Subtotal, partAmounts[i].ServiceCharge).Add(sumMoney(partAmounts[i].Amounts...))
+		partTotals[i] = partTotal.Amount
+		partServiceCharges[i] = partAmounts[i].ServiceCharge.Amount
+	}
+	grandTotals := allocate(orderTotal, partTotals)
+	serviceCharges := allocate(whole.ServiceCharge, partServiceCharges)
+
+	breakdowns := make([]taxBreakdown, len(parts))
+	for i := range parts {
+		breakdowns[i].TaxLines = []models.InvoiceTaxLine{}
+	}
+
+	for t, taxRate := range taxRates {
+		if !whole.Applied[t] {
+			continue
+		}
+
+		taxable := make([]int64, len(parts))
+		amounts := make([]int64, len(parts))
+		for i := range parts {
+			taxable[i] = partAmounts[i].Taxable[t].Amount
+			amounts[i] = partAmounts[i].Amounts[t].Amount
+		}
+
+		partTaxable := allocate(whole.Taxable[t], taxable)
+		partTaxes := allocate(whole.Amounts[t], amounts)
+
+		for i := range parts {
+			if !partAmounts[i].Applied[t] {
+				continue
+			}
+
+			taxLine := invoiceTaxLine(taxRate)
+			taxLine.TaxableAmount = partTaxable[i]
+			taxLine.Amount = partTaxes[i]
+			breakdowns[i].TaxLines = append(breakdowns[i].TaxLines, taxLine)
+		}
+	}
+
+	partLines := splitLineAmounts(lines, parts)
+	partDiscounts := splitDiscounts(bill.Discounts, parts)
+
+	groupId := primitive.NewObjectID().Hex()
+	splitCount := len(parts)
+	splitTotal := sumMoney()
+	invoices := []models.Invoice{}
+
+	for i := range parts {
+		breakdowns[i].total()
+		breakdowns[i].ServiceCharge = serviceCharges[i]
+		breakdowns[i].Subtotal = grandTotals[i].Sub(breakdowns[i].TaxTotal).Sub(serviceCharges[i])
+		breakdowns[i].GrandTotal = grandTotals[i]
+		splitTotal = splitTotal.Add(grandTotals[i])
+
+		splitInvoice := invoice
+		splitInvoice.ID = primitive.NewObjectID()
+		splitInvoice.InvoiceID = splitInvoice.ID.Hex()
+
+		splitIndex := i + 1
+		splitInvoice.SplitGroupID = &groupId
+		splitInvoice.SplitIndex = &splitIndex
+		splitInvoice.SplitCount = &splitCount
+
+		splitInvoice.Lines = partLines[i]
+		splitInvoice.Discounts = partDiscounts[i]
+		splitInvoice.DiscountTotal = discountTotal(partDiscounts[i])
+		splitInvoice.Subtotal = &breakdowns[i].Subtotal
+		splitInvoice.ServiceCharge = &breakdowns[i].ServiceCharge
+		splitInvoice.TaxLines = breakdowns[i].TaxLines
+		splitInvoice.TaxTotal = &breakdowns[i].TaxTotal
+		splitInvoice.GrandTotal = &breakdowns[i].GrandTotal
+
+		invoices = append(invoices, splitInvoice)
+	}
+
+	if splitTotal.Amount != orderTotal.Amount {
+		return nil, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: fmt.Sprintf("split invoices add up to %s instead of the order total %s", splitTotal, orderTotal),
+		}
+	}
+
+	return invoices, nil
+}
+
+// splitServiceCharge adds a share of the service charge to every part, in
+// proportion to the amount of its lines.
+func splitServiceCharge(serviceCharge invoiceLine, parts [][]invoiceLine) [][]invoiceLine {
+	total := 0.0
+	amounts := make([]float64, len(parts))
+	for i, part := range parts {
+		for _, line := range part {
+			amounts[i] += float64(line.amount().Amount)
+		}
+		total += amounts[i]
+	}
+
+	for i := range parts {
+		if amounts[i] == 0 {
+			continue
+		}
+
+		share := serviceCharge
+		share.Share = amounts[i] / total
+		parts[i] = append(parts[i], share)
+	}
+
+	return parts
+}
+
+// splitLineAmounts returns the lines stored on each split invoice. A line
+// shared between payers is split in cents so the shares add up to the line.
+func splitLineAmounts(lines []invoiceLine, parts [][]invoiceLine) [][]models.InvoiceLine {
+	type position struct {
+		part, index int
+		fraction    float64
+	}
+
+	partLines := make([][]models.InvoiceLine, len(parts))
+	positions := map[string][]position{}
+
+	for i, part := range parts {
+		partLines[i] = []models.InvoiceLine{}
+		for _, line := range part {
+			if line.ServiceCharge {
+				continue
+			}
+
+			positions[line.OrderItemID] = append(positions[line.OrderItemID], position{i, len(partLines[i]), line.fraction()})
+			partLines[i] = append(partLines[i], line.InvoiceLine)
+		}
+	}
+
+	for _, line := range lines {
+		amounts := []int64{}
+		discounts := []int64{}
+		for _, at := range positions[line.OrderItemID] {
+			amounts = append(amounts, line.Amount.Scale(at.fraction).Amount)
+			discounts = append(discounts, line.Discount.Scale(at.fraction).Amount)
+		}
+
+		amountShares := allocate(line.Amount, amounts)
+		discountShares := allocate(line.Discount, discounts)
+		for j, at := range positions[line.OrderItemID] {
+			partLines[at.part][at.index].Amount = amountShares[j]
+			partLines[at.part][at.index].Discount = discountShares[j]
+		}
+	}
+
+	return partLines
+}
+
+// splitDiscounts returns the discounts of each split invoice, splitting every
+// discount in cents in proportion to what it took off the lines of each part.
+func splitDiscounts(discounts []models.InvoiceDiscount, parts [][]invoiceLine) [][]models.InvoiceDiscount {
+	partDiscounts := make([][]models.InvoiceDiscount, len(parts))
+	for i := range parts {
+		partDiscounts[i] = []models.InvoiceDiscount{}
+	}
+
+	for _, discount := range discounts {
+		shares := make([]int64, len(parts))
+		for i, part := range parts {
+			for _, line := range part {
+				shares[i] += line.PromotionDiscounts[discount.PromotionID].Scale(line.fraction()).Amount
+			}
+		}
+
+		for i, amount := range allocate(discount.Amount, shares) {
+			if amount.Amount <= 0 {
+				continue
+			}
+
+			partDiscount := discount
+			partDiscount.Amount = amount
+			partDiscounts[i] = append(partDiscounts[i], partDiscount)
+		}
+	}
+
+	return partDiscounts
+}
+
+// splitLines returns the lines each payer is billed for. Every order item is
+// billed exactly once: an even split gives every payer a share of each line,
+// the other modes give each line to a single payer.
+func splitLines(lines []invoiceLine, split InvoiceSplit) ([][]invoiceLine, error) {
+	switch *split.Mode {
+	case SplitModeEven:
+		if split.Count == nil {
+			return nil, helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: "count is required to split evenly",
+			}
+		}
+
+		parts := make([][]invoiceLine, *split.Count)
+		for _, line := range lines {
+			share := line
+			share.Share = 1 / float64(*split.Count)
+
+			for i := range parts {
+				parts[i] = append(parts[i], share)
+			}
+		}
+
+		return parts, nil
+
+	case SplitModeSeat:
+		bySeat := map[int][]invoiceLine{}
+		for _, line := range lines {
+			if line.Seat == nil {
+				return nil, helpers.HttpError{
+					Code:    http.StatusBadRequest,
+					Message: fmt.Sprintf("order item %s has no seat", line.OrderItemID),
+				}
+			}
+			bySeat[*line.Seat] = append(bySeat[*line.Seat], line)
+		}
+
+		seats := []int{}
+		for seat := range bySeat {
+			seats = append(seats, seat)
+		}
+		sort.Ints(seats)
+
+		parts := [][]invoiceLine{}
+		for _, seat := range seats {
+			parts = append(parts, bySeat[seat])
+		}
+
+		return parts, nil
+
+	default:
+		if len(split.Payers) < 2 {
+			return nil, helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: "at least two payers are required to split by items",
+			}
+		}
+
+		byId := map[string]invoiceLine{}
+		for _, line := range lines {
+			byId[line.OrderItemID] = line
+		}
+
+		assigned := map[string]bool{}
+		parts := [][]invoiceLine{}
+
+		for _, orderItemIds := range split.Payers {
+			if len(orderItemIds) == 0 {
+				return nil, helpers.HttpError{
+					Code:    http.StatusBadRequest,
+					Message: "every payer must be assigned at least one order item",
+				}
+			}
+
+			part := []invoiceLine{}
+			for _, orderItemId := range orderItemIds {
+				line, ok := byId[orderItemId]
+				if !ok {
+					return nil, helpers.HttpError{
+						Code:    http.StatusBadRequest,
+						Message: fmt.Sprintf("order item %s is not part of the order", orderItemId),
+					}
+				}
+
+				if assigned[orderItemId] {
+					return nil, helpers.HttpError{
+						Code:    http.StatusBadRequest,
+						Message: fmt.Sprintf("order item %s is assigned to more than one payer", orderItemId),
+					}
+				}
+
+				assigned[orderItemId] = true
+				part = append(part, line)
+			}
+			parts = append(parts, part)
+		}
+
+		for _, line := range lines {
+			if !assigned[line.OrderItemID] {
+				return nil, helpers.HttpError{
+					Code:    http.StatusBadRequest,
+					Message: fmt.Sprintf("order item %s is not assigned to any payer", line.OrderItemID),
+				}
+			}
+		}
+
+		return parts, nil
+	}
+}
+
+// allocate splits the total in proportion to the weights, both in minor
+// units. Every weight gets its share rounded down and the units left over go
+// one each to the weights that lost the most, so the result always adds up to
+// the total. Weights are taken by their size, so a negative total is split the
+// same way as a positive one, and when there is nothing to weigh the total is
+// split evenly.
+func allocate(total models.Money, weights []int64) []models.Money {
+	amounts := make([]models.Money, len(weights))
+	for i := range amounts {
+		amounts[i] = models.Money{Currency: total.Currency}
+	}
+
+	if len(weights) == 0 || total.Amount == 0 {
+		return amounts
+	}
+
+	sizes := make([]uint64, len(weights))
+	var sum uint64
+	for i, weight := range weights {
+		sizes[i] = absUnits(weight)
+		sum += sizes[i]
+	}
+
+	if sum == 0 {
+		for i := range sizes {
+			sizes[i] = 1
+		}
+		sum = uint64(len(sizes))
+	}
+
+	units := absUnits(total.Amount)
+	shares := make([]uint64, len(sizes))
+	remainders := make([]uint64, len(sizes))
+	var allocated uint64
+
+	for i, size := range sizes {
+		// The share is at most the total, so the high word of the product is
+		// always less than the sum and the division cannot overflow.
+		hi, lo := bits.Mul64(units, size)
+		shares[i], remainders[i] = bits.Div64(hi, lo, sum)
+		allocated += shares[i]
+	}
+
+	byRemainder := make([]int, len(sizes))
+	for i := range byRemainder {
+		byRemainder[i] = i
+	}
+	sort.SliceStable(byRemainder, func(a, b int) bool {
+		return remainders[byRemainder[a]] > remainders[byRemainder[b]]
+	})
+
+	// Rounding down loses less than a unit per weight, so fewer units are
+	// left over than there are weights.
+	for _, i := range byRemainder[:units-allocated] {
+		shares[i]++
+	}
+
+	for i, share := range shares {
+		amounts[i].Amount = int64(share)
+		if total.Amount < 0 {
+			amounts[i].Amount = -amounts[i].Amount
+		}
+	}
+
+	return amounts
+}
+
+// absUnits returns the size of an amount in minor units.
+func absUnits(amount int64) uint64 {
+	if amount < 0 {
+		return uint64(-amount)
+	}
+
+	return uint64(amount)
+}
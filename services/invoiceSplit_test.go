@@ -0,0 +1,52 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+)
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name   string
+		total  models.Money
+		shares []int64
+		want   []int64
+	}{
+		{"even", models.Money{Amount: 1500, Currency: "USD"}, []int64{1, 1, 1}, []int64{500, 500, 500}},
+		{"even with a remainder", models.Money{Amount: 1507, Currency: "USD"}, []int64{1, 1, 1}, []int64{503, 502, 502}},
+		{"remainder to the largest loss", models.Money{Amount: 1000, Currency: "USD"}, []int64{1, 2}, []int64{333, 667}},
+		{"proportional", models.Money{Amount: 1349, Currency: "USD"}, []int64{1000, 349}, []int64{1000, 349}},
+		{"rounded shares", models.Money{Amount: 1000, Currency: "USD"}, []int64{333, 333, 333}, []int64{334, 333, 333}},
+		{"one share", models.Money{Amount: 999, Currency: "JPY"}, []int64{5}, []int64{999}},
+		{"zero share", models.Money{Amount: 1000, Currency: "USD"}, []int64{1, 0}, []int64{1000, 0}},
+		{"no shares to weigh", models.Money{Amount: 101, Currency: "USD"}, []int64{0, 0}, []int64{51, 50}},
+		{"nothing to allocate", models.Money{Currency: "USD"}, []int64{1, 2}, []int64{0, 0}},
+		{"negative total", models.Money{Amount: -1507, Currency: "USD"}, []int64{1, 1, 1}, []int64{-503, -502, -502}},
+		{"negative total and shares", models.Money{Amount: -1000, Currency: "USD"}, []int64{-1, -2}, []int64{-333, -667}},
+		{"negative total with no shares to weigh", models.Money{Amount: -101, Currency: "USD"}, []int64{0, 0}, []int64{-51, -50}},
+		{"large amounts", models.Money{Amount: 9_000_000_000_000_001, Currency: "USD"}, []int64{9_000_000_000_000_000, 9_000_000_000_000_000}, []int64{4_500_000_000_000_001, 4_500_000_000_000_000}},
+		{"no shares", models.Money{Amount: 100, Currency: "USD"}, []int64{}, []int64{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			amounts := allocate(test.total, test.shares)
+			if len(amounts) != len(test.want) {
+				t.Fatalf("got %d amounts, want %d", len(amounts), len(test.want))
+			}
+
+			var sum int64
+			for i, amount := range amounts {
+				if amount.Amount != test.want[i] || amount.Currency != test.total.Currency {
+					t.Errorf("amount %d: got %+v, want %d %s", i, amount, test.want[i], test.total.Currency)
+				}
+				sum += amount.Amount
+			}
+
+			if len(amounts) > 0 && sum != test.total.Amount {
+				t.Errorf("amounts add up to %d, want %d", sum, test.total.Amount)
+			}
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/helpers"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/EnesDemirtas/restaurant-management/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	KitchenEventTicketCreated = "ticket.created"
+	KitchenEventItemUpdated   = "item.updated"
+	KitchenEventItemVoided    = "item.voided"
+)
+
+// KitchenTicketItem lists the modifiers of the item by name, such as "no
+// onions", for the kitchen to read. A combo is named after the combo, and its
+// Components are the foods to prepare for it.
+type KitchenTicketItem struct {
+	OrderItemID   string   `json:"order_item_id"`
+	FoodID        *string  `json:"food_id"`
+	FoodName      *string  `json:"food_name"`
+	VariantName   *string  `json:"variant_name"`
+	ComboID       *string  `json:"combo_id"`
+	Components    []string `json:"components"`
+	Quantity      *int     `json:"quantity"`
+	Modifiers     []string `json:"modifiers"`
+	KitchenStatus string   `json:"kitchen_status"`
+}
+
+// KitchenTicket is what the kitchen display shows for one order: the items
+// still being worked on, together with the table they go to.
+type KitchenTicket struct {
+	OrderID     string              `json:"order_id"`
+	TableID     *string             `json:"table_id"`
+	TableNumber *int                `json:"table_number"`
+	Items       []KitchenTicketItem `json:"items"`
+}
+
+type KitchenEvent struct {
+	Type        string        `json:"type"`
+	OrderItemID string        `json:"order_item_id,omitempty"`
+	Ticket      KitchenTicket `json:"ticket"`
+}
+
+type KitchenStatusUpdate struct {
+	KitchenStatus *string `json:"kitchen_status" validate:"required,eq=PREPARING|eq=READY"`
+}
+
+// kitchenTransitions lists the kitchen statuses an item may be bumped to.
+var kitchenTransitions = map[string][]string{
+	models.KitchenStatusQueued:    {models.KitchenStatusPreparing, models.KitchenStatusReady},
+	models.KitchenStatusPreparing: {models.KitchenStatusReady},
+	models.KitchenStatusReady:     {},
+}
+
+// kitchenHub fans kitchen events out to every connected display. Slow
+// subscribers miss events rather than hold up the request that caused them.
+type kitchenHub struct {
+	mu          sync.Mutex
+	subscribers map[chan KitchenEvent]struct{}
+}
+
+var kitchen = &kitchenHub{subscribers: map[chan KitchenEvent]struct{}{}}
+
+func (h *kitchenHub) subscribe() chan KitchenEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	events := make(chan KitchenEvent, 32)
+	h.subscribers[events] = struct{}{}
+
+	return events
+}
+
+func (h *kitchenHub) unsubscribe(events chan KitchenEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.subscribers, events)
+}
+
+func (h *kitchenHub) publish(event KitchenEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for events := range h.subscribers {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// SubscribeKitchen returns a channel receiving every kitchen event from now on
+// and a function to call once the caller stops listening.
+func SubscribeKitchen() (<-chan KitchenEvent, func()) {
+	events := kitchen.subscribe()
+
+	return events, func() {
+		kitchen.unsubscribe(events)
+	}
+}
+
+func GetKitchenTickets(c *gin.Context) ([]KitchenTicket, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	pendingItems, err := store.OrderItems.FindByKitchenStatus(ctx, models.KitchenStatusQueued, models.KitchenStatusPreparing)
+	if err != nil {
+		return nil, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while listing kitchen tickets",
+		}
+	}
+
+	tickets := []KitchenTicket{}
+	seenOrders := map[string]bool{}
+
+	for _, orderItem := range pendingItems {
+		if seenOrders[orderItem.OrderID] {
+			continue
+		}
+		seenOrders[orderItem.OrderID] = true
+
+		ticket, err := kitchenTicket(ctx, orderItem.OrderID)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(ticket.Items) > 0 {
+			tickets = append(tickets, ticket)
+		}
+	}
+
+	return tickets, nil
+}
+
+func UpdateKitchenStatus(c *gin.Context) (KitchenTicket, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	var statusUpdate KitchenStatusUpdate
+
+	if err := c.BindJSON(&statusUpdate); err != nil {
+		return KitchenTicket{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	validationErr := validate.Struct(statusUpdate)
+	if validationErr != nil {
+		return KitchenTicket{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: validationErr.Error(),
+		}
+	}
+
+	orderItem, err := store.OrderItems.FindByID(ctx, c.Param("id"))
+	if err != nil {
+		return KitchenTicket{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "order item was not found",
+		}
+	}
+
+	from := kitchenStatus(orderItem)
+	to := *statusUpdate.KitchenStatus
+	if !canBumpKitchenItem(from, to) {
+		return KitchenTicket{}, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: fmt.Sprintf("order item cannot move from %s to %s", from, to),
+		}
+	}
+
+	updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	err = store.OrderItems.UpdateKitchenStatus(ctx, orderItem.OrderItemID, from, to, updatedAt)
+	if errors.Is(err, repositories.ErrConflict) {
+		return KitchenTicket{}, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: "order item was bumped by another request, please retry",
+		}
+	}
+
+	if err != nil {
+		return KitchenTicket{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "order item update failed",
+		}
+	}
+
+	ticket, err := kitchenTicket(ctx, orderItem.OrderID)
+	if err != nil {
+		return KitchenTicket{}, err
+	}
+
+	kitchen.publish(KitchenEvent{Type: KitchenEventItemUpdated, OrderItemID: orderItem.OrderItemID, Ticket: ticket})
+
+	return ticket, nil
+}
+
+// sendToKitchen puts the order on the kitchen display.
+func sendToKitchen(ctx context.Context, orderId string) error {
+	ticket, err := kitchenTicket(ctx, orderId)
+	if err != nil {
+		return err
+	}
+
+	kitchen.publish(KitchenEvent{Type: KitchenEventTicketCreated, Ticket: ticket})
+
+	return nil
+}
+
+func kitchenTicket(ctx context.Context, orderId string) (KitchenTicket, error) {
+	order, err := store.Orders.FindByID(ctx, orderId)
+	if err != nil {
+		return KitchenTicket{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "Order was not found",
+		}
+	}
+
+	orderItems, err := store.OrderItems.FindByOrder(ctx, orderId)
+	if err != nil {
+		return KitchenTicket{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		}
+	}
+
+	voided, err := voidedOrderItems(ctx, orderId)
+	if err != nil {
+		return KitchenTicket{}, err
+	}
+
+	ticket := KitchenTicket{OrderID: order.OrderID, TableID: order.TableID, Items: []KitchenTicketItem{}}
+
+	if order.TableID != nil {
+		if table, err := store.Tables.FindByID(ctx, *order.TableID); err == nil {
+			ticket.TableNumber = table.TableNumber
+		}
+	}
+
+	for _, orderItem := range orderItems {
+		if voided[orderItem.OrderItemID] || kitchenStatus(orderItem) == models.KitchenStatusReady {
+			continue
+		}
+
+		item := KitchenTicketItem{
+			OrderItemID:   orderItem.OrderItemID,
+			FoodID:        orderItem.FoodID,
+			Quantity:      orderItem.Quantity,
+			ComboID:       orderItem.ComboID,
+			Components:    []string{},
+			Modifiers:     []string{},
+			KitchenStatus: kitchenStatus(orderItem),
+		}
+
+		if orderItem.ComboID != nil {
+			if combo, err := store.Combos.FindByID(ctx, *orderItem.ComboID); err == nil {
+				item.FoodName = combo.Name
+			}
+		}
+
+		for _, component := range orderItem.ComboComponents {
+			name := component.FoodName
+			if component.VariantName != "" {
+				name += " " + component.VariantName
+			}
+			item.Components = append(item.Components, name)
+		}
+
+		for _, modifier := range orderItem.Modifiers {
+			item.Modifiers = append(item.Modifiers, modifier.Name)
+		}
+
+		if orderItem.FoodID != nil {
+			if food, err := store.Foods.FindByID(ctx, *orderItem.FoodID); err == nil {
+				item.FoodName = food.Name
+				if orderItem.VariantID != nil {
+					if variant, ok := findVariant(food, *orderItem.VariantID); ok {
+						item.VariantName = variant.Name
+					}
+				}
+			}
+		}
+
+		ticket.Items = append(ticket.Items, item)
+	}
+
+	return ticket, nil
+}
+
+// kitchenStatus returns the kitchen status of the item. Items ordered before
+// the kitchen display existed have none and are considered ready.
+func kitchenStatus(orderItem models.OrderItem) string {
+	if orderItem.KitchenStatus == nil {
+		return models.KitchenStatusReady
+	}
+
+	return *orderItem.KitchenStatus
+}
+
+func canBumpKitchenItem(from, to string) bool {
+	for _, next := range kitchenTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+)
+
+func TestCanBumpKitchenItem(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want bool
+	}{
+		{models.KitchenStatusQueued, models.KitchenStatusPreparing, true},
+		{models.KitchenStatusQueued, models.KitchenStatusReady, true},
+		{models.KitchenStatusPreparing, models.KitchenStatusReady, true},
+		{models.KitchenStatusPreparing, models.KitchenStatusQueued, false},
+		{models.KitchenStatusReady, models.KitchenStatusPreparing, false},
+		{models.KitchenStatusReady, models.KitchenStatusReady, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.from+" to "+test.to, func(t *testing.T) {
+			if got := canBumpKitchenItem(test.from, test.to); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestKitchenStatus(t *testing.T) {
+	preparing := models.KitchenStatusPreparing
+
+	tests := []struct {
+		name      string
+		orderItem models.OrderItem
+		want      string
+	}{
+		{"set", models.OrderItem{KitchenStatus: &preparing}, models.KitchenStatusPreparing},
+		{"ordered before the kitchen display", models.OrderItem{}, models.KitchenStatusReady},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := kitchenStatus(test.orderItem); got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,526 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/helpers"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/EnesDemirtas/restaurant-management/repositories"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReversalRequest is a refund or a void. Refunds take back the whole paid
+// amount unless OrderItemIDs lists the lines to refund. Staff other than
+// managers must have a manager approve it with their credentials.
+type ReversalRequest struct {
+	ReasonCode   *string          `json:"reason_code" validate:"required,eq=CUSTOMER_COMPLAINT|eq=WRONG_ITEM|eq=QUALITY_ISSUE|eq=PRICING_ERROR|eq=DUPLICATE_CHARGE|eq=OTHER"`
+	Note         *string          `json:"note" validate:"omitempty,max=500"`
+	Method       *string          `json:"method" validate:"omitempty,eq=CASH|eq=CARD|eq=GIFT_CARD"`
+	OrderItemIDs []string         `json:"order_item_ids"`
+	Approval     *ManagerApproval `json:"approval"`
+}
+
+type ManagerApproval struct {
+	Email    *string `json:"email" validate:"required,email"`
+	Password *string `json:"password" validate:"required"`
+}
+
+// ledgerSummary adds up the ledger entries of an invoice.
+type ledgerSummary struct {
+	Entries       []models.LedgerEntry
+	Refunded      models.Money
+	RefundedItems map[string]bool
+	Voided        bool
+}
+
+func RefundInvoice(c *gin.Context) ([]models.LedgerEntry, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	request, err := bindReversal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice, err := store.Invoices.FindByID(ctx, c.Param("id"))
+	if err != nil {
+		return nil, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "invoice was not found",
+		}
+	}
+
+	if err := checkDayOpen(ctx, invoice.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	authorizedBy, err := authorizeReversal(ctx, c, request.Approval)
+	if err != nil {
+		return nil, err
+	}
+
+	ledger, err := invoiceLedger(ctx, invoice.InvoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Invoices refunded before refunds were counted on them fall back on
+	// their ledger.
+	refunded := ledger.Refunded
+	if invoice.AmountRefunded != nil {
+		refunded = *invoice.AmountRefunded
+	}
+	for _, orderItemId := range invoice.RefundedItems {
+		ledger.RefundedItems[orderItemId] = true
+	}
+
+	refundable := paidAmount(invoice).Sub(refunded)
+	if refundable.Amount <= 0 {
+		return nil, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: "invoice has nothing left to refund",
+		}
+	}
+
+	method := request.Method
+	if method == nil && invoice.PaymentMethod != nil && *invoice.PaymentMethod != models.PaymentMethodMixed && *invoice.PaymentMethod != "" {
+		method = invoice.PaymentMethod
+	}
+
+	if method == nil {
+		return nil, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "method is required to refund an invoice paid with several methods",
+		}
+	}
+
+	entry := newLedgerEntry(c, models.LedgerEntryRefund, invoice.OrderID, request, authorizedBy)
+	entry.InvoiceID = &invoice.InvoiceID
+	entry.Method = method
+
+	var entries []models.LedgerEntry
+	total := refundable
+
+	if len(request.OrderItemIDs) == 0 {
+		entry.Amount = refundable
+		entries = append(entries, entry)
+	} else {
+		total = models.Money{Currency: refundable.Currency}
+
+		for _, orderItemId := range request.OrderItemIDs {
+			line, ok := invoiceLineFor(invoice, orderItemId)
+			if !ok {
+				return nil, helpers.HttpError{
+					Code:    http.StatusBadRequest,
+					Message: fmt.Sprintf("order item %s is not on the invoice", orderItemId),
+				}
+			}
+
+			if ledger.RefundedItems[orderItemId] {
+				return nil, helpers.HttpError{
+					Code:    http.StatusConflict,
+					Message: fmt.Sprintf("order item %s was already refunded", orderItemId),
+				}
+			}
+			ledger.RefundedItems[orderItemId] = true
+
+			lineEntry := entry
+			lineEntry.ID = primitive.NewObjectID()
+			lineEntry.LedgerEntryID = lineEntry.ID.Hex()
+			lineEntry.OrderItemID = &line.OrderItemID
+			lineEntry.Amount = lineTotal(invoice, line)
+			total = total.Add(lineEntry.Amount)
+
+			entries = append(entries, lineEntry)
+		}
+
+		if total.Amount > refundable.Amount {
+			return nil, helpers.HttpError{
+				Code:    http.StatusConflict,
+				Message: fmt.Sprintf("refund of %s is more than the %s left to refund", total, refundable),
+			}
+		}
+	}
+
+	// The refund is claimed on the invoice before any money is given back, so
+	// that a concurrent refund read before this one fails instead of
+	// refunding the same amount again.
+	refundedItems := append(append([]string{}, invoice.RefundedItems...), request.OrderItemIDs...)
+	reserved := refunded.Add(total)
+
+	err = store.Invoices.UpdateRefunded(ctx, invoice.InvoiceID, invoice.AmountRefunded, reserved, refundedItems)
+	if errors.Is(err, repositories.ErrConflict) {
+		return nil, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: "another refund was made on the invoice, please retry",
+		}
+	}
+
+	if err != nil {
+		return nil, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "refund was not recorded",
+		}
+	}
+
+	for i, entry := range entries {
+		if err := refundCard(ctx, invoice, append(ledger.Entries, entries[:i]...), &entry); err != nil {
+			releaseRefund(ctx, invoice.InvoiceID, reserved, entries[i:], refundedItems)
+			return nil, err
+		}
+
+		numbered, err := store.Ledger.InsertNumbered(ctx, entry, fiscalSeries(models.FiscalSeriesCreditNote, entry.CreatedAt))
+		if err != nil {
+			// Money given back on the card stays claimed, so it is not
+			// refunded twice.
+			if entry.TransactionID != nil {
+				i++
+			}
+			releaseRefund(ctx, invoice.InvoiceID, reserved, entries[i:], refundedItems)
+			return nil, helpers.HttpError{
+				Code:    http.StatusInternalServerError,
+				Message: "refund was not recorded",
+			}
+		}
+		entries[i] = numbered
+	}
+
+	return entries, nil
+}
+
+// releaseRefund gives back the claim on the invoice of the entries that were
+// not refunded. It gives up if another refund was claimed meanwhile, which
+// only ever leaves less to refund.
+func releaseRefund(ctx context.Context, invoiceId string, reserved models.Money, entries []models.LedgerEntry, refundedItems []string) {
+	if len(entries) == 0 {
+		return
+	}
+
+	released := map[string]bool{}
+	amountRefunded := reserved
+	for _, entry := range entries {
+		amountRefunded = amountRefunded.Sub(entry.Amount)
+		if entry.OrderItemID != nil {
+			released[*entry.OrderItemID] = true
+		}
+	}
+
+	remaining := []string{}
+	for _, orderItemId := range refundedItems {
+		if !released[orderItemId] {
+			remaining = append(remaining, orderItemId)
+		}
+	}
+
+	store.Invoices.UpdateRefunded(ctx, invoiceId, &reserved, amountRefunded, remaining)
+}
+
+func VoidInvoice(c *gin.Context) (models.LedgerEntry, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	request, err := bindReversal(c)
+	if err != nil {
+		return models.LedgerEntry{}, err
+	}
+
+	invoice, err := store.Invoices.FindByID(ctx, c.Param("id"))
+	if err != nil {
+		return models.LedgerEntry{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "invoice was not found",
+		}
+	}
+
+	if err := checkDayOpen(ctx, invoice.CreatedAt); err != nil {
+		return models.LedgerEntry{}, err
+	}
+
+	authorizedBy, err := authorizeReversal(ctx, c, request.Approval)
+	if err != nil {
+		return models.LedgerEntry{}, err
+	}
+
+	ledger, err := invoiceLedger(ctx, invoice.InvoiceID)
+	if err != nil {
+		return models.LedgerEntry{}, err
+	}
+
+	if ledger.Voided {
+		return models.LedgerEntry{}, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: "invoice is already voided",
+		}
+	}
+
+	if paidAmount(invoice).Amount > 0 {
+		return models.LedgerEntry{}, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: "invoice has payments, refund it instead",
+		}
+	}
+
+	entry := newLedgerEntry(c, models.LedgerEntryVoid, invoice.OrderID, request, authorizedBy)
+	entry.InvoiceID = &invoice.InvoiceID
+	if invoice.GrandTotal != nil {
+		entry.Amount = *invoice.GrandTotal
+	}
+
+	entry, err = store.Ledger.InsertNumbered(ctx, entry, fiscalSeries(models.FiscalSeriesCreditNote, entry.CreatedAt))
+	if err != nil {
+		return models.LedgerEntry{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "void was not recorded",
+		}
+	}
+
+	if err := settleOrder(ctx, invoice.OrderID); err != nil {
+		return models.LedgerEntry{}, err
+	}
+
+	return entry, nil
+}
+
+func VoidOrderItem(c *gin.Context) (models.LedgerEntry, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	request, err := bindReversal(c)
+	if err != nil {
+		return models.LedgerEntry{}, err
+	}
+
+	orderItem, err := store.OrderItems.FindByID(ctx, c.Param("id"))
+	if err != nil {
+		return models.LedgerEntry{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "order item was not found",
+		}
+	}
+
+	order, err := store.Orders.FindByID(ctx, orderItem.OrderID)
+	if err != nil {
+		return models.LedgerEntry{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "Order was not found",
+		}
+	}
+
+	// Once billed, items are taken back by refunding them on the invoice.
+	switch orderStatus(order) {
+	case models.OrderStatusOpen, models.OrderStatusSentToKitchen, models.OrderStatusServed:
+	default:
+		return models.LedgerEntry{}, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: fmt.Sprintf("order is %s, refund the item instead", orderStatus(order)),
+		}
+	}
+
+	authorizedBy, err := authorizeReversal(ctx, c, request.Approval)
+	if err != nil {
+		return models.LedgerEntry{}, err
+	}
+
+	voided, err := voidedOrderItems(ctx, order.OrderID)
+	if err != nil {
+		return models.LedgerEntry{}, err
+	}
+
+	if voided[orderItem.OrderItemID] {
+		return models.LedgerEntry{}, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: "order item is already voided",
+		}
+	}
+
+	entry := newLedgerEntry(c, models.LedgerEntryVoid, order.OrderID, request, authorizedBy)
+	entry.OrderItemID = &orderItem.OrderItemID
+	if orderItem.Quantity != nil && orderItem.UnitPrice != nil {
+		entry.Amount = orderItem.UnitPrice.Mul(*orderItem.Quantity)
+	}
+
+	if err := store.Ledger.Insert(ctx, entry); err != nil {
+		return models.LedgerEntry{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "void was not recorded",
+		}
+	}
+
+	ticket, err := kitchenTicket(ctx, order.OrderID)
+	if err != nil {
+		return models.LedgerEntry{}, err
+	}
+
+	kitchen.publish(KitchenEvent{Type: KitchenEventItemVoided, OrderItemID: orderItem.OrderItemID, Ticket: ticket})
+
+	return entry, nil
+}
+
+func bindReversal(c *gin.Context) (ReversalRequest, error) {
+	var request ReversalRequest
+
+	if err := c.BindJSON(&request); err != nil {
+		return ReversalRequest{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	validationErr := validate.Struct(request)
+	if validationErr != nil {
+		return ReversalRequest{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: validationErr.Error(),
+		}
+	}
+
+	return request, nil
+}
+
+// authorizeReversal returns the id of the manager authorizing a refund or a
+// void: the signed in user if they are a manager, otherwise the manager whose
+// credentials were given as approval.
+func authorizeReversal(ctx context.Context, c *gin.Context, approval *ManagerApproval) (string, error) {
+	if isManagerRole(c.GetString("role")) {
+		return c.GetString("uid"), nil
+	}
+
+	if approval == nil {
+		return "", helpers.HttpError{
+			Code:    http.StatusForbidden,
+			Message: "a manager must approve this",
+		}
+	}
+
+	manager, err := store.Users.FindByEmail(ctx, *approval.Email)
+	if err != nil || manager.Password == nil {
+		return "", helpers.HttpError{
+			Code:    http.StatusForbidden,
+			Message: "manager approval is invalid",
+		}
+	}
+
+	passwordIsValid, _ := helpers.VerifyPassword(*approval.Password, *manager.Password)
+	if !passwordIsValid || !isManagerRole(userRole(manager)) {
+		return "", helpers.HttpError{
+			Code:    http.StatusForbidden,
+			Message: "manager approval is invalid",
+		}
+	}
+
+	return manager.UserID, nil
+}
+
+func isManagerRole(role string) bool {
+	return role == models.RoleAdmin || role == models.RoleManager
+}
+
+func newLedgerEntry(c *gin.Context, entryType, orderId string, request ReversalRequest, authorizedBy string) models.LedgerEntry {
+	entry := models.LedgerEntry{
+		Type:         entryType,
+		OrderID:      orderId,
+		ReasonCode:   *request.ReasonCode,
+		Note:         request.Note,
+		RequestedBy:  c.GetString("uid"),
+		AuthorizedBy: authorizedBy,
+	}
+
+	entry.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	entry.ID = primitive.NewObjectID()
+	entry.LedgerEntryID = entry.ID.Hex()
+
+	return entry
+}
+
+func invoiceLedger(ctx context.Context, invoiceId string) (ledgerSummary, error) {
+	entries, err := store.Ledger.FindByInvoice(ctx, invoiceId)
+	if err != nil {
+		return ledgerSummary{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while listing ledger entries",
+		}
+	}
+
+	ledger := ledgerSummary{Entries: entries, RefundedItems: map[string]bool{}}
+	if ledger.Entries == nil {
+		ledger.Entries = []models.LedgerEntry{}
+	}
+
+	for _, entry := range entries {
+		switch entry.Type {
+		case models.LedgerEntryRefund:
+			ledger.Refunded = ledger.Refunded.Add(entry.Amount)
+			if entry.OrderItemID != nil {
+				ledger.RefundedItems[*entry.OrderItemID] = true
+			}
+		case models.LedgerEntryVoid:
+			ledger.Voided = true
+		}
+	}
+
+	return ledger, nil
+}
+
+// voidedOrderItems returns the ids of the voided items of an order.
+func voidedOrderItems(ctx context.Context, orderId string) (map[string]bool, error) {
+	entries, err := store.Ledger.FindByOrder(ctx, orderId)
+	if err != nil {
+		return nil, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while listing ledger entries",
+		}
+	}
+
+	voided := map[string]bool{}
+	for _, entry := range entries {
+		if entry.Type == models.LedgerEntryVoid && entry.OrderItemID != nil {
+			voided[*entry.OrderItemID] = true
+		}
+	}
+
+	return voided, nil
+}
+
+// paidAmount returns what was paid for the invoice. Invoices marked as paid
+// without recording payments count as paid in full.
+func paidAmount(invoice models.Invoice) models.Money {
+	if invoice.AmountPaid != nil {
+		return *invoice.AmountPaid
+	}
+
+	if isPaid(invoice) && invoice.GrandTotal != nil {
+		return *invoice.GrandTotal
+	}
+
+	return models.Money{Currency: models.DefaultCurrency}
+}
+
+func invoiceLineFor(invoice models.Invoice, orderItemId string) (models.InvoiceLine, bool) {
+	for _, line := range invoice.Lines {
+		if line.OrderItemID == orderItemId {
+			return line, true
+		}
+	}
+
+	return models.InvoiceLine{}, false
+}
+
+// lineTotal returns the share of the invoice grand total, taxes included,
+// charged for the line.
+func lineTotal(invoice models.Invoice, line models.InvoiceLine) models.Money {
+	linesTotal := models.Money{}
+	for _, invoiceLine := range invoice.Lines {
+		linesTotal = linesTotal.Add(invoiceLine.Amount)
+	}
+
+	if linesTotal.Amount == 0 || invoice.GrandTotal == nil {
+		return models.Money{Currency: line.Amount.Currency}
+	}
+
+	return invoice.GrandTotal.Scale(float64(line.Amount.Amount) / float64(linesTotal.Amount))
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EnesDemirtas/restaurant-management/helpers"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/EnesDemirtas/restaurant-management/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+// newTestContext returns the context of a request made by a signed in user
+// with the role, with body sent as JSON.
+func newTestContext(role string, params gin.Params, body any) *gin.Context {
+	data, _ := json.Marshal(body)
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = params
+	c.Set("uid", "u-"+role)
+	c.Set("role", role)
+
+	return c
+}
+
+// httpStatus returns the status code of the error a service returned, or 200
+// if it returned none.
+func httpStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	var httpError helpers.HttpError
+	if errors.As(err, &httpError) {
+		return httpError.Code
+	}
+
+	return 0
+}
+
+func newBilledInvoice(t *testing.T, paid bool) models.Invoice {
+	ctx := context.Background()
+	status := models.OrderStatusBilled
+	if err := store.Orders.Insert(ctx, models.Order{OrderID: "o1", Status: &status}); err != nil {
+		t.Fatal(err)
+	}
+
+	grandTotal := money(30)
+	paymentStatus := models.PaymentStatusPending
+	invoice := models.Invoice{
+		InvoiceID:     "i1",
+		OrderID:       "o1",
+		PaymentStatus: &paymentStatus,
+		GrandTotal:    &grandTotal,
+		Lines: []models.InvoiceLine{
+			{OrderItemID: "a", Amount: money(20)},
+			{OrderItemID: "b", Amount: money(10)},
+		},
+		Payments: []models.InvoicePayment{},
+	}
+
+	if paid {
+		method := models.PaymentMethodCash
+		paymentStatus = models.PaymentStatusPaid
+		invoice.AmountPaid = &grandTotal
+		invoice.PaymentMethod = &method
+		invoice.Payments = []models.InvoicePayment{{PaymentID: "p1", Method: method, Amount: grandTotal}}
+	}
+
+	if err := store.Invoices.Insert(ctx, invoice); err != nil {
+		t.Fatal(err)
+	}
+
+	return invoice
+}
+
+func TestRefundInvoice(t *testing.T) {
+	UseStore(repositories.NewMemoryStore())
+	newBilledInvoice(t, true)
+
+	tests := []struct {
+		name       string
+		role       string
+		request    map[string]any
+		wantStatus int
+		want       models.Money
+	}{
+		{"without a reason", models.RoleManager, map[string]any{}, http.StatusBadRequest, money(0)},
+		{"without approval", models.RoleCashier, map[string]any{"reason_code": "WRONG_ITEM"}, http.StatusForbidden, money(0)},
+		{"a line", models.RoleManager, map[string]any{"reason_code": "WRONG_ITEM", "order_item_ids": []string{"a"}}, http.StatusOK, money(20)},
+		{"the same line again", models.RoleManager, map[string]any{"reason_code": "WRONG_ITEM", "order_item_ids": []string{"a"}}, http.StatusConflict, money(20)},
+		{"a line not on the invoice", models.RoleManager, map[string]any{"reason_code": "WRONG_ITEM", "order_item_ids": []string{"c"}}, http.StatusBadRequest, money(20)},
+		{"the rest", models.RoleAdmin, map[string]any{"reason_code": "QUALITY_ISSUE"}, http.StatusOK, money(30)},
+		{"nothing left", models.RoleAdmin, map[string]any{"reason_code": "QUALITY_ISSUE"}, http.StatusConflict, money(30)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newTestContext(test.role, gin.Params{{Key: "id", Value: "i1"}}, test.request)
+
+			entries, err := RefundInvoice(c)
+			if status := httpStatus(err); status != test.wantStatus {
+				t.Fatalf("got status %d (%v), want %d", status, err, test.wantStatus)
+			}
+
+			for _, entry := range entries {
+				if entry.Type != models.LedgerEntryRefund || entry.AuthorizedBy != "u-"+test.role || entry.FiscalNumber == nil {
+					t.Errorf("got entry %+v, want a numbered refund authorized by the %s", entry, test.role)
+				}
+			}
+
+			stored, err := store.Invoices.FindByID(context.Background(), "i1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			refunded := money(0)
+			if stored.AmountRefunded != nil {
+				refunded = *stored.AmountRefunded
+			}
+			if refunded != test.want {
+				t.Errorf("got %s refunded, want %s", refunded, test.want)
+			}
+		})
+	}
+}
+
+func TestVoidInvoice(t *testing.T) {
+	tests := []struct {
+		name       string
+		paid       bool
+		voids      int
+		wantStatus int
+		wantOrder  string
+	}{
+		{"unpaid invoice", false, 1, http.StatusOK, models.OrderStatusVoided},
+		{"voided twice", false, 2, http.StatusConflict, models.OrderStatusVoided},
+		{"paid invoice", true, 1, http.StatusConflict, models.OrderStatusBilled},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			UseStore(repositories.NewMemoryStore())
+			newBilledInvoice(t, test.paid)
+
+			var err error
+			for i := 0; i < test.voids; i++ {
+				c := newTestContext(models.RoleManager, gin.Params{{Key: "id", Value: "i1"}}, map[string]any{"reason_code": "DUPLICATE_CHARGE"})
+				_, err = VoidInvoice(c)
+			}
+
+			if status := httpStatus(err); status != test.wantStatus {
+				t.Fatalf("got status %d (%v), want %d", status, err, test.wantStatus)
+			}
+
+			order, err := store.Orders.FindByID(context.Background(), "o1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if orderStatus(order) != test.wantOrder {
+				t.Errorf("got order %s, want %s", orderStatus(order), test.wantOrder)
+			}
+		})
+	}
+}
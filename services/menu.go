@@ -2,26 +2,22 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
-	"github.com/EnesDemirtas/restaurant-management/database"
 	"github.com/EnesDemirtas/restaurant-management/helpers"
 	"github.com/EnesDemirtas/restaurant-management/models"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var menuCollection *mongo.Collection = database.OpenCollection(database.Client, "menu")
-
-func GetMenus(c *gin.Context) ([]bson.M, error) {
+func GetMenus(c *gin.Context) ([]models.Menu, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
-	result, err := menuCollection.Find(context.TODO(), bson.M{})
+	allMenus, err := store.Menus.FindAll(ctx)
 	if err != nil {
 		return nil, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
@@ -29,25 +25,70 @@ func GetMenus(c *gin.Context) ([]bson.M, error) {
 		}
 	}
 
-	var allMenus []bson.M
-	if err = result.All(ctx, &allMenus); err != nil {
+	return allMenus, nil
+}
+
+// ActiveMenu is a menu served at the time asked for, along with its foods.
+type ActiveMenu struct {
+	models.Menu
+	Foods []models.Food `json:"foods"`
+}
+
+// GetActiveMenus lists the menus served at the time of the at query, now
+// unless set, along with their foods.
+func GetActiveMenus(c *gin.Context) ([]ActiveMenu, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	at := time.Now()
+	if query := c.Query("at"); query != "" {
+		var err error
+		at, err = time.Parse(time.RFC3339, query)
+		if err != nil {
+			return nil, helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: "at must be a time such as 2024-03-01T08:30:00Z",
+			}
+		}
+	}
+
+	allMenus, err := store.Menus.FindAll(ctx)
+	if err != nil {
 		return nil, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
+			Message: "error occured while listing the menu items",
 		}
 	}
 
-	return allMenus, nil
+	location := restaurantLocation()
+	activeMenus := []ActiveMenu{}
+
+	for _, menu := range allMenus {
+		if !menuServedAt(menu, at, location) {
+			continue
+		}
+
+		foods, err := store.Foods.FindByMenu(ctx, menu.MenuID)
+		if err != nil {
+			return nil, helpers.HttpError{
+				Code:    http.StatusInternalServerError,
+				Message: "error occured while listing food items",
+			}
+		}
+
+		activeMenus = append(activeMenus, ActiveMenu{Menu: menu, Foods: foods})
+	}
+
+	return activeMenus, nil
 }
 
 func GetMenu(c *gin.Context) (models.Menu, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
-	menuId := c.Param("menu_id")
-	var menu models.Menu
+	menuId := c.Param("id")
 
-	err := menuCollection.FindOne(ctx, bson.M{"menu_id": menuId}).Decode(&menu)
+	menu, err := store.Menus.FindByID(ctx, menuId)
 	if err != nil {
 		return models.Menu{}, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
@@ -58,13 +99,13 @@ func GetMenu(c *gin.Context) (models.Menu, error) {
 	return menu, nil
 }
 
-func CreateMenu(c *gin.Context) (*mongo.InsertOneResult, error) {
+func CreateMenu(c *gin.Context) (models.Menu, error) {
 	var menu models.Menu
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
 	if err := c.BindJSON(&menu); err != nil {
-		return nil, helpers.HttpError{
+		return models.Menu{}, helpers.HttpError{
 			Code:    http.StatusBadRequest,
 			Message: err.Error(),
 		}
@@ -72,94 +113,199 @@ func CreateMenu(c *gin.Context) (*mongo.InsertOneResult, error) {
 
 	validationErr := validate.Struct(menu)
 	if validationErr != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
-		return nil, helpers.HttpError{
+		return models.Menu{}, helpers.HttpError{
 			Code:    http.StatusBadRequest,
 			Message: validationErr.Error(),
 		}
 	}
 
+	if err := validateMenuDates(menu); err != nil {
+		return models.Menu{}, err
+	}
+
 	menu.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 	menu.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 	menu.ID = primitive.NewObjectID()
 	menu.MenuID = menu.ID.Hex()
 
-	result, insertErr := menuCollection.InsertOne(ctx, menu)
+	insertErr := store.Menus.Insert(ctx, menu)
 	if insertErr != nil {
-		return nil, helpers.HttpError{
+		return models.Menu{}, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
 			Message: "Menu item was not created",
 		}
 	}
 
-	return result, nil
+	return menu, nil
 }
 
-func UpdateMenu(c *gin.Context) (*mongo.UpdateResult, error) {
+func UpdateMenu(c *gin.Context) (models.Menu, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
 	var menu models.Menu
 
 	if err := c.BindJSON(&menu); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return nil, helpers.HttpError{
+		return models.Menu{}, helpers.HttpError{
 			Code:    http.StatusBadRequest,
 			Message: err.Error(),
 		}
 	}
 
-	menuId := c.Param("menu_id")
-	filter := bson.M{"menu_id": menuId}
-
-	var updateObj primitive.D
+	menuId := c.Param("id")
 
-	if menu.StartDate == nil || menu.EndDate == nil {
-		return nil, helpers.HttpError{
-			Code:    http.StatusBadRequest,
-			Message: "Please type the start and end date",
+	foundMenu, err := store.Menus.FindByID(ctx, menuId)
+	if err != nil {
+		return models.Menu{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "menu was not found",
 		}
 	}
 
-	if !inTimeSpan(*menu.StartDate, *menu.EndDate, time.Now()) {
-		return nil, helpers.HttpError{
-			Code:    http.StatusInternalServerError,
-			Message: "kindly retype the time",
-		}
+	if menu.StartDate != nil {
+		foundMenu.StartDate = menu.StartDate
 	}
 
-	updateObj = append(updateObj, primitive.E{Key: "start_date", Value: menu.StartDate})
-	updateObj = append(updateObj, primitive.E{Key: "end_date", Value: menu.EndDate})
+	if menu.EndDate != nil {
+		foundMenu.EndDate = menu.EndDate
+	}
+
+	if menu.Windows != nil {
+		foundMenu.Windows = menu.Windows
+	}
 
 	if menu.Name != "" {
-		updateObj = append(updateObj, primitive.E{Key: "name", Value: menu.Name})
+		foundMenu.Name = menu.Name
 	}
 
 	if menu.Category != "" {
-		updateObj = append(updateObj, primitive.E{Key: "category", Value: menu.Category})
+		foundMenu.Category = menu.Category
 	}
 
-	menu.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-	updateObj = append(updateObj, primitive.E{Key: "updated_at", Value: menu.UpdatedAt})
-
-	upsert := true
+	validationErr := validate.Struct(foundMenu)
+	if validationErr != nil {
+		return models.Menu{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: validationErr.Error(),
+		}
+	}
 
-	opt := options.UpdateOptions{
-		Upsert: &upsert,
+	if err := validateMenuDates(foundMenu); err != nil {
+		return models.Menu{}, err
 	}
 
-	result, err := menuCollection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: updateObj}}, &opt)
+	foundMenu.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
+	err = store.Menus.Update(ctx, foundMenu)
 	if err != nil {
-		return nil, helpers.HttpError{
+		return models.Menu{}, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
 			Message: "Menu update failed",
 		}
 	}
 
-	return result, nil
+	return foundMenu, nil
+}
+
+// validateMenuDates checks that a menu ends after it starts.
+func validateMenuDates(menu models.Menu) error {
+	if menu.StartDate != nil && menu.EndDate != nil && !menu.EndDate.After(*menu.StartDate) {
+		return helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "end date must be after the start date",
+		}
+	}
+
+	return nil
+}
+
+// checkFoodServed returns an error if the menu of the food is not served at
+// the time.
+func checkFoodServed(ctx context.Context, food models.Food, at time.Time) error {
+	if food.MenuID == nil {
+		return nil
+	}
+
+	menu, err := store.Menus.FindByID(ctx, *food.MenuID)
+	if err != nil {
+		return helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "menu of the food was not found",
+		}
+	}
+
+	if !menuServedAt(menu, at, restaurantLocation()) {
+		return helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: fmt.Sprintf("%s is on the %s menu, which is not served now", *food.Name, menu.Name),
+		}
+	}
+
+	return nil
 }
 
-func inTimeSpan(start, end, check time.Time) bool {
-	return start.Before(check) && end.After(start)
+// menuServedAt reports whether the menu is served at the time, which is
+// between its start and end dates, when set, and within one of its windows in
+// the timezone of the restaurant, when it has any.
+func menuServedAt(menu models.Menu, at time.Time, location *time.Location) bool {
+	if !inTimeSpan(menu.StartDate, menu.EndDate, at) {
+		return false
+	}
+
+	if len(menu.Windows) == 0 {
+		return true
+	}
+
+	for _, window := range menu.Windows {
+		if inWindow(window, at.In(location)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inTimeSpan reports whether check is from start on and before end, either of
+// which may be unset.
+func inTimeSpan(start, end *time.Time, check time.Time) bool {
+	return (start == nil || !check.Before(*start)) && (end == nil || check.Before(*end))
+}
+
+// inWindow reports whether the local time falls in the window. A window
+// ending at or before it starts runs past midnight, on the day after one of
+// its days.
+func inWindow(window models.MenuWindow, local time.Time) bool {
+	from, errFrom := time.Parse("15:04", window.From)
+	to, errTo := time.Parse("15:04", window.To)
+	if errFrom != nil || errTo != nil {
+		return false
+	}
+
+	minute := local.Hour()*60 + local.Minute()
+	start := from.Hour()*60 + from.Minute()
+	end := to.Hour()*60 + to.Minute()
+
+	today := weekdayCode(local.Weekday())
+	yesterday := weekdayCode(local.AddDate(0, 0, -1).Weekday())
+
+	if start < end {
+		return hasDay(window.Days, today) && minute >= start && minute < end
+	}
+
+	return (hasDay(window.Days, today) && minute >= start) || (hasDay(window.Days, yesterday) && minute < end)
+}
+
+// weekdayCode returns the code of the day used in menu windows, such as MON.
+func weekdayCode(day time.Weekday) string {
+	return strings.ToUpper(day.String()[:3])
+}
+
+func hasDay(days []string, day string) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+
+	return false
 }
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/EnesDemirtas/restaurant-management/repositories"
+)
+
+func TestMenuServedAt(t *testing.T) {
+	istanbul := time.FixedZone("Istanbul", 3*60*60)
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	breakfast := models.MenuWindow{Days: []string{"MON", "TUE", "WED", "THU", "FRI"}, From: "07:00", To: "11:00"}
+	lateNight := models.MenuWindow{Days: []string{"FRI"}, From: "22:00", To: "02:00"}
+
+	// 2024-03-15 is a Friday.
+	tests := []struct {
+		name string
+		menu models.Menu
+		at   time.Time
+		want bool
+	}{
+		{"no dates or windows", models.Menu{}, time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC), true},
+		{"before the start date", models.Menu{StartDate: &start, EndDate: &end}, start.Add(-time.Second), false},
+		{"on the start date", models.Menu{StartDate: &start, EndDate: &end}, start, true},
+		{"on the end date", models.Menu{StartDate: &start, EndDate: &end}, end, false},
+		{"in the window", models.Menu{Windows: []models.MenuWindow{breakfast}}, time.Date(2024, 3, 15, 7, 0, 0, 0, istanbul), true},
+		{"at the end of the window", models.Menu{Windows: []models.MenuWindow{breakfast}}, time.Date(2024, 3, 15, 11, 0, 0, 0, istanbul), false},
+		{"in the window in the restaurant timezone", models.Menu{Windows: []models.MenuWindow{breakfast}}, time.Date(2024, 3, 15, 5, 0, 0, 0, time.UTC), true},
+		{"on a day off", models.Menu{Windows: []models.MenuWindow{breakfast}}, time.Date(2024, 3, 16, 8, 0, 0, 0, istanbul), false},
+		{"in the window but expired", models.Menu{EndDate: &start, Windows: []models.MenuWindow{breakfast}}, time.Date(2024, 3, 15, 8, 0, 0, 0, istanbul), false},
+		{"before midnight", models.Menu{Windows: []models.MenuWindow{lateNight}}, time.Date(2024, 3, 15, 23, 0, 0, 0, istanbul), true},
+		{"past midnight", models.Menu{Windows: []models.MenuWindow{lateNight}}, time.Date(2024, 3, 16, 1, 0, 0, 0, istanbul), true},
+		{"past midnight of another day", models.Menu{Windows: []models.MenuWindow{lateNight}}, time.Date(2024, 3, 15, 1, 0, 0, 0, istanbul), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := menuServedAt(test.menu, test.at, istanbul); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCheckFoodServed(t *testing.T) {
+	ctx := context.Background()
+	UseStore(repositories.NewMemoryStore())
+
+	endDate := time.Now().Add(-time.Hour)
+	menus := []models.Menu{
+		{MenuID: "served", Name: "Lunch"},
+		{MenuID: "expired", Name: "Breakfast", EndDate: &endDate},
+	}
+	for _, menu := range menus {
+		if err := store.Menus.Insert(ctx, menu); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	name := "Pancakes"
+	tests := []struct {
+		name       string
+		menuId     *string
+		wantStatus int
+	}{
+		{"no menu", nil, http.StatusOK},
+		{"served menu", &menus[0].MenuID, http.StatusOK},
+		{"expired menu", &menus[1].MenuID, http.StatusConflict},
+		{"missing menu", &name, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			food := models.Food{FoodID: "f1", Name: &name, MenuID: test.menuId}
+			if status := httpStatus(checkFoodServed(ctx, food, time.Now())); status != test.wantStatus {
+				t.Errorf("got status %d, want %d", status, test.wantStatus)
+			}
+		})
+	}
+}
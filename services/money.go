@@ -0,0 +1,18 @@
+package services
+
+import "github.com/EnesDemirtas/restaurant-management/models"
+
+// money rounds an amount worked out in major units, such as a tax or a share
+// of a bill, to the minor units of the default currency.
+func money(amount float64) models.Money {
+	return models.NewMoney(amount, models.DefaultCurrency)
+}
+
+func sumMoney(amounts ...models.Money) models.Money {
+	total := models.Money{Currency: models.DefaultCurrency}
+	for _, amount := range amounts {
+		total = total.Add(amount)
+	}
+
+	return total
+}
@@ -2,26 +2,49 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
-	"github.com/EnesDemirtas/restaurant-management/database"
 	"github.com/EnesDemirtas/restaurant-management/helpers"
 	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/EnesDemirtas/restaurant-management/repositories"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var orderCollection *mongo.Collection = database.OpenCollection(database.Client, "order")
+// OrderStatusUpdate moves an order through the kitchen and the floor. Orders
+// are only BILLED, CLOSED or VOIDED by invoicing, paying and voiding them.
+type OrderStatusUpdate struct {
+	Status *string `json:"status" validate:"required,eq=SENT_TO_KITCHEN|eq=SERVED|eq=CANCELLED"`
+}
+
+// orderStatusRoles lists the roles that may move an order to each status
+// through the status endpoint. The kitchen only hands orders over as SERVED,
+// sending them to the kitchen and cancelling them is up to the floor.
+var orderStatusRoles = map[string][]string{
+	models.OrderStatusSentToKitchen: {models.RoleAdmin, models.RoleManager, models.RoleWaiter},
+	models.OrderStatusServed:        {models.RoleAdmin, models.RoleManager, models.RoleWaiter, models.RoleKitchen},
+	models.OrderStatusCancelled:     {models.RoleAdmin, models.RoleManager, models.RoleWaiter},
+}
+
+// orderTransitions lists, for every order status, the statuses it may move to.
+var orderTransitions = map[string][]string{
+	models.OrderStatusOpen:          {models.OrderStatusSentToKitchen, models.OrderStatusCancelled},
+	models.OrderStatusSentToKitchen: {models.OrderStatusServed, models.OrderStatusCancelled, models.OrderStatusVoided},
+	models.OrderStatusServed:        {models.OrderStatusBilled, models.OrderStatusVoided},
+	models.OrderStatusBilled:        {models.OrderStatusClosed, models.OrderStatusVoided},
+	models.OrderStatusClosed:        {},
+	models.OrderStatusCancelled:     {},
+	models.OrderStatusVoided:        {},
+}
 
-func GetOrders(c *gin.Context) ([]bson.M, error) {
+func GetOrders(c *gin.Context) ([]models.Order, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
-	result, err := orderCollection.Find(context.TODO(), bson.M{})
+	allOrders, err := store.Orders.FindAll(ctx)
 	if err != nil {
 		return nil, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
@@ -29,14 +52,6 @@ func GetOrders(c *gin.Context) ([]bson.M, error) {
 		}
 	}
 
-	var allOrders []bson.M
-	if err := result.All(ctx, &allOrders); err != nil {
-		return nil, helpers.HttpError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		}
-	}
-
 	return allOrders, nil
 }
 
@@ -44,12 +59,10 @@ func GetOrder(c *gin.Context) (models.Order, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
-	orderId := c.Param("order_id")
-	var order models.Order
+	orderId := c.Param("id")
 
-	err := orderCollection.FindOne(ctx, bson.M{"order_id": orderId}).Decode(&order)
+	order, err := store.Orders.FindByID(ctx, orderId)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while fetching the order item"})
 		return models.Order{}, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
 			Message: "error occured while fetching the order item",
@@ -59,15 +72,14 @@ func GetOrder(c *gin.Context) (models.Order, error) {
 	return order, nil
 }
 
-func CreateOrder(c *gin.Context) (*mongo.InsertOneResult, error) {
+func CreateOrder(c *gin.Context) (models.Order, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
-	var table models.Table
 	var order models.Order
 
 	if err := c.BindJSON(&order); err != nil {
-		return nil, helpers.HttpError{
+		return models.Order{}, helpers.HttpError{
 			Code:    http.StatusBadRequest,
 			Message: err.Error(),
 		}
@@ -76,96 +88,167 @@ func CreateOrder(c *gin.Context) (*mongo.InsertOneResult, error) {
 	validationErr := validate.Struct(order)
 
 	if validationErr != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
-		return nil, helpers.HttpError{
+		return models.Order{}, helpers.HttpError{
 			Code:    http.StatusBadRequest,
 			Message: validationErr.Error(),
 		}
 	}
 
 	if order.TableID != nil {
-		err := tableCollection.FindOne(ctx, bson.M{"table_id": order.TableID}).Decode(&table)
+		_, err := store.Tables.FindByID(ctx, *order.TableID)
 		if err != nil {
-			return nil, helpers.HttpError{
+			return models.Order{}, helpers.HttpError{
 				Code:    http.StatusInternalServerError,
 				Message: "table was not found",
 			}
 		}
 	}
 
+	if order.WaiterID == nil {
+		waiterId := c.GetString("uid")
+		order.WaiterID = &waiterId
+	}
+
 	order.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 	order.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 	order.ID = primitive.NewObjectID()
 	order.OrderID = order.ID.Hex()
+	openOrder(&order)
 
-	result, insertErr := orderCollection.InsertOne(ctx, order)
+	insertErr := store.Orders.Insert(ctx, order)
 	if insertErr != nil {
-		return nil, helpers.HttpError{
+		return models.Order{}, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
 			Message: "order item was not created",
 		}
 	}
 
-	return result, nil
+	return order, nil
 }
 
-func UpdateOrder(c *gin.Context) (*mongo.UpdateResult, error) {
-	var table models.Table
+func UpdateOrder(c *gin.Context) (models.Order, error) {
 	var order models.Order
 
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
 	if err := c.BindJSON(&order); err != nil {
-		return nil, helpers.HttpError{
+		return models.Order{}, helpers.HttpError{
 			Code:    http.StatusBadRequest,
 			Message: err.Error(),
 		}
 	}
 
-	var updateObj primitive.D
+	orderId := c.Param("id")
 
-	orderId := c.Param("order_id")
+	foundOrder, err := store.Orders.FindByID(ctx, orderId)
+	if err != nil {
+		return models.Order{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "order was not found",
+		}
+	}
+
+	currentStatus := orderStatus(foundOrder)
+	if isFinalOrderStatus(currentStatus) {
+		return models.Order{}, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: fmt.Sprintf("order is %s and can no longer be changed", currentStatus),
+		}
+	}
+
+	if order.Status != nil {
+		if validationErr := validate.Struct(OrderStatusUpdate{Status: order.Status}); validationErr != nil {
+			return models.Order{}, helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: validationErr.Error(),
+			}
+		}
+	}
+
+	if order.Status != nil && !canTransitionOrder(currentStatus, *order.Status) {
+		return models.Order{}, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: fmt.Sprintf("order cannot move from %s to %s", currentStatus, *order.Status),
+		}
+	}
 
 	if order.TableID != nil {
-		err := orderCollection.FindOne(ctx, bson.M{"table_id": order.TableID}).Decode(&table)
+		_, err := store.Tables.FindByID(ctx, *order.TableID)
 		if err != nil {
-			return nil, helpers.HttpError{
+			return models.Order{}, helpers.HttpError{
 				Code:    http.StatusInternalServerError,
 				Message: "table was not found",
 			}
 		}
-		updateObj = append(updateObj, primitive.E{Key: "table", Value: order.TableID})
+		foundOrder.TableID = order.TableID
 	}
 
-	order.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-	updateObj = append(updateObj, primitive.E{Key: "updated_at", Value: order.UpdatedAt})
-
-	upsert := true
-	filter := bson.M{
-		"order_id": orderId,
-	}
-	opt := options.UpdateOptions{
-		Upsert: &upsert,
+	if order.WaiterID != nil {
+		_, err := store.Users.FindByID(ctx, *order.WaiterID)
+		if err != nil {
+			return models.Order{}, helpers.HttpError{
+				Code:    http.StatusNotFound,
+				Message: "waiter was not found",
+			}
+		}
+		foundOrder.WaiterID = order.WaiterID
 	}
 
-	result, err := orderCollection.UpdateOne(
-		ctx,
-		filter,
-		bson.D{
-			{Key: "$set", Value: updateObj},
-		},
-		&opt,
-	)
+	foundOrder.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
+	err = store.Orders.Update(ctx, foundOrder)
 	if err != nil {
-		return nil, helpers.HttpError{
+		return models.Order{}, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
 			Message: "order item update failed",
 		}
 	}
 
-	return result, nil
+	if order.Status != nil {
+		return transitionOrder(ctx, foundOrder, *order.Status)
+	}
+
+	return foundOrder, nil
+}
+
+func UpdateOrderStatus(c *gin.Context) (models.Order, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	var statusUpdate OrderStatusUpdate
+
+	if err := c.BindJSON(&statusUpdate); err != nil {
+		return models.Order{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	validationErr := validate.Struct(statusUpdate)
+	if validationErr != nil {
+		return models.Order{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: validationErr.Error(),
+		}
+	}
+
+	if !canSetOrderStatus(c.GetString("role"), *statusUpdate.Status) {
+		return models.Order{}, helpers.HttpError{
+			Code:    http.StatusForbidden,
+			Message: fmt.Sprintf("your role cannot move orders to %s", *statusUpdate.Status),
+		}
+	}
+
+	order, err := store.Orders.FindByID(ctx, c.Param("id"))
+	if err != nil {
+		return models.Order{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "order was not found",
+		}
+	}
+
+	return transitionOrder(ctx, order, *statusUpdate.Status)
 }
 
 func OrderItemOrderCreator(ctx context.Context, order models.Order) (string, error) {
@@ -173,11 +256,98 @@ func OrderItemOrderCreator(ctx context.Context, order models.Order) (string, err
 	order.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 	order.ID = primitive.NewObjectID()
 	order.OrderID = order.ID.Hex()
+	openOrder(&order)
 
-	_, err := orderCollection.InsertOne(ctx, order)
+	err := store.Orders.Insert(ctx, order)
 	if err != nil {
 		return "", err
 	}
 
 	return order.OrderID, nil
 }
+
+// orderStatus returns the current status of the order. Orders stored before
+// statuses were introduced have none and are treated as open.
+func orderStatus(order models.Order) string {
+	if order.Status == nil || *order.Status == "" {
+		return models.OrderStatusOpen
+	}
+
+	return *order.Status
+}
+
+func canTransitionOrder(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+func canSetOrderStatus(role, status string) bool {
+	for _, allowed := range orderStatusRoles[status] {
+		if allowed == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isFinalOrderStatus(status string) bool {
+	next, ok := orderTransitions[status]
+	return ok && len(next) == 0
+}
+
+func openOrder(order *models.Order) {
+	status := models.OrderStatusOpen
+	order.Status = &status
+	order.StatusHistory = []models.OrderStatusChange{{To: status, At: order.CreatedAt}}
+}
+
+// unbillOrder moves an order that was just billed back to the status it was
+// billed from, when its invoices could not be created after all.
+func unbillOrder(ctx context.Context, order models.Order, to string) {
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	store.Orders.UpdateStatus(ctx, order.OrderID, models.OrderStatusChange{From: models.OrderStatusBilled, To: to, At: now})
+}
+
+// transitionOrder moves the order to the given status and records when it
+// happened. The update only matches while the order is still in the status it
+// was read with, so two concurrent transitions cannot both succeed.
+func transitionOrder(ctx context.Context, order models.Order, to string) (models.Order, error) {
+	from := orderStatus(order)
+	if !canTransitionOrder(from, to) {
+		return models.Order{}, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: fmt.Sprintf("order cannot move from %s to %s", from, to),
+		}
+	}
+
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	change := models.OrderStatusChange{From: from, To: to, At: now}
+
+	err := store.Orders.UpdateStatus(ctx, order.OrderID, change)
+	if errors.Is(err, repositories.ErrConflict) {
+		return models.Order{}, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: "order status was changed by another request, please retry",
+		}
+	}
+
+	if err != nil {
+		return models.Order{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "order status update failed",
+		}
+	}
+
+	order.Status = &to
+	order.StatusHistory = append(order.StatusHistory, change)
+	order.UpdatedAt = now
+
+	return order, nil
+}
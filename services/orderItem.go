@@ -2,17 +2,15 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"time"
 
-	"github.com/EnesDemirtas/restaurant-management/database"
 	"github.com/EnesDemirtas/restaurant-management/helpers"
 	"github.com/EnesDemirtas/restaurant-management/models"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type OrderItemPack struct {
@@ -20,13 +18,11 @@ type OrderItemPack struct {
 	OrderItems []models.OrderItem
 }
 
-var orderItemCollection *mongo.Collection = database.OpenCollection(database.Client, "orderItem")
-
-func GetOrderItems(c *gin.Context) ([]bson.M, error) {
+func GetOrderItems(c *gin.Context) ([]models.OrderItem, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
-	result, err := orderItemCollection.Find(context.TODO(), bson.M{})
+	allOrderItems, err := store.OrderItems.FindAll(ctx)
 	if err != nil {
 		return nil, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
@@ -34,144 +30,200 @@ func GetOrderItems(c *gin.Context) ([]bson.M, error) {
 		}
 	}
 
-	var allOrderItems []bson.M
-
-	if err := result.All(ctx, &allOrderItems); err != nil {
-		return nil, helpers.HttpError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		}
-	}
-
 	return allOrderItems, nil
 }
 
-func ItemsByOrder(orderId string) (orderItems []primitive.M, err error) {
-	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-	defer cancel()
+// invoiceLine is an order item priced for billing, along with what is needed
+// to tax and display it. PromotionDiscounts holds the part of its discount
+// each promotion gave. The service charge is billed as a line of its own.
+// When a line is split evenly, Share is the fraction of it one payer is
+// billed for.
+type invoiceLine struct {
+	models.InvoiceLine
+	FoodImage          *string
+	MenuID             string
+	Category           string
+	CurrentPrice       *models.Money
+	Seat               *int
+	PromotionDiscounts map[string]models.Money
+	ServiceCharge      bool
+	TaxExempt          bool
+	Share              float64
+}
 
-	matchStage := bson.D{
-		{Key: "$match", Value: bson.D{{Key: "order_id", Value: orderId}}},
+// fraction returns the part of the line being billed, all of it unless it is
+// shared between payers.
+func (l invoiceLine) fraction() float64 {
+	if l.Share == 0 {
+		return 1
 	}
 
-	lookupStage := bson.D{
-		{Key: "$lookup", Value: bson.D{
-			{Key: "from", Value: "food"},
-			{Key: "localField", Value: "food_id"},
-			{Key: "foreignField", Value: "food_id"},
-			{Key: "as", Value: "food"},
-		}},
-	}
+	return l.Share
+}
 
-	unwindStage := bson.D{
-		{Key: "$unwind", Value: bson.D{
-			{Key: "path", Value: "$food"},
-			{Key: "preserveNullAndEmptyArrays", Value: true},
-		}},
+// amount returns what is charged for the part of the line being billed.
+func (l invoiceLine) amount() models.Money {
+	if l.Share == 0 {
+		return l.Amount
 	}
 
-	lookupOrderStage := bson.D{
-		{Key: "$lookup", Value: bson.D{
-			{Key: "from", Value: "order"},
-			{Key: "localField", Value: "order_id"},
-			{Key: "foreignField", Value: "order_id"},
-			{Key: "as", Value: "order"},
-		}},
-	}
+	return l.Amount.Scale(l.Share)
+}
 
-	unwindOrderStage := bson.D{
-		{Key: "$unwind", Value: bson.D{
-			{Key: "path", Value: "$order"},
-			{Key: "preserveNullAndEmptyArrays", Value: true},
-		}},
+// orderLines prices every item of an order that was not voided at the unit
+// price captured when it was ordered, and returns them along with the table
+// the order is served at.
+func orderLines(ctx context.Context, orderId string) ([]invoiceLine, models.Table, error) {
+	allOrderItems, err := store.OrderItems.FindByOrder(ctx, orderId)
+	if err != nil {
+		return nil, models.Table{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		}
 	}
 
-	lookupTableStage := bson.D{
-		{Key: "$lookup", Value: bson.D{
-			{Key: "from", Value: "table"},
-			{Key: "localField", Value: "order.table_id"},
-			{Key: "foreignField", Value: "table_id"},
-			{Key: "as", Value: "table"},
-		}},
+	var table models.Table
+	if len(allOrderItems) > 0 {
+		order, _ := store.Orders.FindByID(ctx, orderId)
+		if order.TableID != nil {
+			table, _ = store.Tables.FindByID(ctx, *order.TableID)
+		}
 	}
 
-	unwindTableStage := bson.D{
-		{Key: "$unwind", Value: bson.D{
-			{Key: "path", Value: "$table"},
-			{Key: "preserveNullAndEmptyArrays", Value: true},
-		}},
+	voided, err := voidedOrderItems(ctx, orderId)
+	if err != nil {
+		return nil, models.Table{}, err
 	}
 
-	projectStage := bson.D{
-		{Key: "$project", Value: bson.D{
-			{Key: "id", Value: 0},
-			{Key: "amount", Value: "$food.price"},
-			{Key: "food_name", Value: "$food.name"},
-			{Key: "food_image", Value: "$food.food_image"},
-			{Key: "table_number", Value: "$table.table_number"},
-			{Key: "table_id", Value: "$table.table_id"},
-			{Key: "order_id", Value: "$order.order_id"},
-			{Key: "price", Value: "$food.price"},
-			{Key: "quantity", Value: 1},
-		}},
-	}
+	lines := []invoiceLine{}
+	menus := map[string]models.Menu{}
+
+	for _, orderItem := range allOrderItems {
+		if voided[orderItem.OrderItemID] {
+			continue
+		}
+
+		var food models.Food
+		if orderItem.FoodID != nil {
+			food, _ = store.Foods.FindByID(ctx, *orderItem.FoodID)
+		}
+
+		line := invoiceLine{FoodImage: food.FoodImage, CurrentPrice: food.Price, Seat: orderItem.Seat}
+		line.OrderItemID = orderItem.OrderItemID
+		line.FoodID = food.FoodID
+		if food.Name != nil {
+			line.FoodName = *food.Name
+		}
+
+		if orderItem.VariantID != nil {
+			line.VariantID = *orderItem.VariantID
+			if variant, ok := findVariant(food, *orderItem.VariantID); ok {
+				line.VariantName = *variant.Name
+				line.CurrentPrice = variant.Price
+			}
+		}
+
+		if orderItem.ComboID != nil {
+			line.ComboID = *orderItem.ComboID
+			line.ComboComponents = orderItem.ComboComponents
+			if combo, err := store.Combos.FindByID(ctx, *orderItem.ComboID); err == nil {
+				line.FoodName = *combo.Name
+				line.CurrentPrice = combo.Price
+			}
+		}
+
+		line.Modifiers = orderItem.Modifiers
+		line.Quantity = 1
+		if orderItem.Quantity != nil {
+			line.Quantity = *orderItem.Quantity
+		}
+
+		// Items ordered before unit prices were captured fall back to the
+		// current price of the food.
+		if orderItem.UnitPrice != nil {
+			line.UnitPrice = *orderItem.UnitPrice
+		} else if food.Price != nil {
+			line.UnitPrice = *food.Price
+		}
 
-	groupStage := bson.D{
-		{Key: "$group", Value: bson.D{
-			{Key: "_id", Value: bson.D{
-				{Key: "order_id", Value: "$order_id"},
-				{Key: "table_id", Value: "$table_id"},
-				{Key: "table_number", Value: "$table_number"},
-			}},
-			{Key: "payment_due", Value: bson.D{
-				{Key: "$sum", Value: "$amount"},
-			}},
-			{Key: "total_count", Value: bson.D{
-				{Key: "$sum", Value: 1},
-			}},
-			{Key: "order_items", Value: bson.D{
-				{Key: "$push", Value: "$$ROOT"},
-			}},
-		}},
+		line.Amount = line.UnitPrice.Mul(line.Quantity)
+
+		if food.MenuID != nil {
+			menu, ok := menus[*food.MenuID]
+			if !ok {
+				menu, _ = store.Menus.FindByID(ctx, *food.MenuID)
+				menus[*food.MenuID] = menu
+			}
+			line.MenuID = *food.MenuID
+			line.Category = menu.Category
+		}
+
+		lines = append(lines, line)
 	}
 
-	projectStage2 := bson.D{
-		{Key: "$project", Value: bson.D{
-			{Key: "id", Value: 0},
-			{Key: "payment_due", Value: 1},
-			{Key: "total_count", Value: 1},
-			{Key: "table_number", Value: "$_id.table_number"},
-			{Key: "order_items", Value: 1},
-		}},
+	return lines, table, nil
+}
+
+// orderLineDetails formats priced lines the way order items are shown on
+// invoices.
+func orderLineDetails(lines []invoiceLine, orderId string, table models.Table) []bson.M {
+	items := []bson.M{}
+
+	for _, line := range lines {
+		items = append(items, bson.M{
+			"order_item_id": line.OrderItemID,
+			"food_id":       line.FoodID,
+			"food_name":     line.FoodName,
+			"food_image":    line.FoodImage,
+			"table_number":  table.TableNumber,
+			"table_id":      table.TableID,
+			"order_id":      orderId,
+			"quantity":      line.Quantity,
+			"price":         line.UnitPrice,
+			"unit_price":    line.UnitPrice,
+			"variant_id":    line.VariantID,
+			"variant_name":  line.VariantName,
+			"combo_id":      line.ComboID,
+			"components":    line.ComboComponents,
+			"modifiers":     line.Modifiers,
+			"current_price": line.CurrentPrice,
+			"discount":      line.Discount,
+			"amount":        line.Amount,
+		})
 	}
 
-	result, err := orderItemCollection.Aggregate(ctx, mongo.Pipeline{
-		{{Key: "$match", Value: matchStage}},
-		{{Key: "$lookup", Value: lookupStage}},
-		{{Key: "$unwind", Value: unwindStage}},
-		{{Key: "$lookup", Value: lookupOrderStage}},
-		{{Key: "$unwind", Value: unwindOrderStage}},
-		{{Key: "$lookup", Value: lookupTableStage}},
-		{{Key: "$unwind", Value: unwindTableStage}},
-		{{Key: "$project", Value: projectStage}},
-		{{Key: "$group", Value: groupStage}},
-		{{Key: "$project", Value: projectStage2}},
-	})
+	return items
+}
 
+// ItemsByOrder joins the items of an order with their food, order and table
+// and totals what is due for them.
+func ItemsByOrder(orderId string) (orderItems []primitive.M, err error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	lines, table, err := orderLines(ctx, orderId)
 	if err != nil {
-		return nil, helpers.HttpError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		}
+		return nil, err
 	}
 
-	if err := result.All(ctx, &orderItems); err != nil {
-		return nil, helpers.HttpError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		}
+	if len(lines) == 0 {
+		return []primitive.M{}, nil
+	}
+
+	paymentDue := models.Money{Currency: models.DefaultCurrency}
+	for _, line := range lines {
+		paymentDue = paymentDue.Add(line.Amount)
 	}
 
+	items := orderLineDetails(lines, orderId, table)
+
+	orderItems = append(orderItems, bson.M{
+		"payment_due":  paymentDue,
+		"total_count":  len(items),
+		"table_number": table.TableNumber,
+		"order_items":  items,
+	})
+
 	return orderItems, nil
 
 }
@@ -180,12 +232,10 @@ func GetOrderItem(c *gin.Context) (models.OrderItem, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
-	orderItemId := c.Param("order_item_id")
-	var orderItem models.OrderItem
+	orderItemId := c.Param("id")
 
-	err := orderItemCollection.FindOne(ctx, bson.M{"order_item_id": orderItemId}).Decode(&orderItem)
+	orderItem, err := store.OrderItems.FindByID(ctx, orderItemId)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while listing ordered item"})
 		return models.OrderItem{}, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
 			Message: "error occured while listing ordered item",
@@ -195,7 +245,7 @@ func GetOrderItem(c *gin.Context) (models.OrderItem, error) {
 	return orderItem, nil
 }
 
-func CreateOrderItem(c *gin.Context) (*mongo.InsertManyResult, error) {
+func CreateOrderItem(c *gin.Context) ([]models.OrderItem, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
@@ -211,8 +261,10 @@ func CreateOrderItem(c *gin.Context) (*mongo.InsertManyResult, error) {
 
 	order.OrderDate, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
-	orderItemsToBeInserted := []interface{}{}
+	orderItemsToBeInserted := []models.OrderItem{}
 	order.TableID = orderItemPack.TableID
+	waiterId := c.GetString("uid")
+	order.WaiterID = &waiterId
 	order_id, err := OrderItemOrderCreator(ctx, order)
 	if err != nil {
 		return nil, helpers.HttpError{
@@ -221,9 +273,62 @@ func CreateOrderItem(c *gin.Context) (*mongo.InsertManyResult, error) {
 		}
 	}
 
+	// Portions taken are given back unless the order items are stored.
+	taken := map[string]int{}
+	placed := false
+	defer func() {
+		if !placed {
+			returnPortions(ctx, taken)
+		}
+	}()
+
 	for _, orderItem := range orderItemPack.OrderItems {
 		orderItem.OrderID = order_id
 
+		// The unit price is captured from the food, or the combo, when it is
+		// ordered, so later price changes don't affect orders already taken.
+		// Portions are taken of the food, or of every food of the combo.
+		var food models.Food
+		var foods []models.Food
+		if orderItem.FoodID != nil {
+			food, err = store.Foods.FindByID(ctx, *orderItem.FoodID)
+			if err != nil {
+				return nil, helpers.HttpError{
+					Code:    http.StatusNotFound,
+					Message: "food item was not found",
+				}
+			}
+
+			if err := checkFoodServed(ctx, food, time.Now()); err != nil {
+				return nil, err
+			}
+
+			price, err := foodPrice(food, orderItem.VariantID)
+			if err != nil {
+				return nil, err
+			}
+			orderItem.UnitPrice = &price
+			foods = []models.Food{food}
+		} else if orderItem.ComboID != nil {
+			combo, err := store.Combos.FindByID(ctx, *orderItem.ComboID)
+			if err != nil {
+				return nil, helpers.HttpError{
+					Code:    http.StatusNotFound,
+					Message: "combo was not found",
+				}
+			}
+
+			components, componentFoods, upcharge, err := comboComponents(ctx, combo, orderItem.ComboComponents)
+			if err != nil {
+				return nil, err
+			}
+
+			price := combo.Price.Add(upcharge)
+			orderItem.UnitPrice = &price
+			orderItem.ComboComponents = components
+			foods = componentFoods
+		}
+
 		validationErr := validate.Struct(orderItem)
 
 		if validationErr != nil {
@@ -233,74 +338,285 @@ func CreateOrderItem(c *gin.Context) (*mongo.InsertManyResult, error) {
 			}
 		}
 
+		if orderItem.ComboID != nil && len(orderItem.Modifiers) > 0 {
+			return nil, helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: "modifiers can't be picked for a combo",
+			}
+		}
+
+		modifiers, priceDelta, err := selectModifiers(food, orderItem.Modifiers)
+		if err != nil {
+			return nil, err
+		}
+		unitPrice := orderItem.UnitPrice.Add(priceDelta)
+		orderItem.UnitPrice = &unitPrice
+		orderItem.Modifiers = modifiers
+
+		for _, food := range foods {
+			if err := takePortions(ctx, food, *orderItem.Quantity); err != nil {
+				return nil, err
+			}
+			taken[food.FoodID] += *orderItem.Quantity
+		}
+
 		orderItem.ID = primitive.NewObjectID()
 		orderItem.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		orderItem.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		orderItem.OrderItemID = orderItem.ID.Hex()
-		var num = helpers.ToFixed(*orderItem.UnitPrice, 2)
-		orderItem.UnitPrice = &num
+		queued := models.KitchenStatusQueued
+		orderItem.KitchenStatus = &queued
 		orderItemsToBeInserted = append(orderItemsToBeInserted, orderItem)
 	}
 
-	insertedOrderItems, err := orderItemCollection.InsertMany(ctx, orderItemsToBeInserted)
+	err = store.OrderItems.InsertMany(ctx, orderItemsToBeInserted)
 	if err != nil {
 		return nil, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
 			Message: err.Error(),
 		}
 	}
+	placed = true
 
-	return insertedOrderItems, nil
+	if len(orderItemsToBeInserted) > 0 {
+		createdOrder, err := store.Orders.FindByID(ctx, order_id)
+		if err != nil {
+			return nil, helpers.HttpError{
+				Code:    http.StatusInternalServerError,
+				Message: "Order was not found",
+			}
+		}
+
+		if _, err := transitionOrder(ctx, createdOrder, models.OrderStatusSentToKitchen); err != nil {
+			return nil, err
+		}
+
+		if err := sendToKitchen(ctx, order_id); err != nil {
+			return nil, err
+		}
+	}
+
+	return orderItemsToBeInserted, nil
 }
 
-func UpdateOrderItem(c *gin.Context) (*mongo.UpdateResult, error) {
+func UpdateOrderItem(c *gin.Context) (models.OrderItem, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
 	var orderItem models.OrderItem
 
-	orderItemId := c.Param("order_item_id")
+	orderItemId := c.Param("id")
+
+	if err := c.BindJSON(&orderItem); err != nil {
+		return models.OrderItem{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	foundOrderItem, err := store.OrderItems.FindByID(ctx, orderItemId)
+	if err != nil {
+		return models.OrderItem{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "Order item was not found",
+		}
+	}
+
+	// Items are billed as they are once the order is, so they can no longer
+	// change.
+	order, err := store.Orders.FindByID(ctx, foundOrderItem.OrderID)
+	if err != nil {
+		return models.OrderItem{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "Order was not found",
+		}
+	}
+
+	if status := orderStatus(order); status == models.OrderStatusBilled || isFinalOrderStatus(status) {
+		return models.OrderItem{}, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: fmt.Sprintf("items of a %s order cannot be changed", status),
+		}
+	}
+
+	if orderItem.FoodID != nil && orderItem.ComboID != nil {
+		return models.OrderItem{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "an order item is either a food or a combo",
+		}
+	}
+
+	if orderItem.Quantity != nil && *orderItem.Quantity <= 0 {
+		return models.OrderItem{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "quantity must be greater than zero",
+		}
+	}
+
+	if orderItem.Seat != nil && *orderItem.Seat <= 0 {
+		return models.OrderItem{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "seat must be greater than zero",
+		}
+	}
+
+	previousPortions := itemPortions(foundOrderItem)
+
+	// Changing the food, its variant or its modifiers prices the item again.
+	// Modifiers not sent are kept, as long as the food still offers them,
+	// while a new food is ordered as the variant sent along with it. The same
+	// goes for the combo and its components.
+	if orderItem.FoodID != nil || orderItem.VariantID != nil || orderItem.Modifiers != nil {
+		if orderItem.FoodID != nil {
+			foundOrderItem.FoodID = orderItem.FoodID
+			foundOrderItem.VariantID = orderItem.VariantID
+			foundOrderItem.ComboID = nil
+			foundOrderItem.ComboComponents = nil
+		} else if orderItem.VariantID != nil {
+			foundOrderItem.VariantID = orderItem.VariantID
+		}
+
+		if orderItem.Modifiers != nil {
+			foundOrderItem.Modifiers = orderItem.Modifiers
+		}
+
+		if foundOrderItem.FoodID == nil {
+			return models.OrderItem{}, helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: "a combo has no variant or modifiers",
+			}
+		}
+
+		food, err := store.Foods.FindByID(ctx, *foundOrderItem.FoodID)
+		if err != nil {
+			return models.OrderItem{}, helpers.HttpError{
+				Code:    http.StatusNotFound,
+				Message: "food item was not found",
+			}
+		}
+
+		// A new food can only be ordered while its menu is served, as when
+		// the item was ordered.
+		if orderItem.FoodID != nil {
+			if err := checkFoodServed(ctx, food, time.Now()); err != nil {
+				return models.OrderItem{}, err
+			}
+		}
+
+		if validationErr := validate.Var(foundOrderItem.Modifiers, "dive"); validationErr != nil {
+			return models.OrderItem{}, helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: validationErr.Error(),
+			}
+		}
+
+		price, err := foodPrice(food, foundOrderItem.VariantID)
+		if err != nil {
+			return models.OrderItem{}, err
+		}
+
+		modifiers, priceDelta, err := selectModifiers(food, foundOrderItem.Modifiers)
+		if err != nil {
+			return models.OrderItem{}, err
+		}
+		unitPrice := price.Add(priceDelta)
+		foundOrderItem.UnitPrice = &unitPrice
+		foundOrderItem.Modifiers = modifiers
+	} else if orderItem.ComboID != nil || orderItem.ComboComponents != nil {
+		if orderItem.ComboID != nil {
+			foundOrderItem.ComboID = orderItem.ComboID
+			foundOrderItem.FoodID = nil
+			foundOrderItem.VariantID = nil
+			foundOrderItem.Modifiers = nil
+		}
 
-	filter := bson.M{"order_item_id": orderItemId}
+		if orderItem.ComboComponents != nil {
+			foundOrderItem.ComboComponents = orderItem.ComboComponents
+		}
 
-	var updateObj primitive.D
+		if foundOrderItem.ComboID == nil {
+			return models.OrderItem{}, helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: "only a combo has components",
+			}
+		}
+
+		combo, err := store.Combos.FindByID(ctx, *foundOrderItem.ComboID)
+		if err != nil {
+			return models.OrderItem{}, helpers.HttpError{
+				Code:    http.StatusNotFound,
+				Message: "combo was not found",
+			}
+		}
+
+		components, _, upcharge, err := comboComponents(ctx, combo, foundOrderItem.ComboComponents)
+		if err != nil {
+			return models.OrderItem{}, err
+		}
 
-	if orderItem.UnitPrice != nil {
-		updateObj = append(updateObj, primitive.E{Key: "unit_price", Value: orderItem.UnitPrice})
+		unitPrice := combo.Price.Add(upcharge)
+		foundOrderItem.UnitPrice = &unitPrice
+		foundOrderItem.ComboComponents = components
 	}
 
 	if orderItem.Quantity != nil {
-		updateObj = append(updateObj, primitive.E{Key: "quantity", Value: orderItem.Quantity})
+		foundOrderItem.Quantity = orderItem.Quantity
 	}
 
-	if orderItem.FoodID != nil {
-		updateObj = append(updateObj, primitive.E{Key: "food_id", Value: orderItem.FoodID})
+	if orderItem.Seat != nil {
+		foundOrderItem.Seat = orderItem.Seat
 	}
 
-	orderItem.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-	updateObj = append(updateObj, primitive.E{Key: "updated_at", Value: orderItem.UpdatedAt})
+	// More portions are taken when the quantity grows or the food changes,
+	// and given back unless the order item is updated. Those no longer
+	// needed are given back once it is.
+	currentPortions := itemPortions(foundOrderItem)
+	taken := map[string]int{}
+	updated := false
+	defer func() {
+		if !updated {
+			returnPortions(ctx, taken)
+		}
+	}()
+
+	for foodId, portions := range currentPortions {
+		extra := portions - previousPortions[foodId]
+		if extra <= 0 {
+			continue
+		}
 
-	upsert := true
+		food, err := store.Foods.FindByID(ctx, foodId)
+		if err != nil {
+			return models.OrderItem{}, helpers.HttpError{
+				Code:    http.StatusNotFound,
+				Message: "food item was not found",
+			}
+		}
 
-	opt := options.UpdateOptions{
-		Upsert: &upsert,
+		if err := takePortions(ctx, food, extra); err != nil {
+			return models.OrderItem{}, err
+		}
+		taken[foodId] = extra
 	}
 
-	result, err := orderItemCollection.UpdateOne(
-		ctx,
-		filter,
-		bson.D{
-			{Key: "$set", Value: updateObj},
-		},
-		&opt,
-	)
+	foundOrderItem.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
+	err = store.OrderItems.Update(ctx, foundOrderItem)
 	if err != nil {
-		return nil, helpers.HttpError{
+		return models.OrderItem{}, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
 			Message: "Order item update failed",
 		}
 	}
+	updated = true
+
+	freed := map[string]int{}
+	for foodId, portions := range previousPortions {
+		if portions > currentPortions[foodId] {
+			freed[foodId] = portions - currentPortions[foodId]
+		}
+	}
+	returnPortions(ctx, freed)
 
-	return result, nil
+	return foundOrderItem, nil
 }
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+)
+
+func TestCanTransitionOrder(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want bool
+	}{
+		{models.OrderStatusOpen, models.OrderStatusSentToKitchen, true},
+		{models.OrderStatusOpen, models.OrderStatusCancelled, true},
+		{models.OrderStatusOpen, models.OrderStatusServed, false},
+		{models.OrderStatusOpen, models.OrderStatusBilled, false},
+		{models.OrderStatusOpen, models.OrderStatusVoided, false},
+		{models.OrderStatusSentToKitchen, models.OrderStatusServed, true},
+		{models.OrderStatusSentToKitchen, models.OrderStatusCancelled, true},
+		{models.OrderStatusSentToKitchen, models.OrderStatusVoided, true},
+		{models.OrderStatusSentToKitchen, models.OrderStatusOpen, false},
+		{models.OrderStatusSentToKitchen, models.OrderStatusBilled, false},
+		{models.OrderStatusServed, models.OrderStatusBilled, true},
+		{models.OrderStatusServed, models.OrderStatusVoided, true},
+		{models.OrderStatusServed, models.OrderStatusCancelled, false},
+		{models.OrderStatusServed, models.OrderStatusClosed, false},
+		{models.OrderStatusBilled, models.OrderStatusClosed, true},
+		{models.OrderStatusBilled, models.OrderStatusVoided, true},
+		{models.OrderStatusBilled, models.OrderStatusServed, false},
+		{models.OrderStatusClosed, models.OrderStatusVoided, false},
+		{models.OrderStatusCancelled, models.OrderStatusOpen, false},
+		{models.OrderStatusVoided, models.OrderStatusOpen, false},
+		{"UNKNOWN", models.OrderStatusOpen, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.from+" to "+test.to, func(t *testing.T) {
+			if got := canTransitionOrder(test.from, test.to); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestIsFinalOrderStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		want   bool
+	}{
+		{models.OrderStatusOpen, false},
+		{models.OrderStatusSentToKitchen, false},
+		{models.OrderStatusServed, false},
+		{models.OrderStatusBilled, false},
+		{models.OrderStatusClosed, true},
+		{models.OrderStatusCancelled, true},
+		{models.OrderStatusVoided, true},
+		{"UNKNOWN", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.status, func(t *testing.T) {
+			if got := isFinalOrderStatus(test.status); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCanSetOrderStatus(t *testing.T) {
+	tests := []struct {
+		role   string
+		status string
+		want   bool
+	}{
+		{models.RoleWaiter, models.OrderStatusSentToKitchen, true},
+		{models.RoleWaiter, models.OrderStatusServed, true},
+		{models.RoleWaiter, models.OrderStatusCancelled, true},
+		{models.RoleKitchen, models.OrderStatusServed, true},
+		{models.RoleKitchen, models.OrderStatusSentToKitchen, false},
+		{models.RoleKitchen, models.OrderStatusCancelled, false},
+		{models.RoleCashier, models.OrderStatusServed, false},
+		{models.RoleManager, models.OrderStatusCancelled, true},
+		{models.RoleAdmin, models.OrderStatusVoided, false},
+		{models.RoleAdmin, models.OrderStatusBilled, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.role+" to "+test.status, func(t *testing.T) {
+			if got := canSetOrderStatus(test.role, test.status); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
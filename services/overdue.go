@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/EnesDemirtas/restaurant-management/notify"
+	"github.com/EnesDemirtas/restaurant-management/repositories"
+)
+
+var notifier notify.Notifier
+
+// UseNotifier sets the notifier reminders of overdue invoices are sent with.
+// Without one, invoices are still marked overdue but no reminder is sent.
+func UseNotifier(n notify.Notifier) {
+	notifier = n
+}
+
+// reminderSettings is how often invoices are checked, configured with
+// OVERDUE_CHECK_INTERVAL, and how long after the due date each reminder is
+// sent, configured with REMINDER_INTERVALS as a comma separated list, both as
+// durations such as 30m or 72h.
+type reminderSettings struct {
+	CheckInterval time.Duration
+	Intervals     []time.Duration
+}
+
+func loadReminderSettings() reminderSettings {
+	settings := reminderSettings{CheckInterval: time.Hour}
+
+	if interval, err := time.ParseDuration(os.Getenv("OVERDUE_CHECK_INTERVAL")); err == nil && interval > 0 {
+		settings.CheckInterval = interval
+	}
+
+	intervals := os.Getenv("REMINDER_INTERVALS")
+	if intervals == "" {
+		intervals = "0,72h,168h"
+	}
+
+	for _, field := range strings.Split(intervals, ",") {
+		interval, err := time.ParseDuration(strings.TrimSpace(field))
+		if err != nil {
+			log.Printf("Ignoring reminder interval %q: %s", field, err)
+			continue
+		}
+		settings.Intervals = append(settings.Intervals, interval)
+	}
+
+	return settings
+}
+
+// RunOverdueJob checks for overdue invoices every OVERDUE_CHECK_INTERVAL
+// until the context is done.
+func RunOverdueJob(ctx context.Context) {
+	settings := loadReminderSettings()
+
+	ticker := time.NewTicker(settings.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := checkOverdueInvoices(ctx, settings, time.Now()); err != nil {
+			log.Printf("Checking overdue invoices failed: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkOverdueInvoices marks the invoices that are not paid by their due date
+// OVERDUE and sends the reminders that are due for them. Each reminder is
+// recorded before it is sent, so that it is sent once even with several
+// servers running the job.
+func checkOverdueInvoices(ctx context.Context, settings reminderSettings, now time.Time) error {
+	allInvoices, err := store.Invoices.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	allEntries, err := store.Ledger.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	voided := map[string]bool{}
+	for _, entry := range allEntries {
+		if entry.Type == models.LedgerEntryVoid && entry.InvoiceID != nil {
+			voided[*entry.InvoiceID] = true
+		}
+	}
+
+	for _, invoice := range allInvoices {
+		if isPaid(invoice) || voided[invoice.InvoiceID] || invoice.GrandTotal == nil || !now.After(invoice.PaymentDueDate) {
+			continue
+		}
+
+		if invoice.PaymentStatus == nil || *invoice.PaymentStatus != models.PaymentStatusOverdue {
+			err := store.Invoices.MarkOverdue(ctx, invoice.InvoiceID, now)
+			if errors.Is(err, repositories.ErrConflict) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		sequence := 0
+		for _, interval := range settings.Intervals {
+			if !now.Before(invoice.PaymentDueDate.Add(interval)) {
+				sequence++
+			}
+		}
+
+		if notifier == nil || sequence <= invoice.RemindersSent {
+			continue
+		}
+
+		err := store.Invoices.RecordReminder(ctx, invoice.InvoiceID, sequence, now)
+		if errors.Is(err, repositories.ErrConflict) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		reminder := notify.Reminder{
+			InvoiceID:     invoice.InvoiceID,
+			InvoiceNumber: invoice.InvoiceID,
+			Email:         invoice.BillingEmail,
+			Balance:       invoiceBalance(invoice),
+			DueDate:       invoice.PaymentDueDate,
+			Sequence:      sequence,
+			SentAt:        now,
+		}
+		if invoice.FiscalNumber != nil {
+			reminder.InvoiceNumber = invoice.FiscalNumber.Number
+		}
+
+		if err := notifier.Send(ctx, reminder); err != nil {
+			log.Printf("Reminder %d of invoice %s was not sent with %s: %s", sequence, invoice.InvoiceID, notifier.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// unpaidStatus is the payment status of an invoice that is not paid in full.
+func unpaidStatus(invoice models.Invoice, at time.Time) string {
+	if at.After(invoice.PaymentDueDate) {
+		return models.PaymentStatusOverdue
+	}
+
+	if len(invoice.Payments) > 0 {
+		return models.PaymentStatusPartiallyPaid
+	}
+
+	return models.PaymentStatusPending
+}
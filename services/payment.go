@@ -0,0 +1,422 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/helpers"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/EnesDemirtas/restaurant-management/repositories"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PaymentRequest is a payment received for an invoice. A cash payment may only
+// give the cash tendered, in which case as much of it as is due is applied and
+// the rest is given back as change. A tip is paid on top of the amount, either
+// as is or as a percentage of the amount. Amounts are in the payment currency
+// of the invoice. Card payments are charged with the card token through the
+// payment provider, if one is configured.
+type PaymentRequest struct {
+	Method        *string       `json:"method" validate:"required,eq=CASH|eq=CARD|eq=GIFT_CARD"`
+	Amount        *models.Money `json:"amount"`
+	Tendered      *models.Money `json:"tendered"`
+	Tip           *models.Money `json:"tip"`
+	TipPercentage *float64      `json:"tip_percentage" validate:"omitempty,gt=0,lte=100"`
+	Reference     *string       `json:"reference"`
+	CardToken     *string       `json:"card_token"`
+}
+
+// GetTips totals the tips of the payments received on a day, given as
+// YYYY-MM-DD in the date query and defaulting to today, per waiter.
+func GetTips(c *gin.Context) ([]models.WaiterTips, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	// Days begin at midnight where the restaurant is.
+	location := restaurantLocation()
+	day, err := time.ParseInLocation(time.DateOnly, c.DefaultQuery("date", time.Now().In(location).Format(time.DateOnly)), location)
+	if err != nil {
+		return nil, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "date must be given as YYYY-MM-DD",
+		}
+	}
+
+	allInvoices, err := store.Invoices.FindAll(ctx)
+	if err != nil {
+		return nil, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while listing all invoices",
+		}
+	}
+
+	return tipsByWaiter(allInvoices, func(payment models.InvoicePayment) bool {
+		return !payment.CreatedAt.Before(day) && payment.CreatedAt.Before(day.AddDate(0, 0, 1))
+	}), nil
+}
+
+// tipsByWaiter totals the tips of the payments that match per waiter.
+func tipsByWaiter(invoices []models.Invoice, match func(models.InvoicePayment) bool) []models.WaiterTips {
+	tips := []models.WaiterTips{}
+	byWaiter := map[string]int{}
+
+	for _, invoice := range invoices {
+		for _, payment := range invoice.Payments {
+			if payment.Tip.Amount == 0 || !match(payment) {
+				continue
+			}
+
+			waiterId := ""
+			if payment.WaiterID != nil {
+				waiterId = *payment.WaiterID
+			}
+
+			i, ok := byWaiter[waiterId]
+			if !ok {
+				i = len(tips)
+				byWaiter[waiterId] = i
+				tips = append(tips, models.WaiterTips{WaiterID: waiterId, Tips: sumMoney()})
+			}
+
+			tips[i].Tips = tips[i].Tips.Add(payment.Tip)
+			tips[i].Payments++
+		}
+	}
+
+	return tips
+}
+
+func GetPayments(c *gin.Context) ([]models.InvoicePayment, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	invoice, err := store.Invoices.FindByID(ctx, c.Param("id"))
+	if err != nil {
+		return nil, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "invoice was not found",
+		}
+	}
+
+	if invoice.Payments == nil {
+		return []models.InvoicePayment{}, nil
+	}
+
+	return invoice.Payments, nil
+}
+
+func CreatePayment(c *gin.Context) (models.Invoice, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	var request PaymentRequest
+
+	if err := c.BindJSON(&request); err != nil {
+		return models.Invoice{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	validationErr := validate.Struct(request)
+	if validationErr != nil {
+		return models.Invoice{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: validationErr.Error(),
+		}
+	}
+
+	invoice, err := store.Invoices.FindByID(ctx, c.Param("id"))
+	if err != nil {
+		return models.Invoice{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "invoice was not found",
+		}
+	}
+
+	if isPaid(invoice) {
+		return models.Invoice{}, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: "invoice is already paid",
+		}
+	}
+
+	if err := checkDayOpen(ctx, invoice.CreatedAt); err != nil {
+		return models.Invoice{}, err
+	}
+
+	ledger, err := invoiceLedger(ctx, invoice.InvoiceID)
+	if err != nil {
+		return models.Invoice{}, err
+	}
+
+	if ledger.Voided {
+		return models.Invoice{}, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: "invoice is voided",
+		}
+	}
+
+	// The amounts are billed one last time before the first payment and never
+	// change after it.
+	if len(invoice.Payments) == 0 && (invoice.SplitGroupID == nil || invoice.GrandTotal == nil) {
+		if err := billInvoice(ctx, &invoice); err != nil {
+			return models.Invoice{}, err
+		}
+
+		err := store.Invoices.Update(ctx, invoice)
+		if errors.Is(err, repositories.ErrConflict) {
+			return models.Invoice{}, helpers.HttpError{
+				Code:    http.StatusConflict,
+				Message: "another payment was recorded for the invoice, please retry",
+			}
+		}
+
+		if err != nil {
+			return models.Invoice{}, helpers.HttpError{
+				Code:    http.StatusInternalServerError,
+				Message: "invoice item update failed",
+			}
+		}
+	}
+
+	balance := invoiceBalance(invoice)
+	currency := paymentCurrency(invoice)
+	paidAt := time.Now()
+
+	rate, err := exchangeRateAt(ctx, currency, paidAt)
+	if err != nil {
+		return models.Invoice{}, err
+	}
+
+	payment, err := newPayment(convertPaymentRequest(request, balance, currency, rate), balance)
+	if err != nil {
+		return models.Invoice{}, err
+	}
+	payment.Currency = currency
+	payment.ExchangeRate = rate
+	payment.ConvertedAmount = payment.Amount.Convert(rate, currency)
+	payment.ReceivedBy = c.GetString("uid")
+
+	if order, err := store.Orders.FindByID(ctx, invoice.OrderID); err == nil {
+		payment.WaiterID = order.WaiterID
+	}
+
+	amountPaid := amountPaid(invoice).Add(payment.Amount)
+	tipTotal := tipTotal(invoice).Add(payment.Tip)
+	balance = balance.Sub(payment.Amount)
+
+	invoice.Payments = append(invoice.Payments, payment)
+
+	status := unpaidStatus(invoice, paidAt)
+	if balance.Amount <= 0 {
+		status = models.PaymentStatusPaid
+	}
+
+	invoice.AmountPaid = &amountPaid
+	invoice.Balance = &balance
+	invoice.TipTotal = &tipTotal
+	invoice.PaymentStatus = &status
+	invoice.PaymentMethod = paymentMethod(invoice.Payments)
+	if err := convertInvoice(ctx, &invoice, paidAt); err != nil {
+		return models.Invoice{}, err
+	}
+	invoice.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	if err := chargeCard(ctx, &invoice.Payments[len(invoice.Payments)-1], invoice.InvoiceID, request.CardToken); err != nil {
+		return models.Invoice{}, err
+	}
+
+	err = store.Invoices.AddPayment(ctx, invoice)
+	if err != nil {
+		cancelCharge(ctx, invoice.Payments[len(invoice.Payments)-1])
+	}
+
+	if errors.Is(err, repositories.ErrConflict) {
+		return models.Invoice{}, helpers.HttpError{
+			Code:    http.StatusConflict,
+			Message: "another payment was recorded for the invoice, please retry",
+		}
+	}
+
+	if err != nil {
+		return models.Invoice{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "payment was not recorded",
+		}
+	}
+
+	if isPaid(invoice) {
+		if err := settleOrder(ctx, invoice.OrderID); err != nil {
+			return models.Invoice{}, err
+		}
+	}
+
+	return invoice, nil
+}
+
+// convertPaymentRequest takes the amounts of a payment made in another
+// currency to the base currency. Paying the balance as converted on the
+// invoice pays the whole balance, whatever the rounding.
+func convertPaymentRequest(request PaymentRequest, balance models.Money, currency string, rate float64) PaymentRequest {
+	if currency == models.DefaultCurrency {
+		return request
+	}
+
+	convertedBalance := balance.Convert(rate, currency)
+
+	toBase := func(amount *models.Money) *models.Money {
+		if amount == nil {
+			return nil
+		}
+
+		// Amounts are read in the base currency, so they are first taken
+		// to the minor units of the payment currency, as they were sent.
+		paid := amount.Convert(1, currency)
+		if paid == convertedBalance {
+			return &balance
+		}
+
+		converted := paid.Convert(1/rate, models.DefaultCurrency)
+		return &converted
+	}
+
+	request.Amount = toBase(request.Amount)
+	request.Tendered = toBase(request.Tendered)
+	request.Tip = toBase(request.Tip)
+
+	return request
+}
+
+// newPayment checks the payment against the balance of the invoice and works
+// out the tip and the change due for cash.
+func newPayment(request PaymentRequest, balance models.Money) (models.InvoicePayment, error) {
+	method := *request.Method
+	isCash := method == models.PaymentMethodCash
+
+	if request.Tendered != nil && !isCash {
+		return models.InvoicePayment{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "only cash can be tendered",
+		}
+	}
+
+	if request.Tip != nil && request.TipPercentage != nil {
+		return models.InvoicePayment{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "a tip is given either as an amount or as a percentage",
+		}
+	}
+
+	if request.Tip != nil && request.Tip.Amount < 0 {
+		return models.InvoicePayment{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "tip cannot be negative",
+		}
+	}
+
+	var amount models.Money
+	switch {
+	case request.Amount != nil:
+		amount = *request.Amount
+	case request.Tendered != nil:
+		available := *request.Tendered
+		if request.Tip != nil {
+			available = available.Sub(*request.Tip)
+		}
+		if request.TipPercentage != nil {
+			available = available.WithoutPercent(*request.TipPercentage)
+		}
+
+		amount = balance
+		if available.Amount < balance.Amount {
+			amount = available
+		}
+	default:
+		return models.InvoicePayment{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "amount is required",
+		}
+	}
+
+	if amount.Amount <= 0 || amount.Amount > balance.Amount {
+		return models.InvoicePayment{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: fmt.Sprintf("payment of %s does not fit the balance of %s", amount, balance),
+		}
+	}
+
+	payment := models.InvoicePayment{
+		PaymentID: primitive.NewObjectID().Hex(),
+		Method:    method,
+		Amount:    amount,
+		Tip:       sumMoney(),
+		ChangeDue: sumMoney(),
+		Reference: request.Reference,
+	}
+	payment.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	switch {
+	case request.Tip != nil:
+		payment.Tip = *request.Tip
+	case request.TipPercentage != nil:
+		payment.Tip = amount.Percent(*request.TipPercentage)
+	}
+
+	if request.Tendered != nil {
+		tendered := *request.Tendered
+		due := amount.Add(payment.Tip)
+		if tendered.Amount < due.Amount {
+			return models.InvoicePayment{}, helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: fmt.Sprintf("%s tendered does not cover the payment of %s", tendered, due),
+			}
+		}
+
+		payment.Tendered = &tendered
+		payment.ChangeDue = tendered.Sub(due)
+	}
+
+	return payment, nil
+}
+
+func amountPaid(invoice models.Invoice) models.Money {
+	if invoice.AmountPaid == nil {
+		return sumMoney()
+	}
+
+	return *invoice.AmountPaid
+}
+
+func tipTotal(invoice models.Invoice) models.Money {
+	if invoice.TipTotal == nil {
+		return sumMoney()
+	}
+
+	return *invoice.TipTotal
+}
+
+func invoiceBalance(invoice models.Invoice) models.Money {
+	if invoice.GrandTotal == nil {
+		return sumMoney()
+	}
+
+	return invoice.GrandTotal.Sub(amountPaid(invoice))
+}
+
+// paymentMethod returns the method the payments were made with, or MIXED if
+// there was more than one.
+func paymentMethod(payments []models.InvoicePayment) *string {
+	method := payments[0].Method
+	for _, payment := range payments {
+		if payment.Method != method {
+			method = models.PaymentMethodMixed
+		}
+	}
+
+	return &method
+}
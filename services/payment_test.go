@@ -0,0 +1,82 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/EnesDemirtas/restaurant-management/helpers"
+	"github.com/EnesDemirtas/restaurant-management/models"
+)
+
+func TestNewPayment(t *testing.T) {
+	cash := models.PaymentMethodCash
+	card := models.PaymentMethodCard
+	amount := func(value float64) *models.Money {
+		m := money(value)
+		return &m
+	}
+
+	tests := []struct {
+		name      string
+		request   PaymentRequest
+		wantErr   bool
+		amount    models.Money
+		changeDue models.Money
+	}{
+		{"part of the balance", PaymentRequest{Method: &card, Amount: amount(5)}, false, money(5), money(0)},
+		{"the whole balance", PaymentRequest{Method: &card, Amount: amount(20)}, false, money(20), money(0)},
+		{"more than the balance", PaymentRequest{Method: &card, Amount: amount(20.01)}, true, models.Money{}, models.Money{}},
+		{"nothing", PaymentRequest{Method: &card, Amount: amount(0)}, true, models.Money{}, models.Money{}},
+		{"no amount", PaymentRequest{Method: &cash}, true, models.Money{}, models.Money{}},
+		{"cash tendered over the balance", PaymentRequest{Method: &cash, Tendered: amount(50)}, false, money(20), money(30)},
+		{"cash tendered under the balance", PaymentRequest{Method: &cash, Tendered: amount(15)}, false, money(15), money(0)},
+		{"cash tendered with an amount", PaymentRequest{Method: &cash, Amount: amount(12.5), Tendered: amount(20)}, false, money(12.5), money(7.5)},
+		{"cash tendered short of the amount", PaymentRequest{Method: &cash, Amount: amount(12.5), Tendered: amount(10)}, true, models.Money{}, models.Money{}},
+		{"card tendered", PaymentRequest{Method: &card, Tendered: amount(20)}, true, models.Money{}, models.Money{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payment, err := newPayment(test.request, money(20))
+			if test.wantErr {
+				var httpError helpers.HttpError
+				if !errors.As(err, &httpError) {
+					t.Fatalf("got %v, want an http error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if payment.Amount != test.amount || payment.ChangeDue != test.changeDue {
+				t.Errorf("got %s with %s change, want %s with %s change", payment.Amount, payment.ChangeDue, test.amount, test.changeDue)
+			}
+		})
+	}
+}
+
+func TestPaymentMethod(t *testing.T) {
+	tests := []struct {
+		name    string
+		methods []string
+		want    string
+	}{
+		{"one payment", []string{models.PaymentMethodCard}, models.PaymentMethodCard},
+		{"one method", []string{models.PaymentMethodCash, models.PaymentMethodCash}, models.PaymentMethodCash},
+		{"mixed methods", []string{models.PaymentMethodCash, models.PaymentMethodGiftCard}, models.PaymentMethodMixed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payments := []models.InvoicePayment{}
+			for _, method := range test.methods {
+				payments = append(payments, models.InvoicePayment{Method: method})
+			}
+
+			if got := *paymentMethod(payments); got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
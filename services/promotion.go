@@ -0,0 +1,579 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/helpers"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/EnesDemirtas/restaurant-management/repositories"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func GetPromotions(c *gin.Context) ([]models.Promotion, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	allPromotions, err := store.Promotions.FindAll(ctx)
+	if err != nil {
+		return nil, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while listing promotions",
+		}
+	}
+
+	return allPromotions, nil
+}
+
+func GetPromotion(c *gin.Context) (models.Promotion, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	promotion, err := store.Promotions.FindByID(ctx, c.Param("id"))
+	if err != nil {
+		return models.Promotion{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "promotion was not found",
+		}
+	}
+
+	return promotion, nil
+}
+
+func CreatePromotion(c *gin.Context) (models.Promotion, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	var promotion models.Promotion
+
+	if err := c.BindJSON(&promotion); err != nil {
+		return models.Promotion{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	if promotion.Active == nil {
+		active := true
+		promotion.Active = &active
+	}
+
+	promotion.UsageCount = 0
+
+	if err := validatePromotion(ctx, &promotion); err != nil {
+		return models.Promotion{}, err
+	}
+
+	promotion.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	promotion.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	promotion.ID = primitive.NewObjectID()
+	promotion.PromotionID = promotion.ID.Hex()
+
+	insertErr := store.Promotions.Insert(ctx, promotion)
+	if insertErr != nil {
+		return models.Promotion{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "promotion was not created",
+		}
+	}
+
+	return promotion, nil
+}
+
+func UpdatePromotion(c *gin.Context) (models.Promotion, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	var promotion models.Promotion
+
+	if err := c.BindJSON(&promotion); err != nil {
+		return models.Promotion{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	foundPromotion, err := store.Promotions.FindByID(ctx, c.Param("id"))
+	if err != nil {
+		return models.Promotion{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "promotion was not found",
+		}
+	}
+
+	if promotion.Name != nil {
+		foundPromotion.Name = promotion.Name
+	}
+
+	if promotion.Type != nil {
+		foundPromotion.Type = promotion.Type
+	}
+
+	if promotion.Scope != nil {
+		foundPromotion.Scope = promotion.Scope
+	}
+
+	if promotion.Value != nil {
+		foundPromotion.Value = promotion.Value
+	}
+
+	if promotion.FoodIDs != nil {
+		foundPromotion.FoodIDs = promotion.FoodIDs
+	}
+
+	if promotion.BuyQuantity != nil {
+		foundPromotion.BuyQuantity = promotion.BuyQuantity
+	}
+
+	if promotion.GetQuantity != nil {
+		foundPromotion.GetQuantity = promotion.GetQuantity
+	}
+
+	if promotion.CouponCode != nil {
+		foundPromotion.CouponCode = promotion.CouponCode
+	}
+
+	if promotion.UsageLimit != nil {
+		foundPromotion.UsageLimit = promotion.UsageLimit
+	}
+
+	if promotion.StartsAt != nil {
+		foundPromotion.StartsAt = promotion.StartsAt
+	}
+
+	if promotion.ExpiresAt != nil {
+		foundPromotion.ExpiresAt = promotion.ExpiresAt
+	}
+
+	if promotion.Stackable != nil {
+		foundPromotion.Stackable = promotion.Stackable
+	}
+
+	if promotion.Priority != nil {
+		foundPromotion.Priority = promotion.Priority
+	}
+
+	if promotion.Active != nil {
+		foundPromotion.Active = promotion.Active
+	}
+
+	if err := validatePromotion(ctx, &foundPromotion); err != nil {
+		return models.Promotion{}, err
+	}
+
+	foundPromotion.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	err = store.Promotions.Update(ctx, foundPromotion)
+	if err != nil {
+		return models.Promotion{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "promotion update failed",
+		}
+	}
+
+	return foundPromotion, nil
+}
+
+func validatePromotion(ctx context.Context, promotion *models.Promotion) error {
+	validationErr := validate.Struct(promotion)
+	if validationErr != nil {
+		return helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: validationErr.Error(),
+		}
+	}
+
+	invalid := func(message string) error {
+		return helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: message,
+		}
+	}
+
+	switch *promotion.Type {
+	case models.PromotionTypePercentage:
+		if promotion.Value == nil || *promotion.Value > 100 {
+			return invalid("a percentage promotion needs a value between 0 and 100")
+		}
+	case models.PromotionTypeFixed:
+		if promotion.Value == nil {
+			return invalid("a fixed promotion needs a value")
+		}
+	case models.PromotionTypeBuyXGetY:
+		if promotion.BuyQuantity == nil || promotion.GetQuantity == nil {
+			return invalid("a buy X get Y promotion needs buy_quantity and get_quantity")
+		}
+		if *promotion.Scope != models.PromotionScopeItem {
+			return invalid("a buy X get Y promotion applies to items")
+		}
+	}
+
+	if promotion.StartsAt != nil && promotion.ExpiresAt != nil && !promotion.ExpiresAt.After(*promotion.StartsAt) {
+		return invalid("a promotion must expire after it starts")
+	}
+
+	if promotion.CouponCode != nil {
+		couponCode := normalizeCouponCode(*promotion.CouponCode)
+		promotion.CouponCode = &couponCode
+
+		found, err := store.Promotions.FindByCouponCode(ctx, couponCode)
+		if err == nil && found.PromotionID != promotion.PromotionID {
+			return helpers.HttpError{
+				Code:    http.StatusConflict,
+				Message: fmt.Sprintf("coupon code %s is already used by another promotion", couponCode),
+			}
+		}
+	}
+
+	return nil
+}
+
+func normalizeCouponCode(couponCode string) string {
+	return strings.ToUpper(strings.TrimSpace(couponCode))
+}
+
+// checkCoupons normalizes the coupon codes given for an invoice and returns
+// their promotions, if they can all be used now and together.
+func checkCoupons(ctx context.Context, couponCodes []string) ([]string, []models.Promotion, error) {
+	invalid := func(format string, args ...interface{}) error {
+		return helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: fmt.Sprintf(format, args...),
+		}
+	}
+
+	now := time.Now()
+	codes := []string{}
+	coupons := []models.Promotion{}
+	seen := map[string]bool{}
+
+	for _, couponCode := range couponCodes {
+		couponCode = normalizeCouponCode(couponCode)
+		if seen[couponCode] {
+			continue
+		}
+		seen[couponCode] = true
+
+		coupon, err := store.Promotions.FindByCouponCode(ctx, couponCode)
+		if err != nil || !isTrue(coupon.Active) {
+			return nil, nil, invalid("coupon code %s is not valid", couponCode)
+		}
+
+		if coupon.StartsAt != nil && now.Before(*coupon.StartsAt) {
+			return nil, nil, invalid("coupon code %s is not valid yet", couponCode)
+		}
+
+		if coupon.ExpiresAt != nil && !now.Before(*coupon.ExpiresAt) {
+			return nil, nil, invalid("coupon code %s has expired", couponCode)
+		}
+
+		if coupon.UsageLimit != nil && coupon.UsageCount >= *coupon.UsageLimit {
+			return nil, nil, invalid("coupon code %s has reached its usage limit", couponCode)
+		}
+
+		codes = append(codes, couponCode)
+		coupons = append(coupons, coupon)
+	}
+
+	if len(coupons) > 1 {
+		for _, coupon := range coupons {
+			if !isTrue(coupon.Stackable) {
+				return nil, nil, invalid("coupon code %s cannot be combined with other coupons", *coupon.CouponCode)
+			}
+		}
+	}
+
+	return codes, coupons, nil
+}
+
+// checkCouponsApplied rejects the coupons that gave no discount on the
+// invoices they were given for.
+func checkCouponsApplied(coupons []models.Promotion, invoices []models.Invoice) error {
+	applied := map[string]bool{}
+	for _, invoice := range invoices {
+		for _, discount := range invoice.Discounts {
+			applied[discount.PromotionID] = true
+		}
+	}
+
+	for _, coupon := range coupons {
+		if !applied[coupon.PromotionID] {
+			return helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: fmt.Sprintf("coupon code %s does not apply to this order", *coupon.CouponCode),
+			}
+		}
+	}
+
+	return nil
+}
+
+// redeemCoupons counts a use of every coupon. If one of them can't be
+// redeemed, the uses already counted are given back.
+func redeemCoupons(ctx context.Context, coupons []models.Promotion) error {
+	updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	for i, coupon := range coupons {
+		err := store.Promotions.Redeem(ctx, coupon.PromotionID, updatedAt)
+		if err != nil {
+			unredeemCoupons(ctx, coupons[:i])
+		}
+
+		if errors.Is(err, repositories.ErrConflict) {
+			return helpers.HttpError{
+				Code:    http.StatusConflict,
+				Message: fmt.Sprintf("coupon code %s has reached its usage limit", *coupon.CouponCode),
+			}
+		}
+
+		if err != nil {
+			return helpers.HttpError{
+				Code:    http.StatusInternalServerError,
+				Message: "error occured while redeeming the coupons",
+			}
+		}
+	}
+
+	return nil
+}
+
+// unredeemCoupons gives back the uses of coupons whose invoices were not
+// created after all.
+func unredeemCoupons(ctx context.Context, coupons []models.Promotion) {
+	updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	for _, coupon := range coupons {
+		store.Promotions.Unredeem(ctx, coupon.PromotionID, updatedAt)
+	}
+}
+
+// billingPromotions returns the promotions running now that apply without a
+// coupon, and the promotions of the coupons given for an invoice. Coupons
+// are only checked when the invoice is created, so they keep applying to it.
+func billingPromotions(ctx context.Context, couponCodes []string) ([]models.Promotion, []models.Promotion, error) {
+	allPromotions, err := store.Promotions.FindAll(ctx)
+	if err != nil {
+		return nil, nil, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while listing promotions",
+		}
+	}
+
+	now := time.Now()
+	automatic := []models.Promotion{}
+	coupons := []models.Promotion{}
+
+	for _, promotion := range allPromotions {
+		if promotion.CouponCode != nil {
+			for _, couponCode := range couponCodes {
+				if couponCode == *promotion.CouponCode {
+					coupons = append(coupons, promotion)
+				}
+			}
+			continue
+		}
+
+		running := isTrue(promotion.Active) &&
+			(promotion.StartsAt == nil || !now.Before(*promotion.StartsAt)) &&
+			(promotion.ExpiresAt == nil || now.Before(*promotion.ExpiresAt))
+
+		if running {
+			automatic = append(automatic, promotion)
+		}
+	}
+
+	return automatic, coupons, nil
+}
+
+// applyPromotions takes the best combination of promotions off the lines.
+// Stackable promotions combine with each other while the others only apply
+// on their own. Coupons are always honored: a coupon that doesn't stack is
+// applied alone, otherwise the coupons are applied with the stackable
+// promotions. Without coupons, whichever of the stackable promotions together
+// or a single other promotion gives the larger discount is applied.
+func applyPromotions(lines []invoiceLine, automatic, coupons []models.Promotion) ([]invoiceLine, []models.InvoiceDiscount) {
+	var candidates [][]models.Promotion
+
+	for _, coupon := range coupons {
+		if !isTrue(coupon.Stackable) {
+			candidates = [][]models.Promotion{{coupon}}
+		}
+	}
+
+	if candidates == nil {
+		stackable := append([]models.Promotion{}, coupons...)
+		for _, promotion := range automatic {
+			if isTrue(promotion.Stackable) {
+				stackable = append(stackable, promotion)
+			}
+		}
+		candidates = append(candidates, stackable)
+
+		if len(coupons) == 0 {
+			for _, promotion := range automatic {
+				if !isTrue(promotion.Stackable) {
+					candidates = append(candidates, []models.Promotion{promotion})
+				}
+			}
+		}
+	}
+
+	bestLines, bestDiscounts, bestTotal := discountLines(lines, candidates[0])
+	for _, candidate := range candidates[1:] {
+		discountedLines, discounts, total := discountLines(lines, candidate)
+		if total.Amount > bestTotal.Amount {
+			bestLines, bestDiscounts, bestTotal = discountedLines, discounts, total
+		}
+	}
+
+	return bestLines, bestDiscounts
+}
+
+// discountLines applies the promotions one after the other, item promotions
+// first and then by priority, each on what is left to pay for the lines.
+func discountLines(lines []invoiceLine, promotions []models.Promotion) ([]invoiceLine, []models.InvoiceDiscount, models.Money) {
+	promotions = append([]models.Promotion{}, promotions...)
+	sort.SliceStable(promotions, func(i, j int) bool {
+		iItem := *promotions[i].Scope == models.PromotionScopeItem
+		jItem := *promotions[j].Scope == models.PromotionScopeItem
+		if iItem != jItem {
+			return iItem
+		}
+
+		return intValue(promotions[i].Priority) < intValue(promotions[j].Priority)
+	})
+
+	discounted := make([]invoiceLine, len(lines))
+	for i, line := range lines {
+		discounted[i] = line
+		discounted[i].PromotionDiscounts = map[string]models.Money{}
+	}
+
+	discounts := []models.InvoiceDiscount{}
+	total := sumMoney()
+
+	for _, promotion := range promotions {
+		amounts := promotionAmounts(discounted, promotion)
+
+		promotionTotal := sumMoney()
+		for i, amount := range amounts {
+			if amount.Amount > discounted[i].Amount.Amount {
+				amount = discounted[i].Amount
+			}
+
+			if amount.Amount <= 0 {
+				continue
+			}
+
+			discounted[i].Discount = discounted[i].Discount.Add(amount)
+			discounted[i].Amount = discounted[i].Amount.Sub(amount)
+			discounted[i].PromotionDiscounts[promotion.PromotionID] = discounted[i].PromotionDiscounts[promotion.PromotionID].Add(amount)
+			promotionTotal = promotionTotal.Add(amount)
+		}
+
+		if promotionTotal.Amount <= 0 {
+			continue
+		}
+
+		discounts = append(discounts, models.InvoiceDiscount{
+			PromotionID: promotion.PromotionID,
+			Name:        *promotion.Name,
+			Type:        *promotion.Type,
+			Scope:       *promotion.Scope,
+			CouponCode:  promotion.CouponCode,
+			Amount:      promotionTotal,
+		})
+		total = total.Add(promotionTotal)
+	}
+
+	return discounted, discounts, total
+}
+
+// promotionAmounts returns the discount the promotion gives on each line.
+func promotionAmounts(lines []invoiceLine, promotion models.Promotion) []models.Money {
+	amounts := make([]models.Money, len(lines))
+
+	var eligible []int
+	for i, line := range lines {
+		if line.Amount.Amount > 0 && promotionApplies(promotion, line) {
+			eligible = append(eligible, i)
+		}
+	}
+
+	if len(eligible) == 0 {
+		return amounts
+	}
+
+	if *promotion.Type == models.PromotionTypeBuyXGetY {
+		sort.SliceStable(eligible, func(a, b int) bool {
+			return lines[eligible[a]].UnitPrice.Amount < lines[eligible[b]].UnitPrice.Amount
+		})
+
+		units := 0
+		for _, i := range eligible {
+			units += lines[i].Quantity
+		}
+
+		free := units / (*promotion.BuyQuantity + *promotion.GetQuantity) * *promotion.GetQuantity
+		for _, i := range eligible {
+			freeUnits := min(free, lines[i].Quantity)
+			amounts[i] = lines[i].UnitPrice.Mul(freeUnits)
+			free -= freeUnits
+		}
+
+		return amounts
+	}
+
+	if *promotion.Scope == models.PromotionScopeItem {
+		for _, i := range eligible {
+			if *promotion.Type == models.PromotionTypePercentage {
+				amounts[i] = lines[i].Amount.Percent(*promotion.Value)
+			} else {
+				amounts[i] = money(*promotion.Value).Mul(lines[i].Quantity)
+			}
+		}
+
+		return amounts
+	}
+
+	base := sumMoney()
+	shares := make([]int64, len(eligible))
+	for j, i := range eligible {
+		base = base.Add(lines[i].Amount)
+		shares[j] = lines[i].Amount.Amount
+	}
+
+	discount := money(*promotion.Value)
+	if *promotion.Type == models.PromotionTypePercentage {
+		discount = base.Percent(*promotion.Value)
+	}
+	if discount.Amount > base.Amount {
+		discount = base
+	}
+
+	for j, amount := range allocate(discount, shares) {
+		amounts[eligible[j]] = amount
+	}
+
+	return amounts
+}
+
+func promotionApplies(promotion models.Promotion, line invoiceLine) bool {
+	if len(promotion.FoodIDs) == 0 {
+		return true
+	}
+
+	for _, foodId := range promotion.FoodIDs {
+		if foodId == line.FoodID {
+			return true
+		}
+	}
+
+	return false
+}
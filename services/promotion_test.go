@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/EnesDemirtas/restaurant-management/repositories"
+)
+
+func newTestPromotion(id, promotionType, scope string, value float64, stackable bool) models.Promotion {
+	name := id
+	active := true
+	promotion := models.Promotion{
+		PromotionID: id,
+		Name:        &name,
+		Type:        &promotionType,
+		Scope:       &scope,
+		Stackable:   &stackable,
+		Active:      &active,
+	}
+
+	if promotionType == models.PromotionTypeBuyXGetY {
+		buy, get := int(value), 1
+		promotion.BuyQuantity = &buy
+		promotion.GetQuantity = &get
+	} else {
+		promotion.Value = &value
+	}
+
+	return promotion
+}
+
+func TestApplyPromotions(t *testing.T) {
+	lines := []invoiceLine{
+		{InvoiceLine: models.InvoiceLine{OrderItemID: "a", FoodID: "burger", Quantity: 2, UnitPrice: money(10), Amount: money(20)}},
+		{InvoiceLine: models.InvoiceLine{OrderItemID: "b", FoodID: "fries", Quantity: 3, UnitPrice: money(3.5), Amount: money(10.5)}},
+	}
+
+	tenPercent := newTestPromotion("ten-percent", models.PromotionTypePercentage, models.PromotionScopeInvoice, 10, false)
+	fiveOff := newTestPromotion("five-off", models.PromotionTypeFixed, models.PromotionScopeInvoice, 5, true)
+	burgerOff := newTestPromotion("burger-off", models.PromotionTypeFixed, models.PromotionScopeItem, 1, true)
+	burgerOff.FoodIDs = []string{"burger"}
+	threeForTwo := newTestPromotion("three-for-two", models.PromotionTypeBuyXGetY, models.PromotionScopeItem, 2, false)
+
+	tests := []struct {
+		name      string
+		automatic []models.Promotion
+		coupons   []models.Promotion
+		want      map[string]models.Money
+	}{
+		{"none", nil, nil, map[string]models.Money{}},
+		{"percentage of the invoice", []models.Promotion{tenPercent}, nil, map[string]models.Money{"ten-percent": money(3.05)}},
+		{"per unit of a food", []models.Promotion{burgerOff}, nil, map[string]models.Money{"burger-off": money(2)}},
+		{"cheapest units free", []models.Promotion{threeForTwo}, nil, map[string]models.Money{"three-for-two": money(3.5)}},
+		{"stackable together beat a larger single one", []models.Promotion{tenPercent, fiveOff, burgerOff}, nil, map[string]models.Money{"burger-off": money(2), "five-off": money(5)}},
+		{"single one beats smaller stackable ones", []models.Promotion{tenPercent, burgerOff}, nil, map[string]models.Money{"ten-percent": money(3.05)}},
+		{"coupon that doesn't stack applies alone", []models.Promotion{fiveOff}, []models.Promotion{threeForTwo}, map[string]models.Money{"three-for-two": money(3.5)}},
+		{"stackable coupon adds to stackable promotions", []models.Promotion{tenPercent, burgerOff}, []models.Promotion{fiveOff}, map[string]models.Money{"burger-off": money(2), "five-off": money(5)}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			discounted, discounts := applyPromotions(lines, test.automatic, test.coupons)
+
+			got := map[string]models.Money{}
+			for _, discount := range discounts {
+				got[discount.PromotionID] = discount.Amount
+			}
+
+			if len(got) != len(test.want) {
+				t.Fatalf("got discounts %v, want %v", got, test.want)
+			}
+			for id, amount := range test.want {
+				if got[id] != amount {
+					t.Errorf("got %s off for %s, want %s", got[id], id, amount)
+				}
+			}
+
+			// The discounts are taken off the lines, never below zero.
+			linesDiscount := sumMoney()
+			for i, line := range discounted {
+				if line.Amount.Amount < 0 || line.Amount.Add(line.Discount) != lines[i].Amount {
+					t.Errorf("line %s: got %s with %s off, want %s in all", line.OrderItemID, line.Amount, line.Discount, lines[i].Amount)
+				}
+				linesDiscount = linesDiscount.Add(line.Discount)
+			}
+
+			if total := *discountTotal(discounts); linesDiscount != total {
+				t.Errorf("lines were discounted %s, want %s", linesDiscount, total)
+			}
+		})
+	}
+}
+
+func TestCheckCoupons(t *testing.T) {
+	ctx := context.Background()
+	UseStore(repositories.NewMemoryStore())
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	limit := 1
+
+	coupon := func(code string, stackable bool, change func(*models.Promotion)) {
+		promotion := newTestPromotion(code, models.PromotionTypePercentage, models.PromotionScopeInvoice, 10, stackable)
+		promotion.CouponCode = &code
+		if change != nil {
+			change(&promotion)
+		}
+
+		if err := store.Promotions.Insert(ctx, promotion); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	coupon("WELCOME", false, nil)
+	coupon("STACK1", true, nil)
+	coupon("STACK2", true, nil)
+	coupon("EXPIRED", true, func(p *models.Promotion) { p.ExpiresAt = &past })
+	coupon("UPCOMING", true, func(p *models.Promotion) { p.StartsAt = &future })
+	coupon("INACTIVE", true, func(p *models.Promotion) { p.Active = new(bool) })
+	coupon("ONCE", true, func(p *models.Promotion) { p.UsageLimit = &limit; p.UsageCount = 1 })
+
+	tests := []struct {
+		name       string
+		codes      []string
+		wantStatus int
+		want       []string
+	}{
+		{"normalized", []string{" welcome "}, http.StatusOK, []string{"WELCOME"}},
+		{"given twice", []string{"stack1", "STACK1"}, http.StatusOK, []string{"STACK1"}},
+		{"stacked", []string{"STACK1", "STACK2"}, http.StatusOK, []string{"STACK1", "STACK2"}},
+		{"combined with one that doesn't stack", []string{"WELCOME", "STACK1"}, http.StatusBadRequest, nil},
+		{"unknown", []string{"NOPE"}, http.StatusBadRequest, nil},
+		{"expired", []string{"EXPIRED"}, http.StatusBadRequest, nil},
+		{"not started", []string{"UPCOMING"}, http.StatusBadRequest, nil},
+		{"inactive", []string{"INACTIVE"}, http.StatusBadRequest, nil},
+		{"used up", []string{"ONCE"}, http.StatusBadRequest, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			codes, coupons, err := checkCoupons(ctx, test.codes)
+			if status := httpStatus(err); status != test.wantStatus {
+				t.Fatalf("got status %d (%v), want %d", status, err, test.wantStatus)
+			}
+
+			if len(codes) != len(test.want) || len(coupons) != len(test.want) {
+				t.Fatalf("got codes %v, want %v", codes, test.want)
+			}
+			for i, code := range test.want {
+				if codes[i] != code || *coupons[i].CouponCode != code {
+					t.Errorf("got code %s for %s, want %s", codes[i], *coupons[i].CouponCode, code)
+				}
+			}
+		})
+	}
+}
+
+func TestRedeemCoupons(t *testing.T) {
+	ctx := context.Background()
+	UseStore(repositories.NewMemoryStore())
+
+	limit := 1
+	first := newTestPromotion("first", models.PromotionTypeFixed, models.PromotionScopeInvoice, 1, true)
+	second := newTestPromotion("second", models.PromotionTypeFixed, models.PromotionScopeInvoice, 1, true)
+	for _, promotion := range []*models.Promotion{&first, &second} {
+		code := promotion.PromotionID
+		promotion.CouponCode = &code
+		promotion.UsageLimit = &limit
+		if err := store.Promotions.Insert(ctx, *promotion); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := store.Promotions.Redeem(ctx, "second", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The second coupon is used up, so the use of the first one is given back.
+	if err := redeemCoupons(ctx, []models.Promotion{first, second}); httpStatus(err) != http.StatusConflict {
+		t.Fatalf("got %v, want a conflict", err)
+	}
+
+	stored, err := store.Promotions.FindByID(ctx, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.UsageCount != 0 {
+		t.Errorf("got %d uses of the first coupon, want 0", stored.UsageCount)
+	}
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/EnesDemirtas/restaurant-management/helpers"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Receipt is a rendered invoice, ready to be sent as is.
+type Receipt struct {
+	ContentType string
+	Body        []byte
+}
+
+// receiptWidths is the number of characters printed on a line of thermal
+// paper of each width, in millimeters.
+var receiptWidths = map[string]int{
+	"58": 32,
+	"80": 48,
+}
+
+const (
+	// PDF receipts are A4 pages printed in 10pt Courier, 6pt wide, leaving an
+	// inch of margin on both sides.
+	pdfPageWidth    = 595.28
+	pdfPageHeight   = 841.89
+	pdfFontSize     = 10
+	pdfMargin       = 72
+	pdfReceiptWidth = 72
+)
+
+// restaurantSettings is the header printed on receipts, configured with
+// RESTAURANT_NAME, RESTAURANT_ADDRESS, RESTAURANT_PHONE and RESTAURANT_TAX_ID,
+// the RESTAURANT_CODE fiscal numbers are prefixed with, and the
+// RESTAURANT_TIMEZONE local times are in.
+type restaurantSettings struct {
+	Code     string
+	Name     string
+	Address  string
+	Phone    string
+	TaxID    string
+	Timezone string
+}
+
+func loadRestaurantSettings() restaurantSettings {
+	return restaurantSettings{
+		Code:     os.Getenv("RESTAURANT_CODE"),
+		Name:     os.Getenv("RESTAURANT_NAME"),
+		Address:  os.Getenv("RESTAURANT_ADDRESS"),
+		Phone:    os.Getenv("RESTAURANT_PHONE"),
+		TaxID:    os.Getenv("RESTAURANT_TAX_ID"),
+		Timezone: os.Getenv("RESTAURANT_TIMEZONE"),
+	}
+}
+
+// restaurantLocation returns the timezone of the restaurant, UTC unless
+// RESTAURANT_TIMEZONE is set.
+func restaurantLocation() *time.Location {
+	timezone := loadRestaurantSettings().Timezone
+	if timezone == "" {
+		return time.UTC
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		log.Printf("Ignoring RESTAURANT_TIMEZONE %q: %s", timezone, err)
+		return time.UTC
+	}
+
+	return location
+}
+
+// GetReceipt renders an invoice as a PDF, or as plain text laid out for a
+// thermal printer with format=text and width=58 or width=80, in millimeters.
+func GetReceipt(c *gin.Context) (Receipt, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	format := c.DefaultQuery("format", "pdf")
+	if format != "pdf" && format != "text" {
+		return Receipt{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "format must be pdf or text",
+		}
+	}
+
+	width, ok := receiptWidths[c.DefaultQuery("width", "80")]
+	if !ok {
+		return Receipt{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "width must be 58 or 80",
+		}
+	}
+
+	invoice, err := store.Invoices.FindByID(ctx, c.Param("id"))
+	if err != nil {
+		return Receipt{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "invoice was not found",
+		}
+	}
+
+	ledger, err := invoiceLedger(ctx, invoice.InvoiceID)
+	if err != nil {
+		return Receipt{}, err
+	}
+
+	lines, table := billedLines(ctx, invoice)
+
+	if format == "text" {
+		text := strings.Join(receiptLines(invoice, lines, table, ledger, width), "\n") + "\n"
+		return Receipt{ContentType: "text/plain; charset=utf-8", Body: []byte(text)}, nil
+	}
+
+	body := helpers.TextPDF(receiptLines(invoice, lines, table, ledger, pdfReceiptWidth), pdfPageWidth, pdfPageHeight, pdfFontSize, pdfMargin)
+	return Receipt{ContentType: "application/pdf", Body: body}, nil
+}
+
+// receiptLines lays out the invoice on lines of the given number of
+// characters: the restaurant header, the items, the totals and the payments.
+func receiptLines(invoice models.Invoice, lines []invoiceLine, table models.Table, ledger ledgerSummary, width int) []string {
+	rule := strings.Repeat("-", width)
+	settings := loadRestaurantSettings()
+
+	var receipt []string
+	for _, header := range []string{settings.Name, settings.Address, settings.Phone} {
+		if header != "" {
+			receipt = append(receipt, centerText(header, width))
+		}
+	}
+	if settings.TaxID != "" {
+		receipt = append(receipt, centerText("Tax ID "+settings.TaxID, width))
+	}
+	receipt = append(receipt, rule)
+
+	invoiceNumber := invoice.InvoiceID
+	if invoice.FiscalNumber != nil {
+		invoiceNumber = invoice.FiscalNumber.Number
+	}
+
+	receipt = append(receipt,
+		receiptRow("Invoice", invoiceNumber, width),
+		receiptRow("Date", invoice.CreatedAt.In(restaurantLocation()).Format("2006-01-02 15:04"), width),
+	)
+	if table.TableNumber != nil {
+		receipt = append(receipt, receiptRow("Table", fmt.Sprint(*table.TableNumber), width))
+	}
+	if invoice.SplitIndex != nil && invoice.SplitCount != nil {
+		receipt = append(receipt, receiptRow("Bill", fmt.Sprintf("%d of %d", *invoice.SplitIndex, *invoice.SplitCount), width))
+	}
+	receipt = append(receipt, rule)
+
+	for _, line := range lines {
+		name := line.FoodName
+		if line.VariantName != "" {
+			name += " " + line.VariantName
+		}
+		receipt = append(receipt, receiptRow(fmt.Sprintf("%d x %s", line.Quantity, name), line.Amount.String(), width))
+		for _, component := range line.ComboComponents {
+			receipt = append(receipt, receiptRow("  "+strings.TrimSpace(component.FoodName+" "+component.VariantName), "", width))
+		}
+		for _, modifier := range line.Modifiers {
+			receipt = append(receipt, receiptRow("  "+modifier.Name, "", width))
+		}
+		if line.Discount.Amount != 0 {
+			receipt = append(receipt, receiptRow("  Discount", "-"+line.Discount.String(), width))
+		}
+	}
+	receipt = append(receipt, rule)
+
+	if invoice.GrandTotal == nil {
+		return append(receipt, centerText("Not billed yet", width))
+	}
+
+	for _, discount := range invoice.Discounts {
+		receipt = append(receipt, receiptRow(discount.Name, "-"+discount.Amount.String(), width))
+	}
+	if invoice.Subtotal != nil {
+		receipt = append(receipt, receiptRow("Subtotal", invoice.Subtotal.String(), width))
+	}
+	if invoice.ServiceCharge != nil && invoice.ServiceCharge.Amount != 0 {
+		receipt = append(receipt, receiptRow("Service charge", invoice.ServiceCharge.String(), width))
+	}
+	for _, taxLine := range invoice.TaxLines {
+		name := fmt.Sprintf("%s %g%%", taxLine.Name, taxLine.Rate)
+		if taxLine.Inclusive {
+			name += " incl."
+		}
+		receipt = append(receipt, receiptRow(name, taxLine.Amount.String(), width))
+	}
+	receipt = append(receipt, receiptRow("TOTAL "+models.DefaultCurrency, invoice.GrandTotal.String(), width))
+	if currency := paymentCurrency(invoice); currency != models.DefaultCurrency && invoice.ConvertedTotal != nil && invoice.ExchangeRate != nil {
+		receipt = append(receipt,
+			receiptRow("TOTAL "+currency, invoice.ConvertedTotal.String(), width),
+			receiptRow("  Rate", fmt.Sprintf("1 %s = %g %s", models.DefaultCurrency, *invoice.ExchangeRate, currency), width),
+		)
+	}
+
+	if len(invoice.Payments) > 0 {
+		receipt = append(receipt, rule)
+	}
+	for _, payment := range invoice.Payments {
+		receipt = append(receipt, receiptRow(payment.Method, payment.Amount.String(), width))
+		if payment.Currency != "" && payment.Currency != models.DefaultCurrency {
+			receipt = append(receipt, receiptRow("  Paid in "+payment.Currency, payment.ConvertedAmount.String(), width))
+		}
+		if payment.Tendered != nil {
+			receipt = append(receipt,
+				receiptRow("  Tendered", payment.Tendered.String(), width),
+				receiptRow("  Change", payment.ChangeDue.String(), width),
+			)
+		}
+		if payment.Tip.Amount != 0 {
+			receipt = append(receipt, receiptRow("  Tip", payment.Tip.String(), width))
+		}
+	}
+	if invoice.TipTotal != nil && invoice.TipTotal.Amount != 0 {
+		receipt = append(receipt, receiptRow("Tips", invoice.TipTotal.String(), width))
+	}
+	receipt = append(receipt, receiptRow("Balance", invoiceBalance(invoice).String(), width))
+
+	if ledger.Refunded.Amount != 0 {
+		receipt = append(receipt, receiptRow("Refunded", ledger.Refunded.String(), width))
+	}
+	if ledger.Voided {
+		receipt = append(receipt, centerText("*** VOID ***", width))
+	}
+
+	return append(receipt, rule, centerText("Thank you!", width))
+}
+
+// receiptRow prints the label on the left of the line and the value on the
+// right, cutting the label short if both don't fit.
+func receiptRow(label, value string, width int) string {
+	space := width - utf8.RuneCountInString(value) - 1
+	if space < 0 {
+		return value
+	}
+
+	label = truncateText(label, space)
+	return label + strings.Repeat(" ", width-utf8.RuneCountInString(label)-utf8.RuneCountInString(value)) + value
+}
+
+func centerText(text string, width int) string {
+	text = truncateText(text, width)
+	return strings.Repeat(" ", (width-utf8.RuneCountInString(text))/2) + text
+}
+
+func truncateText(text string, width int) string {
+	if utf8.RuneCountInString(text) <= width {
+		return text
+	}
+
+	return string([]rune(text)[:width])
+}
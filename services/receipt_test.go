@@ -0,0 +1,45 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+)
+
+func TestReceiptLinesSplitInvoice(t *testing.T) {
+	splitIndex, splitCount := 2, 3
+	grandTotal := money(11.16)
+	invoice := models.Invoice{
+		InvoiceID:  "i1",
+		SplitIndex: &splitIndex,
+		SplitCount: &splitCount,
+		GrandTotal: &grandTotal,
+		Subtotal:   &grandTotal,
+	}
+
+	// A line shared between three payers is printed at the share billed on
+	// this invoice, not at the price of the whole line.
+	lines := []invoiceLine{
+		{InvoiceLine: models.InvoiceLine{FoodName: "Burger", Quantity: 3, UnitPrice: money(10), Amount: money(10)}},
+		{InvoiceLine: models.InvoiceLine{FoodName: "Fries", Quantity: 1, UnitPrice: money(3.5), Amount: money(1.16)}},
+	}
+
+	receipt := receiptLines(invoice, lines, models.Table{}, ledgerSummary{}, 32)
+
+	for _, want := range []string{
+		receiptRow("Bill", "2 of 3", 32),
+		receiptRow("3 x Burger", "10.00", 32),
+		receiptRow("1 x Fries", "1.16", 32),
+		receiptRow("TOTAL "+models.DefaultCurrency, "11.16", 32),
+	} {
+		found := false
+		for _, line := range receipt {
+			found = found || line == want
+		}
+
+		if !found {
+			t.Errorf("receipt has no line %q:\n%s", want, strings.Join(receipt, "\n"))
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/helpers"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/gin-gonic/gin"
+)
+
+// RevenueReport is the revenue of each period of the range.
+type RevenueReport struct {
+	models.ReportRange
+	Timezone string                 `json:"timezone"`
+	Period   string                 `json:"period"`
+	Revenue  []models.PeriodRevenue `json:"revenue"`
+}
+
+// reportRange reads the range a report covers from the from and to query
+// parameters, dates in the timezone of the tz query, the timezone of the
+// restaurant unless set. Both dates are included, and the range defaults to
+// the last 30 days.
+func reportRange(c *gin.Context) (models.ReportRange, error) {
+	location, err := time.LoadLocation(c.DefaultQuery("tz", restaurantLocation().String()))
+	if err != nil || location == time.Local {
+		return models.ReportRange{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "tz must be an IANA timezone, such as Europe/Istanbul",
+		}
+	}
+
+	now := time.Now().In(location)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+	if date := c.Query("to"); date != "" {
+		to, err = time.ParseInLocation(time.DateOnly, date, location)
+		if err != nil {
+			return models.ReportRange{}, helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: "to must be a date such as 2024-03-01",
+			}
+		}
+	}
+
+	from := to.AddDate(0, 0, -29)
+	if date := c.Query("from"); date != "" {
+		from, err = time.ParseInLocation(time.DateOnly, date, location)
+		if err != nil {
+			return models.ReportRange{}, helpers.HttpError{
+				Code:    http.StatusBadRequest,
+				Message: "from must be a date such as 2024-03-01",
+			}
+		}
+	}
+
+	if to.Before(from) {
+		return models.ReportRange{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "from must not be after to",
+		}
+	}
+
+	return models.ReportRange{From: from, To: to.AddDate(0, 0, 1), Location: location}, nil
+}
+
+// GetRevenueReport totals the revenue by day, week or month with the period
+// query, by day unless set.
+func GetRevenueReport(c *gin.Context) (RevenueReport, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	reportRange, err := reportRange(c)
+	if err != nil {
+		return RevenueReport{}, err
+	}
+
+	period := c.DefaultQuery("period", models.ReportPeriodDay)
+	if period != models.ReportPeriodDay && period != models.ReportPeriodWeek && period != models.ReportPeriodMonth {
+		return RevenueReport{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "period must be day, week or month",
+		}
+	}
+
+	revenue, err := store.Reports.RevenueByPeriod(ctx, reportRange, period)
+	if err != nil {
+		return RevenueReport{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while reporting revenue",
+		}
+	}
+
+	return RevenueReport{
+		ReportRange: reportRange,
+		Timezone:    reportRange.Location.String(),
+		Period:      period,
+		Revenue:     revenue,
+	}, nil
+}
+
+func GetTicketReport(c *gin.Context) (models.TicketSummary, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	reportRange, err := reportRange(c)
+	if err != nil {
+		return models.TicketSummary{}, err
+	}
+
+	summary, err := store.Reports.Tickets(ctx, reportRange)
+	if err != nil {
+		return models.TicketSummary{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while reporting tickets",
+		}
+	}
+
+	return summary, nil
+}
+
+func GetTableReport(c *gin.Context) ([]models.TableRevenue, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	reportRange, err := reportRange(c)
+	if err != nil {
+		return nil, err
+	}
+
+	revenues, err := store.Reports.RevenueByTable(ctx, reportRange)
+	if err != nil {
+		return nil, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while reporting revenue per table",
+		}
+	}
+
+	return revenues, nil
+}
+
+// GetTopFoodsReport lists the foods that sold the most, 10 of them unless the
+// limit query says otherwise.
+func GetTopFoodsReport(c *gin.Context) ([]models.FoodSales, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	reportRange, err := reportRange(c)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 {
+		return nil, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "limit must be a positive number",
+		}
+	}
+
+	sales, err := store.Reports.TopFoods(ctx, reportRange, limit)
+	if err != nil {
+		return nil, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while reporting top foods",
+		}
+	}
+
+	return sales, nil
+}
+
+func GetCategoryReport(c *gin.Context) ([]models.CategorySales, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	reportRange, err := reportRange(c)
+	if err != nil {
+		return nil, err
+	}
+
+	sales, err := store.Reports.SalesByCategory(ctx, reportRange)
+	if err != nil {
+		return nil, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while reporting sales per category",
+		}
+	}
+
+	return sales, nil
+}
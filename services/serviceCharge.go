@@ -0,0 +1,53 @@
+package services
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/EnesDemirtas/restaurant-management/models"
+)
+
+// serviceChargeSettings is the service charge added to the bill of large
+// parties, configured with SERVICE_CHARGE_RATE (a percentage of the
+// discounted subtotal), SERVICE_CHARGE_MIN_GUESTS and SERVICE_CHARGE_TAXABLE.
+type serviceChargeSettings struct {
+	Rate      float64
+	MinGuests int
+	Taxable   bool
+}
+
+func loadServiceChargeSettings() serviceChargeSettings {
+	var settings serviceChargeSettings
+
+	settings.Rate, _ = strconv.ParseFloat(os.Getenv("SERVICE_CHARGE_RATE"), 64)
+	settings.MinGuests, _ = strconv.Atoi(os.Getenv("SERVICE_CHARGE_MIN_GUESTS"))
+	settings.Taxable, _ = strconv.ParseBool(os.Getenv("SERVICE_CHARGE_TAXABLE"))
+
+	return settings
+}
+
+// serviceChargeLine returns the service charge for an order served at the
+// table, or nil if the party is too small for one.
+func serviceChargeLine(table models.Table, subtotal models.Money) *invoiceLine {
+	settings := loadServiceChargeSettings()
+
+	if settings.Rate <= 0 || table.NumberOfGuests == nil || *table.NumberOfGuests < settings.MinGuests {
+		return nil
+	}
+
+	amount := subtotal.Percent(settings.Rate)
+	if amount.Amount <= 0 {
+		return nil
+	}
+
+	return &invoiceLine{
+		InvoiceLine: models.InvoiceLine{
+			FoodName:  "Service charge",
+			Quantity:  1,
+			UnitPrice: amount,
+			Amount:    amount,
+		},
+		ServiceCharge: true,
+		TaxExempt:     !settings.Taxable,
+	}
+}
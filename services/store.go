@@ -0,0 +1,26 @@
+package services
+
+import "github.com/EnesDemirtas/restaurant-management/repositories"
+
+var store repositories.Store
+
+// UseStore sets the repositories every service reads from and writes to. It
+// must be called before the router starts serving requests.
+func UseStore(s repositories.Store) {
+	store = s
+}
+
+// paginate mirrors Mongo's $slice: it returns at most recordPerPage items
+// starting at startIndex, or nothing when startIndex is past the end.
+func paginate[T any](items []T, startIndex, recordPerPage int) []T {
+	if startIndex < 0 || startIndex >= len(items) {
+		return []T{}
+	}
+
+	endIndex := startIndex + recordPerPage
+	if endIndex > len(items) {
+		endIndex = len(items)
+	}
+
+	return items[startIndex:endIndex]
+}
@@ -0,0 +1,364 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/EnesDemirtas/restaurant-management/helpers"
+	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// taxBreakdown is the result of applying tax rates to the lines of an order.
+type taxBreakdown struct {
+	Subtotal      models.Money
+	ServiceCharge models.Money
+	TaxLines      []models.InvoiceTaxLine
+	TaxTotal      models.Money
+	GrandTotal    models.Money
+}
+
+func GetTaxRates(c *gin.Context) ([]models.TaxRate, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	allTaxRates, err := store.TaxRates.FindAll(ctx)
+	if err != nil {
+		return nil, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while listing tax rates",
+		}
+	}
+
+	return allTaxRates, nil
+}
+
+func GetTaxRate(c *gin.Context) (models.TaxRate, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	taxRate, err := store.TaxRates.FindByID(ctx, c.Param("id"))
+	if err != nil {
+		return models.TaxRate{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "tax rate was not found",
+		}
+	}
+
+	return taxRate, nil
+}
+
+func CreateTaxRate(c *gin.Context) (models.TaxRate, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	var taxRate models.TaxRate
+
+	if err := c.BindJSON(&taxRate); err != nil {
+		return models.TaxRate{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	validationErr := validate.Struct(taxRate)
+	if validationErr != nil {
+		return models.TaxRate{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: validationErr.Error(),
+		}
+	}
+
+	if taxRate.Active == nil {
+		active := true
+		taxRate.Active = &active
+	}
+
+	if err := validateTaxRate(taxRate); err != nil {
+		return models.TaxRate{}, err
+	}
+
+	taxRate.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	taxRate.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	taxRate.ID = primitive.NewObjectID()
+	taxRate.TaxRateID = taxRate.ID.Hex()
+
+	insertErr := store.TaxRates.Insert(ctx, taxRate)
+	if insertErr != nil {
+		return models.TaxRate{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "tax rate was not created",
+		}
+	}
+
+	return taxRate, nil
+}
+
+func UpdateTaxRate(c *gin.Context) (models.TaxRate, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	var taxRate models.TaxRate
+
+	if err := c.BindJSON(&taxRate); err != nil {
+		return models.TaxRate{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	foundTaxRate, err := store.TaxRates.FindByID(ctx, c.Param("id"))
+	if err != nil {
+		return models.TaxRate{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "tax rate was not found",
+		}
+	}
+
+	if taxRate.Name != nil {
+		foundTaxRate.Name = taxRate.Name
+	}
+
+	if taxRate.Rate != nil {
+		foundTaxRate.Rate = taxRate.Rate
+	}
+
+	if taxRate.Inclusive != nil {
+		foundTaxRate.Inclusive = taxRate.Inclusive
+	}
+
+	if taxRate.Compound != nil {
+		foundTaxRate.Compound = taxRate.Compound
+	}
+
+	if taxRate.Priority != nil {
+		foundTaxRate.Priority = taxRate.Priority
+	}
+
+	if taxRate.Categories != nil {
+		foundTaxRate.Categories = taxRate.Categories
+	}
+
+	if taxRate.MenuIDs != nil {
+		foundTaxRate.MenuIDs = taxRate.MenuIDs
+	}
+
+	if taxRate.Active != nil {
+		foundTaxRate.Active = taxRate.Active
+	}
+
+	validationErr := validate.Struct(foundTaxRate)
+	if validationErr != nil {
+		return models.TaxRate{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: validationErr.Error(),
+		}
+	}
+
+	if err := validateTaxRate(foundTaxRate); err != nil {
+		return models.TaxRate{}, err
+	}
+
+	foundTaxRate.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	err = store.TaxRates.Update(ctx, foundTaxRate)
+	if err != nil {
+		return models.TaxRate{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "tax rate update failed",
+		}
+	}
+
+	return foundTaxRate, nil
+}
+
+func validateTaxRate(taxRate models.TaxRate) error {
+	if isTrue(taxRate.Inclusive) && isTrue(taxRate.Compound) {
+		return helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "an inclusive tax cannot be compound",
+		}
+	}
+
+	return nil
+}
+
+// activeTaxRates returns the enabled tax rates in the order they are applied.
+func activeTaxRates(ctx context.Context) ([]models.TaxRate, error) {
+	allTaxRates, err := store.TaxRates.FindAll(ctx)
+	if err != nil {
+		return nil, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while listing tax rates",
+		}
+	}
+
+	var taxRates []models.TaxRate
+	for _, taxRate := range allTaxRates {
+		if taxRate.Active == nil || *taxRate.Active {
+			taxRates = append(taxRates, taxRate)
+		}
+	}
+
+	sort.SliceStable(taxRates, func(i, j int) bool {
+		return intValue(taxRates[i].Priority) < intValue(taxRates[j].Priority)
+	})
+
+	return taxRates, nil
+}
+
+func taxApplies(taxRate models.TaxRate, line invoiceLine) bool {
+	if line.TaxExempt {
+		return false
+	}
+
+	if len(taxRate.Categories) == 0 && len(taxRate.MenuIDs) == 0 {
+		return true
+	}
+
+	for _, category := range taxRate.Categories {
+		if category == line.Category {
+			return true
+		}
+	}
+
+	for _, menuId := range taxRate.MenuIDs {
+		if menuId == line.MenuID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// taxAmounts holds the result of taxing some lines, with the taxable amount
+// and tax charged for each rate, in the order of the rates.
+type taxAmounts struct {
+	Subtotal      models.Money
+	ServiceCharge models.Money
+	Taxable       []models.Money
+	Amounts       []models.Money
+	Applied       []bool
+}
+
+// applyTaxes applies the tax rates, already in priority order, to every line.
+// Inclusive taxes are first taken out of the line amount to get its net price,
+// then every tax is charged on that net price, compound ones on the net price
+// plus the taxes charged before them on the same line. The service charge is
+// already a net amount and is kept apart from the subtotal. The net price and
+// each tax of every line are rounded to cents before they are added up, so
+// the totals are the sums of what each line shows.
+func applyTaxes(lines []invoiceLine, taxRates []models.TaxRate) taxAmounts {
+	amounts := taxAmounts{
+		Subtotal:      sumMoney(),
+		ServiceCharge: sumMoney(),
+		Taxable:       make([]models.Money, len(taxRates)),
+		Amounts:       make([]models.Money, len(taxRates)),
+		Applied:       make([]bool, len(taxRates)),
+	}
+	for i := range taxRates {
+		amounts.Taxable[i] = sumMoney()
+		amounts.Amounts[i] = sumMoney()
+	}
+
+	for _, line := range lines {
+		inclusiveRate := 0.0
+		for _, taxRate := range taxRates {
+			if isTrue(taxRate.Inclusive) && taxApplies(taxRate, line) {
+				inclusiveRate += *taxRate.Rate
+			}
+		}
+
+		net := line.amount().WithoutPercent(inclusiveRate)
+		if line.ServiceCharge {
+			net = line.amount()
+			amounts.ServiceCharge = amounts.ServiceCharge.Add(net)
+		} else {
+			amounts.Subtotal = amounts.Subtotal.Add(net)
+		}
+
+		taxed := sumMoney()
+		for i, taxRate := range taxRates {
+			if !taxApplies(taxRate, line) {
+				continue
+			}
+
+			base := net
+			if isTrue(taxRate.Compound) {
+				base = base.Add(taxed)
+			}
+
+			amount := base.Percent(*taxRate.Rate)
+			taxed = taxed.Add(amount)
+
+			amounts.Applied[i] = true
+			amounts.Taxable[i] = amounts.Taxable[i].Add(base)
+			amounts.Amounts[i] = amounts.Amounts[i].Add(amount)
+		}
+	}
+
+	return amounts
+}
+
+// calculateTaxes taxes the lines and adds up the tax lines and totals.
+func calculateTaxes(lines []invoiceLine, taxRates []models.TaxRate) taxBreakdown {
+	amounts := applyTaxes(lines, taxRates)
+
+	breakdown := taxBreakdown{
+		Subtotal:      amounts.Subtotal,
+		ServiceCharge: amounts.ServiceCharge,
+		TaxLines:      []models.InvoiceTaxLine{},
+	}
+
+	for i, taxRate := range taxRates {
+		if !amounts.Applied[i] {
+			continue
+		}
+
+		taxLine := invoiceTaxLine(taxRate)
+		taxLine.TaxableAmount = amounts.Taxable[i]
+		taxLine.Amount = amounts.Amounts[i]
+
+		breakdown.TaxLines = append(breakdown.TaxLines, taxLine)
+	}
+
+	breakdown.total()
+
+	return breakdown
+}
+
+// total sums the tax lines into the tax total, and adds it to the subtotal and
+// service charge for the grand total.
+func (b *taxBreakdown) total() {
+	b.TaxTotal = sumMoney()
+	for _, taxLine := range b.TaxLines {
+		b.TaxTotal = b.TaxTotal.Add(taxLine.Amount)
+	}
+
+	b.GrandTotal = sumMoney(b.Subtotal, b.ServiceCharge, b.TaxTotal)
+}
+
+func invoiceTaxLine(taxRate models.TaxRate) models.InvoiceTaxLine {
+	return models.InvoiceTaxLine{
+		TaxRateID: taxRate.TaxRateID,
+		Name:      *taxRate.Name,
+		Rate:      *taxRate.Rate,
+		Inclusive: isTrue(taxRate.Inclusive),
+		Compound:  isTrue(taxRate.Compound),
+	}
+}
+
+func isTrue(value *bool) bool {
+	return value != nil && *value
+}
+
+func intValue(value *int) int {
+	if value == nil {
+		return 0
+	}
+
+	return *value
+}
@@ -2,23 +2,51 @@ package services
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
-	"github.com/EnesDemirtas/restaurant-management/database"
 	"github.com/EnesDemirtas/restaurant-management/helpers"
 	"github.com/EnesDemirtas/restaurant-management/models"
+	"github.com/EnesDemirtas/restaurant-management/repositories"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var userCollection *mongo.Collection = database.OpenCollection(database.Client, "user")
 var validate = validator.New()
 
+type RefreshRequest struct {
+	RefreshToken *string `json:"refresh_token" validate:"required"`
+}
+
+type UserRoleUpdate struct {
+	Role *string `json:"role" validate:"required,eq=admin|eq=manager|eq=waiter|eq=kitchen|eq=cashier"`
+}
+
+// UserProfile is what is shown of a user, leaving out their password and tokens.
+type UserProfile struct {
+	FirstName *string   `json:"first_name"`
+	LastName  *string   `json:"last_name"`
+	Email     *string   `json:"email"`
+	Avatar    *string   `json:"avatar"`
+	Phone     *string   `json:"phone"`
+	Role      *string   `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    string    `json:"user_id"`
+}
+
+// Session is the profile of a user who logged in or refreshed their tokens,
+// along with the tokens. It is the only place the tokens are handed out.
+type Session struct {
+	UserProfile
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
 func GetUsers(c *gin.Context) ([]bson.M, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
@@ -38,21 +66,7 @@ func GetUsers(c *gin.Context) ([]bson.M, error) {
 		startIndex = (page - 1) * recordPerPage
 	}
 
-	matchStage := bson.D{{Key: "$match", Value: bson.D{{}}}}
-	projectStage := bson.D{
-		{
-			Key: "$project", Value: bson.D{
-				{Key: "_id", Value: 0},
-				{Key: "total_count", Value: 1},
-				{Key: "user_items", Value: bson.D{{Key: "$slice", Value: []interface{}{"$data", startIndex, recordPerPage}}}},
-			},
-		},
-	}
-
-	result, err := userCollection.Aggregate(ctx, mongo.Pipeline{
-		matchStage, projectStage,
-	})
-
+	allUsers, err := store.Users.FindAll(ctx)
 	if err != nil {
 		return nil, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
@@ -60,37 +74,32 @@ func GetUsers(c *gin.Context) ([]bson.M, error) {
 		}
 	}
 
-	var allUsers []bson.M
-	if err := result.All(ctx, &allUsers); err != nil {
-		return nil, helpers.HttpError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		}
+	userItems := []UserProfile{}
+	for _, user := range paginate(allUsers, startIndex, recordPerPage) {
+		userItems = append(userItems, userProfile(user))
 	}
 
-	return allUsers, nil
+	return []bson.M{{
+		"total_count": len(allUsers),
+		"user_items":  userItems,
+	}}, nil
 }
 
-func GetUser(c *gin.Context) (models.User, error) {
+func GetUser(c *gin.Context) (UserProfile, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
-	userId := c.Param("user_id")
-
-	var user models.User
-
-	err := userCollection.FindOne(ctx, bson.M{
-		"user_id": userId,
-	}).Decode(&user)
+	userId := c.Param("id")
 
+	user, err := store.Users.FindByID(ctx, userId)
 	if err != nil {
-		return models.User{}, helpers.HttpError{
+		return UserProfile{}, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
 			Message: err.Error(),
 		}
 	}
 
-	return user, nil
+	return userProfile(user), nil
 }
 
 func SignUp(c *gin.Context) (interface{}, error) {
@@ -114,7 +123,7 @@ func SignUp(c *gin.Context) (interface{}, error) {
 		}
 	}
 
-	emailCount, err := userCollection.CountDocuments(ctx, bson.M{"email": user.Email})
+	emailCount, err := store.Users.CountByEmail(ctx, *user.Email)
 	if err != nil {
 		return nil, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
@@ -132,7 +141,7 @@ func SignUp(c *gin.Context) (interface{}, error) {
 	password := helpers.HashPassword(*user.Password)
 	user.Password = &password
 
-	phoneCount, err := userCollection.CountDocuments(ctx, bson.M{"phone": user.Phone})
+	phoneCount, err := store.Users.CountByPhone(ctx, *user.Phone)
 	if err != nil {
 		return nil, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
@@ -147,16 +156,37 @@ func SignUp(c *gin.Context) (interface{}, error) {
 		}
 	}
 
+	// Roles are only handed out by admins. The very first account is the
+	// exception, otherwise nobody could ever become an admin. Two accounts
+	// signing up at once may both find no users; only one of them is
+	// inserted as the first one and the other gets no role.
+	user.Role = nil
+
+	userCount, err := store.Users.Count(ctx)
+	if err != nil {
+		return nil, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while counting the users",
+		}
+	}
+
 	user.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 	user.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 	user.ID = primitive.NewObjectID()
 	user.UserID = user.ID.Hex()
 
-	token, refreshToken, _ := helpers.GenerateAllTokens(*user.Email, *user.FirstName, *user.LastName, user.UserID)
-	user.Token = &token
-	user.RefreshToken = &refreshToken
+	insertErr := repositories.ErrConflict
+	if userCount == 0 {
+		role := models.RoleAdmin
+		user.Role = &role
+		insertErr = store.Users.InsertFirst(ctx, user)
+	}
+
+	if errors.Is(insertErr, repositories.ErrConflict) {
+		user.Role = nil
+		insertErr = store.Users.Insert(ctx, user)
+	}
 
-	insertedID, insertErr := userCollection.InsertOne(ctx, user)
 	if insertErr != nil {
 		return nil, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
@@ -164,26 +194,32 @@ func SignUp(c *gin.Context) (interface{}, error) {
 		}
 	}
 
-	return insertedID.InsertedID, nil
+	return user.ID, nil
 }
 
-func Login(c *gin.Context) (models.User, error) {
+func Login(c *gin.Context) (Session, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
 	var user models.User
-	var foundUser models.User
 
 	if err := c.BindJSON(&user); err != nil {
-		return models.User{}, helpers.HttpError{
+		return Session{}, helpers.HttpError{
 			Code:    http.StatusBadRequest,
 			Message: err.Error(),
 		}
 	}
 
-	err := userCollection.FindOne(ctx, bson.M{"email": user.Email}).Decode(&foundUser)
+	if user.Email == nil || user.Password == nil {
+		return Session{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: "email and password are required",
+		}
+	}
+
+	foundUser, err := store.Users.FindByEmail(ctx, *user.Email)
 	if err != nil {
-		return models.User{}, helpers.HttpError{
+		return Session{}, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
 			Message: "user not found",
 		}
@@ -191,16 +227,220 @@ func Login(c *gin.Context) (models.User, error) {
 
 	passwordIsValid, msg := helpers.VerifyPassword(*user.Password, *foundUser.Password)
 	if !passwordIsValid {
-		return models.User{}, helpers.HttpError{
+		return Session{}, helpers.HttpError{
 			Code:    http.StatusInternalServerError,
 			Message: msg,
 		}
 	}
 
+	// Every login starts a session of its own, so that logging in on another
+	// device leaves the sessions already started alone.
+	family := primitive.NewObjectID().Hex()
 	token, refreshToken, _ := helpers.GenerateAllTokens(
-		*foundUser.Email, *foundUser.FirstName, *foundUser.LastName, foundUser.UserID)
+		*foundUser.Email, *foundUser.FirstName, *foundUser.LastName, foundUser.UserID, userRole(foundUser), family)
+
+	createdAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	session := models.UserSession{Family: family, RefreshToken: helpers.HashToken(refreshToken), CreatedAt: createdAt, UpdatedAt: createdAt}
+	err = store.Users.AddSession(ctx, foundUser.UserID, session, createdAt.Add(-helpers.RefreshTokenLifetime))
+	if err != nil {
+		return Session{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while storing the tokens",
+		}
+	}
+
+	return Session{UserProfile: userProfile(foundUser), Token: token, RefreshToken: refreshToken}, nil
+}
+
+// RefreshTokens exchanges a refresh token for a new pair of tokens. Every
+// refresh token can be used once: presenting one that was already rotated
+// means it leaked, so its session is revoked. The other sessions of the user
+// are left alone.
+func RefreshTokens(c *gin.Context) (Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	var refreshRequest RefreshRequest
+
+	if err := c.BindJSON(&refreshRequest); err != nil {
+		return Session{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	validationErr := validate.Struct(refreshRequest)
+	if validationErr != nil {
+		return Session{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: validationErr.Error(),
+		}
+	}
 
-	helpers.UpdateAllTokens(token, refreshToken, foundUser.UserID)
+	claims, msg := helpers.ValidateToken(*refreshRequest.RefreshToken)
+	if msg != "" {
+		return Session{}, helpers.HttpError{
+			Code:    http.StatusUnauthorized,
+			Message: msg,
+		}
+	}
+
+	if claims.TokenType != helpers.RefreshTokenType {
+		return Session{}, helpers.HttpError{
+			Code:    http.StatusUnauthorized,
+			Message: "this is not a refresh token",
+		}
+	}
+
+	foundUser, err := store.Users.FindByID(ctx, claims.Uid)
+	if err != nil || !hasSession(foundUser, claims.Family) {
+		return Session{}, helpers.HttpError{
+			Code:    http.StatusUnauthorized,
+			Message: "the refresh token has been revoked",
+		}
+	}
+
+	updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	token, refreshToken, _ := helpers.GenerateAllTokens(
+		*foundUser.Email, *foundUser.FirstName, *foundUser.LastName, foundUser.UserID, userRole(foundUser), claims.Family)
+
+	err = store.Users.RotateTokens(
+		ctx, foundUser.UserID, claims.Family, helpers.HashToken(*refreshRequest.RefreshToken), helpers.HashToken(refreshToken), updatedAt)
+	if errors.Is(err, repositories.ErrConflict) {
+		store.Users.RevokeTokens(ctx, foundUser.UserID, claims.Family, updatedAt)
+		return Session{}, helpers.HttpError{
+			Code:    http.StatusUnauthorized,
+			Message: "the refresh token was already used, please log in again",
+		}
+	}
 
-	return foundUser, nil
+	if err != nil {
+		return Session{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while storing the tokens",
+		}
+	}
+
+	return Session{UserProfile: userProfile(foundUser), Token: token, RefreshToken: refreshToken}, nil
+}
+
+// Logout revokes the tokens of the session the request was authenticated with.
+func Logout(c *gin.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	err := store.Users.RevokeTokens(ctx, c.GetString("uid"), c.GetString("family"), updatedAt)
+	if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		return helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "error occured while revoking the tokens",
+		}
+	}
+
+	return nil
+}
+
+// SessionRole returns the role the user has now, and whether tokens of the
+// given family may still be used, i.e. the user has neither logged out of
+// that session nor had it revoked. The role is read from the user rather than the token so
+// that a role change applies at once.
+func SessionRole(userId, family string) (string, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	user, err := store.Users.FindByID(ctx, userId)
+	if err != nil || !hasSession(user, family) {
+		return "", false
+	}
+
+	return userRole(user), true
+}
+
+func UpdateUserRole(c *gin.Context) (UserProfile, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	var roleUpdate UserRoleUpdate
+
+	if err := c.BindJSON(&roleUpdate); err != nil {
+		return UserProfile{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	validationErr := validate.Struct(roleUpdate)
+	if validationErr != nil {
+		return UserProfile{}, helpers.HttpError{
+			Code:    http.StatusBadRequest,
+			Message: validationErr.Error(),
+		}
+	}
+
+	userId := c.Param("id")
+
+	// An admin taking away their own role could leave nobody able to assign roles.
+	if userId == c.GetString("uid") {
+		return UserProfile{}, helpers.HttpError{
+			Code:    http.StatusForbidden,
+			Message: "you cannot change your own role",
+		}
+	}
+
+	user, err := store.Users.FindByID(ctx, userId)
+	if err != nil {
+		return UserProfile{}, helpers.HttpError{
+			Code:    http.StatusNotFound,
+			Message: "user not found",
+		}
+	}
+
+	user.Role = roleUpdate.Role
+	user.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	err = store.Users.UpdateRole(ctx, user.UserID, *user.Role, user.UpdatedAt)
+	if err != nil {
+		return UserProfile{}, helpers.HttpError{
+			Code:    http.StatusInternalServerError,
+			Message: "user role update failed",
+		}
+	}
+
+	return userProfile(user), nil
+}
+
+// hasSession reports whether the user has a session of the family.
+func hasSession(user models.User, family string) bool {
+	for _, session := range user.Sessions {
+		if session.Family == family {
+			return true
+		}
+	}
+
+	return false
+}
+
+func userRole(user models.User) string {
+	if user.Role == nil {
+		return ""
+	}
+
+	return *user.Role
+}
+
+func userProfile(user models.User) UserProfile {
+	return UserProfile{
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Email:     user.Email,
+		Avatar:    user.Avatar,
+		Phone:     user.Phone,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		UserID:    user.UserID,
+	}
 }